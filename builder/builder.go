@@ -16,19 +16,41 @@ const (
 
 type join struct {
 	joinType  string
-	joinTable string
+	joinTable interface{} // table name or sub query
 	joinCond  Cond
 }
 
+type limit struct {
+	limitN int
+	offset int
+}
+
+type setOp struct {
+	op      string // UNION, UNION ALL, INTERSECT or EXCEPT
+	builder *Builder
+}
+
 // Builder describes a SQL statement
 type Builder struct {
 	optype
-	tableName string
-	cond      Cond
-	selects   []string
-	joins     []join
-	inserts   Eq
-	updates   []Eq
+	dialect      string
+	tableName    string
+	subQuery     *Builder // derived table selected from
+	fromAlias    string
+	alias        string // alias of the builder used as a derived table
+	cond         Cond
+	selects      []string
+	joins        []join
+	inserts      []Eq
+	insertCols   []string
+	insertSelect *Builder
+	updates      []Eq
+	returning    []string
+	orderBy      string
+	groupBy      string
+	having       Cond
+	limit        *limit
+	setOps       []setOp
 }
 
 // Select creates a select Builder
@@ -37,10 +59,17 @@ func Select(cols ...string) *Builder {
 	return builder.Select(cols...)
 }
 
-// Insert creates an insert Builder
-func Insert(eq Eq) *Builder {
+// Insert creates an insert Builder of one or more rows
+func Insert(eqs ...Eq) *Builder {
 	builder := &Builder{cond: NewCond()}
-	return builder.Insert(eq)
+	return builder.Insert(eqs...)
+}
+
+// InsertSelect creates a builder inserting the rows selected by sub into the
+// columns cols
+func InsertSelect(sub *Builder, cols ...string) *Builder {
+	builder := &Builder{cond: NewCond()}
+	return builder.InsertSelect(sub, cols...)
 }
 
 // Update creates an update Builder
@@ -61,9 +90,26 @@ func (b *Builder) Where(cond Cond) *Builder {
 	return b
 }
 
-// From sets the table name
-func (b *Builder) From(tableName string) *Builder {
-	b.tableName = tableName
+// From sets the table name, or the sub query selected from, and its alias
+func (b *Builder) From(subject interface{}, alias ...string) *Builder {
+	switch v := subject.(type) {
+	case string:
+		b.tableName = v
+		b.subQuery = nil
+	case *Builder:
+		b.tableName = ""
+		b.subQuery = v
+	}
+	if len(alias) > 0 {
+		b.fromAlias = alias[0]
+	}
+	return b
+}
+
+// As sets the alias of the builder when it's used as a derived table by From
+// or Join
+func (b *Builder) As(alias string) *Builder {
+	b.alias = alias
 	return b
 }
 
@@ -73,8 +119,9 @@ func (b *Builder) Into(tableName string) *Builder {
 	return b
 }
 
-// Join sets join table and contions
-func (b *Builder) Join(joinType, joinTable string, joinCond interface{}) *Builder {
+// Join sets join table and contions, the table is a name optionally followed
+// by its alias, e.g. "table2 t2", or a sub query aliased by As
+func (b *Builder) Join(joinType string, joinTable, joinCond interface{}) *Builder {
 	switch joinCond.(type) {
 	case Cond:
 		b.joins = append(b.joins, join{joinType, joinTable, joinCond.(Cond)})
@@ -86,27 +133,27 @@ func (b *Builder) Join(joinType, joinTable string, joinCond interface{}) *Builde
 }
 
 // InnerJoin sets inner join
-func (b *Builder) InnerJoin(joinTable string, joinCond interface{}) *Builder {
+func (b *Builder) InnerJoin(joinTable, joinCond interface{}) *Builder {
 	return b.Join("INNER", joinTable, joinCond)
 }
 
 // LeftJoin sets left join SQL
-func (b *Builder) LeftJoin(joinTable string, joinCond interface{}) *Builder {
+func (b *Builder) LeftJoin(joinTable, joinCond interface{}) *Builder {
 	return b.Join("LEFT", joinTable, joinCond)
 }
 
 // RightJoin sets right join SQL
-func (b *Builder) RightJoin(joinTable string, joinCond interface{}) *Builder {
+func (b *Builder) RightJoin(joinTable, joinCond interface{}) *Builder {
 	return b.Join("RIGHT", joinTable, joinCond)
 }
 
 // CrossJoin sets cross join SQL
-func (b *Builder) CrossJoin(joinTable string, joinCond interface{}) *Builder {
+func (b *Builder) CrossJoin(joinTable, joinCond interface{}) *Builder {
 	return b.Join("CROSS", joinTable, joinCond)
 }
 
 // FullJoin sets full join SQL
-func (b *Builder) FullJoin(joinTable string, joinCond interface{}) *Builder {
+func (b *Builder) FullJoin(joinTable, joinCond interface{}) *Builder {
 	return b.Join("FULL", joinTable, joinCond)
 }
 
@@ -117,6 +164,75 @@ func (b *Builder) Select(cols ...string) *Builder {
 	return b
 }
 
+// OrderBy sets the ORDER BY clause
+func (b *Builder) OrderBy(orderBy string) *Builder {
+	b.orderBy = orderBy
+	return b
+}
+
+// GroupBy sets the GROUP BY clause
+func (b *Builder) GroupBy(groupBy string) *Builder {
+	b.groupBy = groupBy
+	return b
+}
+
+// Having sets the HAVING condition, a Cond or a string
+func (b *Builder) Having(having interface{}) *Builder {
+	switch v := having.(type) {
+	case Cond:
+		b.having = v
+	case string:
+		b.having = Expr(v)
+	}
+	return b
+}
+
+// Limit sets the maximum number of rows selected and the number of rows
+// skipped, it's rendered according to the dialect, see Dialect
+func (b *Builder) Limit(limitN int, offset ...int) *Builder {
+	b.limit = &limit{limitN: limitN}
+	if len(offset) > 0 {
+		b.limit.offset = offset[0]
+	}
+	return b
+}
+
+// Union appends a select builder combined by UNION
+func (b *Builder) Union(other *Builder) *Builder {
+	return b.setOp("UNION", other)
+}
+
+// UnionAll appends a select builder combined by UNION ALL
+func (b *Builder) UnionAll(other *Builder) *Builder {
+	return b.setOp("UNION ALL", other)
+}
+
+// Intersect appends a select builder combined by INTERSECT
+func (b *Builder) Intersect(other *Builder) *Builder {
+	return b.setOp("INTERSECT", other)
+}
+
+// Except appends a select builder combined by EXCEPT, which is MINUS on
+// Oracle
+func (b *Builder) Except(other *Builder) *Builder {
+	return b.setOp("EXCEPT", other)
+}
+
+func (b *Builder) setOp(op string, other *Builder) *Builder {
+	b.setOps = append(b.setOps, setOp{op, other})
+	return b
+}
+
+// Dialect sets the database the SQL is generated for, one of the MYSQL,
+// POSTGRES, SQLITE, MSSQL and ORACLE constants: the names are quoted by its
+// quote character, the placeholders returned by ToSQL are its own and the
+// limit is written as TOP, OFFSET FETCH or on ROWNUM when the database has
+// no LIMIT. The sub queries without dialect inherit it.
+func (b *Builder) Dialect(dialect string) *Builder {
+	b.dialect = dialect
+	return b
+}
+
 // And sets AND condition
 func (b *Builder) And(cond Cond) *Builder {
 	b.cond = And(b.cond, cond)
@@ -129,13 +245,32 @@ func (b *Builder) Or(cond Cond) *Builder {
 	return b
 }
 
-// Insert sets insert SQL
-func (b *Builder) Insert(eq Eq) *Builder {
-	b.inserts = eq
+// Insert sets insert SQL of one or more rows, which have the same columns
+// written in the order of their names
+func (b *Builder) Insert(eqs ...Eq) *Builder {
+	b.inserts = eqs
+	b.insertSelect = nil
+	b.optype = insertType
+	return b
+}
+
+// InsertSelect sets insert SQL of the rows selected by sub into the columns
+// cols
+func (b *Builder) InsertSelect(sub *Builder, cols ...string) *Builder {
+	b.inserts = nil
+	b.insertCols = cols
+	b.insertSelect = sub
 	b.optype = insertType
 	return b
 }
 
+// Returning sets the columns returned by the insert, the update or the
+// delete: RETURNING on PostgreSQL and SQLite, OUTPUT on MSSQL
+func (b *Builder) Returning(cols ...string) *Builder {
+	b.returning = cols
+	return b
+}
+
 // Update sets update SQL
 func (b *Builder) Update(updates ...Eq) *Builder {
 	b.updates = updates
@@ -168,19 +303,34 @@ func (b *Builder) WriteTo(w Writer) error {
 	return ErrNotSupportType
 }
 
-// ToSQL convert a builder to SQL and args
+// ToSQL convert a builder to SQL and args, the placeholders are converted to
+// the ones of the dialect if any
 func (b *Builder) ToSQL(keyFilters ...func(string) string) (string, []interface{}, error) {
 	var keyFilter func(string) string
 	if len(keyFilters) > 0 {
 		keyFilter = keyFilters[0]
 	}
-	w := NewWriter()
-	w.keyFilter = keyFilter
+	w := NewDialectWriter(b.dialect, keyFilter)
 	if err := b.WriteTo(w); err != nil {
 		return "", nil, err
 	}
 
-	return w.writer.String(), w.args, nil
+	return ConvertPlaceholder(w.writer.String(), b.dialect), w.args, nil
+}
+
+// ToBoundSQL convert a builder to SQL whose args are written as literals of
+// the dialect, it's meant to log the statements, not to run them
+func (b *Builder) ToBoundSQL(keyFilters ...func(string) string) (string, error) {
+	var keyFilter func(string) string
+	if len(keyFilters) > 0 {
+		keyFilter = keyFilters[0]
+	}
+	w := NewDialectWriter(b.dialect, keyFilter)
+	if err := b.WriteTo(w); err != nil {
+		return "", err
+	}
+
+	return ConvertToBoundSQL(w.writer.String(), w.args, b.dialect)
 }
 
 // ToSQL convert a builder or condtions to SQL and args
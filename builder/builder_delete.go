@@ -14,9 +14,105 @@ func (b *Builder) deleteWriteTo(w Writer) error {
 		return errors.New("no table indicated")
 	}
 
-	if _, err := fmt.Fprintf(w, "DELETE FROM %s WHERE ", w.Key(b.tableName)); err != nil {
+	dialect := b.dialectOf(w)
+	switch dialect {
+	case POSTGRES, SQLITE, ORACLE:
+		if b.limit != nil || len(b.orderBy) > 0 {
+			return ErrNotSupportDialect
+		}
+	}
+
+	if _, err := fmt.Fprint(w, "DELETE "); err != nil {
 		return err
 	}
+	cond := b.cond
+	switch dialect {
+	case MSSQL:
+		// DELETE [TOP (n)] [t OUTPUT ... FROM t JOIN ...|FROM t OUTPUT ...]
+		if b.limit != nil {
+			if _, err := fmt.Fprintf(w, "TOP (%d) ", b.limit.limitN); err != nil {
+				return err
+			}
+		}
+		if len(b.joins) > 0 {
+			if err := b.targetWriteTo(w); err != nil {
+				return err
+			}
+			if err := b.returningWriteTo(w, dialect, "DELETED"); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprint(w, " FROM "); err != nil {
+				return err
+			}
+			if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+				return err
+			}
+			if err := b.joinsWriteTo(w); err != nil {
+				return err
+			}
+		} else {
+			if _, err := fmt.Fprint(w, "FROM "); err != nil {
+				return err
+			}
+			if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+				return err
+			}
+			if err := b.returningWriteTo(w, dialect, "DELETED"); err != nil {
+				return err
+			}
+		}
+	case POSTGRES, SQLITE, ORACLE:
+		// DELETE FROM t [USING j1, j2 WHERE <join conditions>]
+		if _, err := fmt.Fprint(w, "FROM "); err != nil {
+			return err
+		}
+		if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+			return err
+		}
+		if len(b.joins) > 0 {
+			if dialect != POSTGRES {
+				return ErrNotSupportDialect
+			}
+			var err error
+			if cond, err = b.fromJoinsWriteTo(w, " USING "); err != nil {
+				return err
+			}
+		}
+	default:
+		// DELETE [t FROM t JOIN ...|FROM t] [ORDER BY] [LIMIT]
+		if len(b.joins) > 0 {
+			if err := b.targetWriteTo(w); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprint(w, " "); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprint(w, "FROM "); err != nil {
+			return err
+		}
+		if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+			return err
+		}
+		if err := b.joinsWriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	if cond.IsValid() {
+		if _, err := fmt.Fprint(w, " WHERE "); err != nil {
+			return err
+		}
+		if err := cond.WriteTo(w); err != nil {
+			return err
+		}
+	}
 
-	return b.cond.WriteTo(w)
+	if dialect == MSSQL {
+		return nil
+	}
+	if err := b.orderLimitWriteTo(w); err != nil {
+		return err
+	}
+	return b.returningWriteTo(w, dialect, "")
 }
@@ -5,60 +5,181 @@
 package builder
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"strings"
 )
 
 func (b *Builder) insertWriteTo(w Writer) error {
 	if len(b.tableName) <= 0 {
 		return errors.New("no table indicated")
 	}
-	if len(b.inserts) <= 0 {
-		return errors.New("no column to be update")
+	if b.insertSelect == nil && (len(b.inserts) <= 0 || len(b.inserts[0]) <= 0) {
+		return errors.New("no column to be insert")
 	}
 
-	if _, err := fmt.Fprintf(w, "INSERT INTO %s (", w.Key(b.tableName)); err != nil {
-		return err
+	dialect := b.dialectOf(w)
+	cols := b.insertCols
+	if b.insertSelect == nil {
+		cols = b.inserts[0].sortedKeys()
+		for _, row := range b.inserts[1:] {
+			if len(row) != len(cols) {
+				return ErrInsertColumns
+			}
+			for _, col := range cols {
+				if _, ok := row[col]; !ok {
+					return ErrInsertColumns
+				}
+			}
+		}
 	}
 
-	var args = make([]interface{}, 0)
-	var bs []byte
-	var valBuffer = bytes.NewBuffer(bs)
-	var i = 0
-	for col, value := range b.inserts {
-		fmt.Fprint(w, col)
-		if e, ok := value.(expr); ok {
-			fmt.Fprint(valBuffer, e.sql)
-			args = append(args, e.args...)
-		} else {
-			fmt.Fprint(valBuffer, "?")
-			args = append(args, value)
+	if dialect == ORACLE && len(b.inserts) > 1 {
+		return b.oracleInsertWriteTo(w, cols)
+	}
+
+	if _, err := fmt.Fprint(w, "INSERT INTO "); err != nil {
+		return err
+	}
+	if err := b.insertIntoWriteTo(w, cols); err != nil {
+		return err
+	}
+	if dialect == MSSQL {
+		if err := b.returningWriteTo(w, dialect, "INSERTED"); err != nil {
+			return err
 		}
+	}
 
-		if i != len(b.inserts)-1 {
-			if _, err := fmt.Fprint(w, ","); err != nil {
-				return err
+	if b.insertSelect != nil {
+		if _, err := fmt.Fprint(w, " "); err != nil {
+			return err
+		}
+		if err := b.insertSelect.WriteTo(w); err != nil {
+			return err
+		}
+	} else {
+		if _, err := fmt.Fprint(w, " VALUES "); err != nil {
+			return err
+		}
+		for i, row := range b.inserts {
+			if i > 0 {
+				if _, err := fmt.Fprint(w, ","); err != nil {
+					return err
+				}
 			}
-			if _, err := fmt.Fprint(valBuffer, ","); err != nil {
+			if err := row.valuesWriteTo(w, cols); err != nil {
 				return err
 			}
 		}
-		i = i + 1
 	}
 
-	if _, err := fmt.Fprint(w, ") Values ("); err != nil {
-		return err
+	if dialect != MSSQL {
+		return b.returningWriteTo(w, dialect, "")
 	}
+	return nil
+}
 
-	if _, err := w.Write(valBuffer.Bytes()); err != nil {
+// insertIntoWriteTo writes the table and the columns inserted
+func (b *Builder) insertIntoWriteTo(w Writer, cols []string) error {
+	if _, err := fmt.Fprint(w, w.Key(b.tableName)); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprint(w, ")"); err != nil {
+	if len(cols) == 0 {
+		return nil
+	}
+	keys := make([]string, len(cols))
+	for i, col := range cols {
+		keys[i] = w.Key(col)
+	}
+	_, err := fmt.Fprintf(w, " (%s)", strings.Join(keys, ","))
+	return err
+}
+
+// oracleInsertWriteTo writes the rows as INSERT ALL since Oracle doesn't
+// insert several rows by VALUES
+func (b *Builder) oracleInsertWriteTo(w Writer, cols []string) error {
+	if len(b.returning) > 0 {
+		return ErrNotSupportDialect
+	}
+	if _, err := fmt.Fprint(w, "INSERT ALL"); err != nil {
 		return err
 	}
+	for _, row := range b.inserts {
+		if _, err := fmt.Fprint(w, " INTO "); err != nil {
+			return err
+		}
+		if err := b.insertIntoWriteTo(w, cols); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, " VALUES "); err != nil {
+			return err
+		}
+		if err := row.valuesWriteTo(w, cols); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, " SELECT 1 FROM DUAL")
+	return err
+}
 
-	w.Append(args...)
+// valuesWriteTo writes the values of the columns cols of the row
+func (eq Eq) valuesWriteTo(w Writer, cols []string) error {
+	if _, err := fmt.Fprint(w, "("); err != nil {
+		return err
+	}
+	for i, col := range cols {
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ","); err != nil {
+				return err
+			}
+		}
+		switch v := eq[col].(type) {
+		case expr:
+			if _, err := fmt.Fprint(w, v.sql); err != nil {
+				return err
+			}
+			w.Append(v.args...)
+		case *Builder:
+			if _, err := fmt.Fprint(w, "("); err != nil {
+				return err
+			}
+			if err := v.WriteTo(w); err != nil {
+				return err
+			}
+			if _, err := fmt.Fprint(w, ")"); err != nil {
+				return err
+			}
+		default:
+			if _, err := fmt.Fprint(w, "?"); err != nil {
+				return err
+			}
+			w.Append(v)
+		}
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
 
-	return nil
+// returningWriteTo writes the columns returned by the statement, as RETURNING
+// or, on MSSQL, as OUTPUT of the pseudo table prefix
+func (b *Builder) returningWriteTo(w Writer, dialect, prefix string) error {
+	if len(b.returning) == 0 {
+		return nil
+	}
+	cols := make([]string, len(b.returning))
+	for i, col := range b.returning {
+		cols[i] = w.Key(col)
+	}
+	switch dialect {
+	case MYSQL, ORACLE:
+		return ErrNotSupportDialect
+	case MSSQL:
+		for i, col := range cols {
+			cols[i] = prefix + "." + col
+		}
+		_, err := fmt.Fprint(w, " OUTPUT ", strings.Join(cols, ","))
+		return err
+	}
+	_, err := fmt.Fprint(w, " RETURNING ", strings.Join(cols, ","))
+	return err
 }
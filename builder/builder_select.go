@@ -7,14 +7,52 @@ package builder
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 func (b *Builder) selectWriteTo(w Writer) error {
-	if len(b.tableName) <= 0 {
+	if len(b.tableName) <= 0 && b.subQuery == nil {
 		return errors.New("no table indicated")
 	}
 
-	if _, err := fmt.Fprint(w, "SELECT "); err != nil {
+	dialect := b.dialectOf(w)
+	if b.limit != nil {
+		switch dialect {
+		case ORACLE:
+			return b.oracleLimitWriteTo(w)
+		case MSSQL:
+			return b.mssqlLimitWriteTo(w)
+		}
+	}
+
+	if err := b.selectCoreWriteTo(w, ""); err != nil {
+		return err
+	}
+	if err := b.setOpsWriteTo(w, dialect); err != nil {
+		return err
+	}
+	if len(b.orderBy) > 0 {
+		if _, err := fmt.Fprint(w, " ORDER BY ", b.orderBy); err != nil {
+			return err
+		}
+	}
+	if b.limit != nil {
+		if _, err := fmt.Fprintf(w, " LIMIT %d", b.limit.limitN); err != nil {
+			return err
+		}
+		if b.limit.offset > 0 {
+			if _, err := fmt.Fprintf(w, " OFFSET %d", b.limit.offset); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// selectCoreWriteTo writes the statement up to the HAVING clause, top is
+// written before the columns
+func (b *Builder) selectCoreWriteTo(w Writer, top string) error {
+	if _, err := fmt.Fprint(w, "SELECT ", top); err != nil {
 		return err
 	}
 	if len(b.selects) > 0 {
@@ -34,20 +72,193 @@ func (b *Builder) selectWriteTo(w Writer) error {
 		}
 	}
 
-	if _, err := fmt.Fprintf(w, " FROM %s", w.Key(b.tableName)); err != nil {
+	if _, err := fmt.Fprint(w, " FROM "); err != nil {
+		return err
+	}
+	var from interface{} = b.tableName
+	if b.subQuery != nil {
+		from = b.subQuery
+	}
+	if err := tableWriteTo(w, from, b.fromAlias); err != nil {
 		return err
 	}
 
-	for _, v := range b.joins {
-		fmt.Fprintf(w, " %s JOIN %s ON ", v.joinType, w.Key(v.joinTable))
-		if err := v.joinCond.WriteTo(w); err != nil {
+	if err := b.joinsWriteTo(w); err != nil {
+		return err
+	}
+
+	if b.cond.IsValid() {
+		if _, err := fmt.Fprint(w, " WHERE "); err != nil {
+			return err
+		}
+		if err := b.cond.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	if len(b.groupBy) > 0 {
+		if _, err := fmt.Fprint(w, " GROUP BY ", b.groupBy); err != nil {
+			return err
+		}
+	}
+	if b.having != nil && b.having.IsValid() {
+		if _, err := fmt.Fprint(w, " HAVING "); err != nil {
+			return err
+		}
+		if err := b.having.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setOpsWriteTo writes the builders combined by UNION, INTERSECT or EXCEPT,
+// those having their own order, limit or combinations are selected as
+// derived tables
+func (b *Builder) setOpsWriteTo(w Writer, dialect string) error {
+	for i, v := range b.setOps {
+		op := v.op
+		if op == "EXCEPT" && dialect == ORACLE {
+			op = "MINUS"
+		}
+		if _, err := fmt.Fprintf(w, " %s ", op); err != nil {
+			return err
+		}
+		if len(v.builder.orderBy) == 0 && v.builder.limit == nil && len(v.builder.setOps) == 0 {
+			if err := v.builder.WriteTo(w); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprint(w, "SELECT * FROM ("); err != nil {
+			return err
+		}
+		if err := v.builder.WriteTo(w); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, ") u%d", i+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mssqlLimitWriteTo writes the limit as TOP, or as OFFSET FETCH which needs
+// an ORDER BY clause
+func (b *Builder) mssqlLimitWriteTo(w Writer) error {
+	if b.limit.offset <= 0 && len(b.setOps) == 0 {
+		if err := b.selectCoreWriteTo(w, fmt.Sprintf("TOP %d ", b.limit.limitN)); err != nil {
 			return err
 		}
+		if len(b.orderBy) > 0 {
+			if _, err := fmt.Fprint(w, " ORDER BY ", b.orderBy); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
 
-	if _, err := fmt.Fprint(w, " WHERE "); err != nil {
+	if err := b.selectCoreWriteTo(w, ""); err != nil {
+		return err
+	}
+	if err := b.setOpsWriteTo(w, MSSQL); err != nil {
 		return err
 	}
+	orderBy := b.orderBy
+	if len(orderBy) == 0 {
+		if len(b.setOps) > 0 {
+			orderBy = "1"
+		} else {
+			orderBy = "(SELECT NULL)"
+		}
+	}
+	_, err := fmt.Fprintf(w, " ORDER BY %s OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", orderBy, b.limit.offset, b.limit.limitN)
+	return err
+}
+
+// oracleLimitWriteTo writes the limit as a condition on the ROWNUM of the
+// statement selected as a derived table, the rows skipped by an offset are
+// numbered by an extra rn column
+func (b *Builder) oracleLimitWriteTo(w Writer) error {
+	inner := *b
+	inner.limit = nil
+	inner.dialect = ORACLE
+
+	if b.limit.offset <= 0 {
+		if _, err := fmt.Fprint(w, "SELECT * FROM ("); err != nil {
+			return err
+		}
+		if err := inner.selectWriteTo(w); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, ") WHERE ROWNUM <= %d", b.limit.limitN)
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, "SELECT * FROM (SELECT at.*, ROWNUM rn FROM ("); err != nil {
+		return err
+	}
+	if err := inner.selectWriteTo(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, ") at WHERE ROWNUM <= %d) WHERE rn > %d", b.limit.offset+b.limit.limitN, b.limit.offset)
+	return err
+}
+
+func (b *Builder) joinsWriteTo(w Writer) error {
+	for _, v := range b.joins {
+		if _, err := fmt.Fprintf(w, " %s JOIN ", v.joinType); err != nil {
+			return err
+		}
+		if err := tableWriteTo(w, v.joinTable, ""); err != nil {
+			return err
+		}
+		if v.joinCond == nil || !v.joinCond.IsValid() {
+			continue
+		}
+		if _, err := fmt.Fprint(w, " ON "); err != nil {
+			return err
+		}
+		if err := v.joinCond.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return b.cond.WriteTo(w)
+// tableWriteTo writes a table name optionally followed by its alias, e.g.
+// "table1 t1", or a sub query with the alias given by As
+func tableWriteTo(w Writer, table interface{}, alias string) error {
+	switch v := table.(type) {
+	case string:
+		if len(alias) == 0 {
+			if fields := strings.Fields(v); len(fields) > 1 {
+				v, alias = fields[0], fields[len(fields)-1]
+			}
+		}
+		if _, err := fmt.Fprint(w, w.Key(v)); err != nil {
+			return err
+		}
+	case *Builder:
+		if len(alias) == 0 {
+			alias = v.alias
+		}
+		if _, err := fmt.Fprint(w, "("); err != nil {
+			return err
+		}
+		if err := v.WriteTo(w); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprint(w, ")"); err != nil {
+			return err
+		}
+	default:
+		return ErrNotSupportType
+	}
+	if len(alias) > 0 {
+		if _, err := fmt.Fprint(w, " ", w.Key(alias)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
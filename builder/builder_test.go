@@ -91,12 +91,11 @@ func TestBuilderCond(t *testing.T) {
 			"a NOT IN (select id from x where name > ?)",
 			[]interface{}{"b"},
 		},
-		// FIXME: since map will not guarantee the sequence, this may be failed random
-		/*{
+		{
 			Or(Eq{"a": 1, "b": 2}, Eq{"c": 3, "d": 4}),
 			"(a=? AND b=?) OR (c=? AND d=?)",
 			[]interface{}{1, 2, 3, 4},
-		},*/
+		},
 	}
 
 	for _, k := range cases {
@@ -178,3 +177,349 @@ func TestBuilderDelete(t *testing.T) {
 	}
 	fmt.Println(sql, args)
 }
+
+func TestBuilderSelectClauses(t *testing.T) {
+	sub := Select("uid").From("orders").Where(Gt{"amount": 10})
+	var cases = []struct {
+		b    *Builder
+		sql  string
+		args []interface{}
+	}{
+		{
+			Select("a").From("table1"),
+			"SELECT a FROM table1",
+			nil,
+		},
+		{
+			Select("a", "count(*)").From("table1", "t").Where(Eq{"t.b": 1}).GroupBy("a").Having("count(*) > 1").OrderBy("a DESC").Limit(10, 20),
+			"SELECT a,count(*) FROM table1 t WHERE t.b=? GROUP BY a HAVING count(*) > 1 ORDER BY a DESC LIMIT 10 OFFSET 20",
+			[]interface{}{1},
+		},
+		{
+			Select("*").From(Select("uid", "sum(amount) total").From("orders").GroupBy("uid"), "o").Where(Gt{"o.total": 100}),
+			"SELECT * FROM (SELECT uid,sum(amount) total FROM orders GROUP BY uid) o WHERE o.total>?",
+			[]interface{}{100},
+		},
+		{
+			Select("u.name").From("users u").InnerJoin(Select("uid").From("orders").As("o"), "o.uid = u.id"),
+			"SELECT u.name FROM users u INNER JOIN (SELECT uid FROM orders) o ON o.uid = u.id",
+			nil,
+		},
+		{
+			Select("id").From("users").Where(In("id", sub)),
+			"SELECT id FROM users WHERE id IN (SELECT uid FROM orders WHERE amount>?)",
+			[]interface{}{10},
+		},
+		{
+			Select("id").From("users").Where(Exists(Select("1").From("orders").Where(Expr("orders.uid = users.id"))).And(NotExists(sub))),
+			"SELECT id FROM users WHERE EXISTS (SELECT 1 FROM orders WHERE orders.uid = users.id) AND NOT EXISTS (SELECT uid FROM orders WHERE amount>?)",
+			[]interface{}{10},
+		},
+		{
+			Select("a").From("t1").Where(Eq{"b": 1}).Union(Select("a").From("t2")).UnionAll(Select("a").From("t3").OrderBy("a").Limit(1)).OrderBy("a").Limit(5),
+			"SELECT a FROM t1 WHERE b=? UNION SELECT a FROM t2 UNION ALL SELECT * FROM (SELECT a FROM t3 ORDER BY a LIMIT 1) u2 ORDER BY a LIMIT 5",
+			[]interface{}{1},
+		},
+		{
+			Select("a").From("t1").Except(Select("a").From("t2")).Dialect(ORACLE),
+			`SELECT a FROM "t1" MINUS SELECT a FROM "t2"`,
+			nil,
+		},
+		{
+			Select("a").From("t1").OrderBy("a").Limit(10).Dialect(MSSQL),
+			"SELECT TOP 10 a FROM [t1] ORDER BY a",
+			nil,
+		},
+		{
+			Select("a").From("t1").Limit(10, 5).Dialect(MSSQL),
+			"SELECT a FROM [t1] ORDER BY (SELECT NULL) OFFSET 5 ROWS FETCH NEXT 10 ROWS ONLY",
+			nil,
+		},
+		{
+			Select("a").From("t1").Where(Eq{"b": 1}).Limit(10).Dialect(ORACLE),
+			`SELECT * FROM (SELECT a FROM "t1" WHERE "b"=:1) WHERE ROWNUM <= 10`,
+			[]interface{}{1},
+		},
+		{
+			Select("a").From("t1").OrderBy("a").Limit(10, 5).Dialect(ORACLE),
+			`SELECT * FROM (SELECT at.*, ROWNUM rn FROM (SELECT a FROM "t1" ORDER BY a) at WHERE ROWNUM <= 15) WHERE rn > 5`,
+			nil,
+		},
+		{
+			Select("a").From("t1").Where(In("b", Select("b").From("t2").Limit(1))).Dialect(MSSQL),
+			"SELECT a FROM [t1] WHERE [b] IN (SELECT TOP 1 b FROM [t2])",
+			nil,
+		},
+	}
+
+	for _, k := range cases {
+		sql, args, err := k.b.ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if sql != k.sql {
+			t.Error("want", k.sql, "get", sql)
+			return
+		}
+		if !(len(args) == 0 && len(k.args) == 0) {
+			if !reflect.DeepEqual(args, k.args) {
+				t.Error("want", k.args, "get", args)
+				return
+			}
+		}
+	}
+}
+
+func TestBuilderDialect(t *testing.T) {
+	var cases = []struct {
+		b     *Builder
+		sql   string
+		bound string
+	}{
+		{
+			Dialect(POSTGRES).Select("t.a", "count(*)").From("table1", "t").Where(Eq{"t.b": "it's"}.And(Gt{"t.c": 2}, Expr("t.d <> '?'"))),
+			`SELECT t.a,count(*) FROM "table1" "t" WHERE "t"."b"=$1 AND "t"."c">$2 AND t.d <> '?'`,
+			`SELECT t.a,count(*) FROM "table1" "t" WHERE "t"."b"='it''s' AND "t"."c">2 AND t.d <> '?'`,
+		},
+		{
+			Dialect(MYSQL).Select("a").From("table1").Where(Eq{"b": true}.And(IsNull{"c"}, Eq{"d": `a\b`})).Limit(2),
+			"SELECT a FROM `table1` WHERE `b`=? AND `c` IS NULL AND `d`=? LIMIT 2",
+			"SELECT a FROM `table1` WHERE `b`=1 AND `c` IS NULL AND `d`='a\\\\b' LIMIT 2",
+		},
+		{
+			Dialect(ORACLE).Select("a").From("table1").Where(In("b", 1, 2)),
+			`SELECT a FROM "table1" WHERE "b" IN (:1,:2)`,
+			`SELECT a FROM "table1" WHERE "b" IN (1,2)`,
+		},
+		{
+			Dialect(MSSQL).Select("a").From("table1").Where(Eq{"b": []byte("x")}).Limit(1),
+			"SELECT TOP 1 a FROM [table1] WHERE [b]=@p1",
+			"SELECT TOP 1 a FROM [table1] WHERE [b]=0x78",
+		},
+	}
+
+	for _, k := range cases {
+		sql, _, err := k.b.ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if sql != k.sql {
+			t.Error("want", k.sql, "get", sql)
+			return
+		}
+		bound, err := k.b.ToBoundSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if bound != k.bound {
+			t.Error("want", k.bound, "get", bound)
+			return
+		}
+	}
+}
+
+func TestBuilderWrite(t *testing.T) {
+	rows := []Eq{{"b": 1, "a": "x"}, {"a": "y", "b": Expr("b + ?", 1)}}
+	var cases = []struct {
+		b    *Builder
+		sql  string
+		args []interface{}
+	}{
+		{
+			Insert(rows...).Into("table1"),
+			"INSERT INTO table1 (a,b) VALUES (?,?),(?,b + ?)",
+			[]interface{}{"x", 1, "y", 1},
+		},
+		{
+			Dialect(POSTGRES).Insert(rows[0]).Into("table1").Returning("id"),
+			`INSERT INTO "table1" ("a","b") VALUES ($1,$2) RETURNING "id"`,
+			[]interface{}{"x", 1},
+		},
+		{
+			Dialect(MSSQL).Insert(rows[0]).Into("table1").Returning("id"),
+			"INSERT INTO [table1] ([a],[b]) OUTPUT INSERTED.[id] VALUES (@p1,@p2)",
+			[]interface{}{"x", 1},
+		},
+		{
+			Dialect(ORACLE).Insert(rows...).Into("table1"),
+			`INSERT ALL INTO "table1" ("a","b") VALUES (:1,:2) INTO "table1" ("a","b") VALUES (:3,b + :4) SELECT 1 FROM DUAL`,
+			[]interface{}{"x", 1, "y", 1},
+		},
+		{
+			InsertSelect(Select("a", "b").From("table2").Where(Gt{"b": 2}), "a", "b").Into("table1"),
+			"INSERT INTO table1 (a,b) SELECT a,b FROM table2 WHERE b>?",
+			[]interface{}{2},
+		},
+		{
+			Dialect(MYSQL).Update(Eq{"t1.a": Expr("t2.a")}).From("table1", "t1").InnerJoin("table2 t2", "t2.id = t1.t2_id").Where(Eq{"t2.b": 1}),
+			"UPDATE `table1` `t1` INNER JOIN `table2` `t2` ON t2.id = t1.t2_id SET `t1`.`a`=(t2.a) WHERE `t2`.`b`=?",
+			[]interface{}{1},
+		},
+		{
+			Dialect(POSTGRES).Update(Eq{"a": Expr("t2.a")}).From("table1", "t1").InnerJoin("table2 t2", "t2.id = t1.t2_id").Where(Eq{"t2.b": 1}).Returning("t1.id"),
+			`UPDATE "table1" "t1" SET "a"=(t2.a) FROM "table2" "t2" WHERE t2.id = t1.t2_id AND "t2"."b"=$1 RETURNING "t1"."id"`,
+			[]interface{}{1},
+		},
+		{
+			Dialect(MSSQL).Update(Eq{"a": 1}).From("table1").Where(Eq{"b": 2}).Limit(10),
+			"UPDATE TOP (10) [table1] SET [a]=@p1 WHERE [b]=@p2",
+			[]interface{}{1, 2},
+		},
+		{
+			Dialect(MYSQL).Update(Eq{"a": 1}).From("table1").OrderBy("id").Limit(10),
+			"UPDATE `table1` SET `a`=? ORDER BY id LIMIT 10",
+			[]interface{}{1},
+		},
+		{
+			Dialect(MYSQL).Delete(Eq{"t2.b": 1}).From("table1", "t1").InnerJoin("table2 t2", "t2.id = t1.t2_id"),
+			"DELETE `t1` FROM `table1` `t1` INNER JOIN `table2` `t2` ON t2.id = t1.t2_id WHERE `t2`.`b`=?",
+			[]interface{}{1},
+		},
+		{
+			Dialect(POSTGRES).Delete(Eq{"t2.b": 1}).From("table1", "t1").InnerJoin("table2 t2", "t2.id = t1.t2_id").Returning("t1.id"),
+			`DELETE FROM "table1" "t1" USING "table2" "t2" WHERE t2.id = t1.t2_id AND "t2"."b"=$1 RETURNING "t1"."id"`,
+			[]interface{}{1},
+		},
+		{
+			Dialect(MSSQL).Delete(Eq{"b": 1}).From("table1").Returning("id"),
+			"DELETE FROM [table1] OUTPUT DELETED.[id] WHERE [b]=@p1",
+			[]interface{}{1},
+		},
+	}
+
+	for _, k := range cases {
+		sql, args, err := k.b.ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if sql != k.sql {
+			t.Error("want", k.sql, "get", sql)
+			return
+		}
+		if !reflect.DeepEqual(args, k.args) {
+			t.Error("want", k.args, "get", args)
+			return
+		}
+	}
+
+	var errCases = []*Builder{
+		Insert(Eq{"a": 1}, Eq{"b": 1}).Into("table1"),
+		Dialect(MYSQL).Insert(Eq{"a": 1}).Into("table1").Returning("id"),
+		Dialect(POSTGRES).Update(Eq{"a": 1}).From("table1").Limit(1),
+		Dialect(POSTGRES).Delete().From("table1").LeftJoin("table2", "table2.id = table1.id"),
+		Dialect(SQLITE).Delete().From("table1").InnerJoin("table2", "table2.id = table1.id"),
+	}
+	for _, b := range errCases {
+		if _, _, err := b.ToSQL(); err == nil {
+			t.Error("want an error for", b)
+			return
+		}
+	}
+}
+
+func TestBuilderJSON(t *testing.T) {
+	var cases = []struct {
+		dialect string
+		cond    Cond
+		sql     string
+		args    []interface{}
+	}{
+		{POSTGRES, JSONEq("doc", "address.city", "Paris"), `"doc"#>>'{address,city}'=$1`, []interface{}{"Paris"}},
+		{MYSQL, JSONEq("doc", "tags[0]", "a"), "JSON_UNQUOTE(JSON_EXTRACT(`doc`,'$.tags[0]'))=?", []interface{}{"a"}},
+		{SQLITE, JSONEq("doc", "$.a", 1), "json_extract(`doc`,'$.a')=?", []interface{}{1}},
+		{MSSQL, JSONEq("doc", "a", 1), "JSON_VALUE([doc],'$.a')=@p1", []interface{}{1}},
+		{POSTGRES, JSONContains("doc", map[string]int{"a": 1}), `"doc"@>$1`, []interface{}{`{"a":1}`}},
+		{MYSQL, JSONContains("doc", 1, "tags"), "JSON_CONTAINS(`doc`,?,'$.tags')", []interface{}{"1"}},
+		{POSTGRES, JSONHasKey("doc", "a.b"), `jsonb_exists("doc"#>'{a}','b')`, nil},
+		{MYSQL, JSONHasKey("doc", "a.b"), "JSON_CONTAINS_PATH(`doc`,'one','$.a.b')", nil},
+		{ORACLE, JSONHasKey("doc", "a"), `JSON_EXISTS("doc",'$.a')`, nil},
+	}
+
+	for _, k := range cases {
+		sql, args, err := Dialect(k.dialect).Select("id").From("t").Where(k.cond).ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := "SELECT id FROM " + quoteKey(k.dialect, "t") + " WHERE " + k.sql; sql != want {
+			t.Error("want", want, "get", sql)
+			return
+		}
+		if !(len(args) == 0 && len(k.args) == 0) && !reflect.DeepEqual(args, k.args) {
+			t.Error("want", k.args, "get", args)
+			return
+		}
+	}
+
+	if _, _, err := Dialect(SQLITE).Select("id").From("t").Where(JSONContains("doc", 1)).ToSQL(); err == nil {
+		t.Error("want an error")
+		return
+	}
+
+	values := map[string]interface{}{"a.b": 1, "c": []string{"x"}}
+	var sets = []struct {
+		dialect string
+		sql     string
+	}{
+		{POSTGRES, `UPDATE "t" SET "doc"=jsonb_set(jsonb_set(COALESCE("doc"::jsonb,'{}'),'{a,b}',$1),'{c}',$2)`},
+		{MYSQL, "UPDATE `t` SET `doc`=JSON_SET(COALESCE(`doc`,'{}'),'$.a.b',CAST(? AS JSON),'$.c',CAST(? AS JSON))"},
+		{SQLITE, "UPDATE `t` SET `doc`=json_set(COALESCE(`doc`,'{}'),'$.a.b',json(?),'$.c',json(?))"},
+		{ORACLE, `UPDATE "t" SET "doc"=JSON_TRANSFORM(COALESCE("doc",'{}'),SET '$.a.b' = :1 FORMAT JSON,SET '$.c' = :2 FORMAT JSON)`},
+	}
+	for _, k := range sets {
+		sql, args, err := Dialect(k.dialect).Update(Eq{"doc": JSONSet("doc", values)}).From("t").ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if sql != k.sql {
+			t.Error("want", k.sql, "get", sql)
+			return
+		}
+		if !reflect.DeepEqual(args, []interface{}{"1", `["x"]`}) {
+			t.Error("get", args)
+			return
+		}
+	}
+}
+
+func TestBuilderArray(t *testing.T) {
+	var cases = []struct {
+		cond Cond
+		sql  string
+		args []interface{}
+	}{
+		{ArrayAny("tags", "a"), `$1=ANY("tags")`, []interface{}{"a"}},
+		{ArrayContains("ids", []int64{1, 2}), `"ids"@>$1`, []interface{}{"{1,2}"}},
+		{ArrayOverlap("tags", []string{"a b", `c"d`}), `"tags"&&$1`, []interface{}{`{"a b","c\"d"}`}},
+		{ArrayContains("ids", "{3}").And(Eq{"id": 1}), `"ids"@>$1 AND "id"=$2`, []interface{}{"{3}", 1}},
+	}
+
+	for _, k := range cases {
+		sql, args, err := Dialect(POSTGRES).Select("id").From("t").Where(k.cond).ToSQL()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := `SELECT id FROM "t" WHERE ` + k.sql; sql != want {
+			t.Error("want", want, "get", sql)
+			return
+		}
+		if !reflect.DeepEqual(args, k.args) {
+			t.Error("want", k.args, "get", args)
+			return
+		}
+	}
+
+	if _, _, err := Dialect(MYSQL).Select("id").From("t").Where(ArrayAny("tags", "a")).ToSQL(); err != ErrNotSupportDialect {
+		t.Error("want", ErrNotSupportDialect, "get", err)
+		return
+	}
+	if _, _, err := Dialect(POSTGRES).Select("id").From("t").Where(ArrayOverlap("tags", 1)).ToSQL(); err != ErrNotSupportType {
+		t.Error("want", ErrNotSupportType, "get", err)
+	}
+}
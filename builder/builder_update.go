@@ -7,6 +7,7 @@ package builder
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 func (b *Builder) updateWriteTo(w Writer) error {
@@ -17,10 +18,98 @@ func (b *Builder) updateWriteTo(w Writer) error {
 		return errors.New("no column to be update")
 	}
 
-	if _, err := fmt.Fprintf(w, "UPDATE %s SET ", w.Key(b.tableName)); err != nil {
+	dialect := b.dialectOf(w)
+	switch dialect {
+	case POSTGRES, SQLITE, ORACLE:
+		if b.limit != nil || len(b.orderBy) > 0 {
+			return ErrNotSupportDialect
+		}
+	}
+
+	if _, err := fmt.Fprint(w, "UPDATE "); err != nil {
 		return err
 	}
+	cond := b.cond
+	switch dialect {
+	case MSSQL:
+		// UPDATE [TOP (n)] t SET ... [OUTPUT ...] [FROM t JOIN ...]
+		if b.limit != nil {
+			if _, err := fmt.Fprintf(w, "TOP (%d) ", b.limit.limitN); err != nil {
+				return err
+			}
+		}
+		if err := b.targetWriteTo(w); err != nil {
+			return err
+		}
+		if err := b.setWriteTo(w); err != nil {
+			return err
+		}
+		if err := b.returningWriteTo(w, dialect, "INSERTED"); err != nil {
+			return err
+		}
+		if len(b.joins) > 0 {
+			if _, err := fmt.Fprint(w, " FROM "); err != nil {
+				return err
+			}
+			if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+				return err
+			}
+			if err := b.joinsWriteTo(w); err != nil {
+				return err
+			}
+		}
+	case POSTGRES, SQLITE, ORACLE:
+		// UPDATE t SET ... [FROM j1, j2 WHERE <join conditions>]
+		if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+			return err
+		}
+		if err := b.setWriteTo(w); err != nil {
+			return err
+		}
+		if len(b.joins) > 0 {
+			if dialect == ORACLE {
+				return ErrNotSupportDialect
+			}
+			var err error
+			if cond, err = b.fromJoinsWriteTo(w, " FROM "); err != nil {
+				return err
+			}
+		}
+	default:
+		// UPDATE t JOIN ... SET ... [ORDER BY] [LIMIT]
+		if err := tableWriteTo(w, b.tableName, b.fromAlias); err != nil {
+			return err
+		}
+		if err := b.joinsWriteTo(w); err != nil {
+			return err
+		}
+		if err := b.setWriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	if cond.IsValid() {
+		if _, err := fmt.Fprint(w, " WHERE "); err != nil {
+			return err
+		}
+		if err := cond.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	if dialect == MSSQL {
+		return nil
+	}
+	if err := b.orderLimitWriteTo(w); err != nil {
+		return err
+	}
+	return b.returningWriteTo(w, dialect, "")
+}
 
+func (b *Builder) setWriteTo(w Writer) error {
+	if _, err := fmt.Fprint(w, " SET "); err != nil {
+		return err
+	}
 	for i, s := range b.updates {
 		if err := s.opWriteTo(",", w); err != nil {
 			return err
@@ -32,10 +121,64 @@ func (b *Builder) updateWriteTo(w Writer) error {
 			}
 		}
 	}
+	return nil
+}
 
-	if _, err := fmt.Fprint(w, " WHERE "); err != nil {
+// targetWriteTo writes the alias of the table updated or deleted when it's
+// joined, or the table
+func (b *Builder) targetWriteTo(w Writer) error {
+	if len(b.joins) > 0 && len(b.fromAlias) > 0 {
+		_, err := fmt.Fprint(w, w.Key(b.fromAlias))
 		return err
 	}
+	_, err := fmt.Fprint(w, w.Key(b.tableName))
+	return err
+}
+
+// fromJoinsWriteTo writes the joined tables as a list after keyword, the
+// form of PostgreSQL, and returns the condition of the statement with the
+// ones of the joins; only the inner and cross joins can be written so
+func (b *Builder) fromJoinsWriteTo(w Writer, keyword string) (Cond, error) {
+	if _, err := fmt.Fprint(w, keyword); err != nil {
+		return nil, err
+	}
+	cond := NewCond()
+	for i, v := range b.joins {
+		switch strings.ToUpper(v.joinType) {
+		case "INNER", "CROSS", "":
+		default:
+			return nil, ErrNotSupportDialect
+		}
+		if i > 0 {
+			if _, err := fmt.Fprint(w, ", "); err != nil {
+				return nil, err
+			}
+		}
+		if err := tableWriteTo(w, v.joinTable, ""); err != nil {
+			return nil, err
+		}
+		if v.joinCond != nil {
+			cond = cond.And(v.joinCond)
+		}
+	}
+	return cond.And(b.cond), nil
+}
 
-	return b.cond.WriteTo(w)
+// orderLimitWriteTo writes the ORDER BY and LIMIT clauses of an update or a
+// delete, MySQL only accepts them without joins
+func (b *Builder) orderLimitWriteTo(w Writer) error {
+	if len(b.joins) > 0 && (len(b.orderBy) > 0 || b.limit != nil) {
+		return ErrNotSupportDialect
+	}
+	if len(b.orderBy) > 0 {
+		if _, err := fmt.Fprint(w, " ORDER BY ", b.orderBy); err != nil {
+			return err
+		}
+	}
+	if b.limit != nil {
+		if _, err := fmt.Fprintf(w, " LIMIT %d", b.limit.limitN); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -24,6 +24,7 @@ type BytesWriter struct {
 	buffer    []byte
 	args      []interface{}
 	keyFilter func(string) string
+	dialect   string
 }
 
 // NewWriter creates a new string writer
@@ -47,6 +48,9 @@ func (s *BytesWriter) Key(key string) string {
 	if s.keyFilter != nil {
 		return s.keyFilter(key)
 	}
+	if len(s.dialect) > 0 {
+		return quoteKey(s.dialect, key)
+	}
 	return key
 }
 
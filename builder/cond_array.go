@@ -0,0 +1,147 @@
+// Copyright 2016 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The array conditions are PostgreSQL's, they're written for the PostgreSQL
+// writers and for the writers without dialect, the other dialects return
+// ErrNotSupportDialect. The values of the arrays are given as slices, their
+// elements are written in an array literal, e.g. {1,2} or {"a","b"}, unless
+// they're a string, which is taken as the literal.
+
+// arrayLiteral returns the PostgreSQL array literal of the slice values
+func arrayLiteral(values interface{}) (string, error) {
+	if s, ok := values.(string); ok {
+		return s, nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(values))
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", ErrNotSupportType
+	}
+	elems := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface {
+			if elem.IsNil() {
+				break
+			}
+			elem = elem.Elem()
+		}
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			elems[i] = "NULL"
+		case reflect.Bool:
+			elems[i] = "f"
+			if elem.Bool() {
+				elems[i] = "t"
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			elems[i] = strconv.FormatInt(elem.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			elems[i] = strconv.FormatUint(elem.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			elems[i] = strconv.FormatFloat(elem.Float(), 'g', -1, elem.Type().Bits())
+		default:
+			s := strings.Replace(fmt.Sprint(elem.Interface()), `\`, `\\`, -1)
+			elems[i] = `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+		}
+	}
+	return "{" + strings.Join(elems, ",") + "}", nil
+}
+
+// arrayDialect reports whether the array conditions can be written for w
+func arrayDialect(w Writer) bool {
+	dialect := writerDialect(w)
+	return dialect == POSTGRES || dialect == ""
+}
+
+type condArrayAny struct {
+	col   string
+	value interface{}
+}
+
+var _ Cond = condArrayAny{}
+
+// ArrayAny generates the condition of the array column col having an element
+// equal to value: ?=ANY(col)
+func ArrayAny(col string, value interface{}) Cond {
+	return condArrayAny{col, value}
+}
+
+func (c condArrayAny) WriteTo(w Writer) error {
+	if !arrayDialect(w) {
+		return ErrNotSupportDialect
+	}
+	if _, err := fmt.Fprintf(w, "?=ANY(%s)", w.Key(c.col)); err != nil {
+		return err
+	}
+	w.Append(c.value)
+	return nil
+}
+
+func (c condArrayAny) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condArrayAny) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condArrayAny) IsValid() bool {
+	return len(c.col) > 0
+}
+
+type condArrayOp struct {
+	col    string
+	op     string
+	values interface{}
+}
+
+var _ Cond = condArrayOp{}
+
+// ArrayContains generates the condition of the array column col containing
+// all the elements of values: col@>?
+func ArrayContains(col string, values interface{}) Cond {
+	return condArrayOp{col, "@>", values}
+}
+
+// ArrayOverlap generates the condition of the array column col having at
+// least one element of values: col&&?
+func ArrayOverlap(col string, values interface{}) Cond {
+	return condArrayOp{col, "&&", values}
+}
+
+func (c condArrayOp) WriteTo(w Writer) error {
+	if !arrayDialect(w) {
+		return ErrNotSupportDialect
+	}
+	literal, err := arrayLiteral(c.values)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(w, "%s%s?", w.Key(c.col), c.op); err != nil {
+		return err
+	}
+	w.Append(literal)
+	return nil
+}
+
+func (c condArrayOp) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condArrayOp) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condArrayOp) IsValid() bool {
+	return len(c.col) > 0
+}
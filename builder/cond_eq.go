@@ -4,7 +4,10 @@
 
 package builder
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
 // Incr implements a type used by Eq
 type Incr int
@@ -17,9 +20,21 @@ type Eq map[string]interface{}
 
 var _ Cond = Eq{}
 
+// sortedKeys returns the column names in order, so that the generated SQL
+// doesn't depend on the order of the map
+func (eq Eq) sortedKeys() []string {
+	keys := make([]string, 0, len(eq))
+	for k := range eq {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (eq Eq) opWriteTo(op string, w Writer) error {
 	var i = 0
-	for k, v := range eq {
+	for _, k := range eq.sortedKeys() {
+		v := eq[k]
 		switch v.(type) {
 		case []int, []int64, []string, []int32, []int16, []int8, []uint, []uint64, []uint32, []uint16, []interface{}:
 			if err := In(k, v).WriteTo(w); err != nil {
@@ -59,6 +74,13 @@ func (eq Eq) opWriteTo(op string, w Writer) error {
 				return err
 			}
 			w.Append(int(v.(Decr)))
+		case jsonSet:
+			if _, err := fmt.Fprintf(w, "%s=", w.Key(k)); err != nil {
+				return err
+			}
+			if err := v.(jsonSet).WriteTo(w); err != nil {
+				return err
+			}
 		default:
 			if _, err := fmt.Fprintf(w, "%s=?", w.Key(k)); err != nil {
 				return err
@@ -0,0 +1,51 @@
+// Copyright 2016 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import "fmt"
+
+type condExists struct {
+	not      bool
+	subQuery *Builder
+}
+
+var _ Cond = condExists{}
+
+// Exists generates EXISTS condition on a sub query
+func Exists(subQuery *Builder) Cond {
+	return condExists{false, subQuery}
+}
+
+// NotExists generates NOT EXISTS condition on a sub query
+func NotExists(subQuery *Builder) Cond {
+	return condExists{true, subQuery}
+}
+
+func (exists condExists) WriteTo(w Writer) error {
+	op := "EXISTS"
+	if exists.not {
+		op = "NOT EXISTS"
+	}
+	if _, err := fmt.Fprintf(w, "%s (", op); err != nil {
+		return err
+	}
+	if err := exists.subQuery.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(w, ")")
+	return err
+}
+
+func (exists condExists) And(conds ...Cond) Cond {
+	return And(exists, And(conds...))
+}
+
+func (exists condExists) Or(conds ...Cond) Cond {
+	return Or(exists, Or(conds...))
+}
+
+func (exists condExists) IsValid() bool {
+	return exists.subQuery != nil
+}
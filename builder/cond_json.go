@@ -0,0 +1,285 @@
+// Copyright 2016 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The JSON paths are the keys separated by dots with the array indexes in
+// brackets, e.g. "address.lines[0]", they're written as $.address.lines[0]
+// or, on PostgreSQL, as '{address,lines,0}'. The JSON conditions are written
+// in the dialect of the writer, see Dialect.
+
+// jsonPath returns the path as a SQL literal in dialect
+func jsonPath(path, dialect string) string {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if dialect == POSTGRES {
+		keys := strings.FieldsFunc(path, func(c rune) bool {
+			return c == '.' || c == '[' || c == ']'
+		})
+		return stringLiteral("{"+strings.Join(keys, ",")+"}", dialect)
+	}
+	if strings.HasPrefix(path, "[") || len(path) == 0 {
+		return stringLiteral("$"+path, dialect)
+	}
+	return stringLiteral("$."+path, dialect)
+}
+
+// jsonValue returns the JSON document of v, which is kept as is when it's a
+// json.RawMessage
+func jsonValue(v interface{}) (string, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		return string(raw), nil
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+type condJSONEq struct {
+	col   string
+	path  string
+	value interface{}
+}
+
+var _ Cond = condJSONEq{}
+
+// JSONEq generates the condition of the scalar at path of the JSON column
+// col equal to value: ->> on PostgreSQL, JSON_EXTRACT on MySQL and SQLite
+// and JSON_VALUE on MSSQL and Oracle
+func JSONEq(col, path string, value interface{}) Cond {
+	return condJSONEq{col, path, value}
+}
+
+func (c condJSONEq) WriteTo(w Writer) error {
+	dialect := writerDialect(w)
+	path := jsonPath(c.path, dialect)
+	var err error
+	switch dialect {
+	case POSTGRES:
+		_, err = fmt.Fprintf(w, "%s#>>%s=?", w.Key(c.col), path)
+	case SQLITE:
+		_, err = fmt.Fprintf(w, "json_extract(%s,%s)=?", w.Key(c.col), path)
+	case MSSQL, ORACLE:
+		_, err = fmt.Fprintf(w, "JSON_VALUE(%s,%s)=?", w.Key(c.col), path)
+	default:
+		_, err = fmt.Fprintf(w, "JSON_UNQUOTE(JSON_EXTRACT(%s,%s))=?", w.Key(c.col), path)
+	}
+	w.Append(c.value)
+	return err
+}
+
+func (c condJSONEq) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condJSONEq) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condJSONEq) IsValid() bool {
+	return len(c.col) > 0
+}
+
+type condJSONContains struct {
+	col   string
+	value interface{}
+	path  []string
+}
+
+var _ Cond = condJSONContains{}
+
+// JSONContains generates the condition of the JSON column col, or of its
+// document at path, containing the JSON document of value: @> on PostgreSQL
+// and JSON_CONTAINS on MySQL, the other databases don't support it
+func JSONContains(col string, value interface{}, path ...string) Cond {
+	return condJSONContains{col, value, path}
+}
+
+func (c condJSONContains) WriteTo(w Writer) error {
+	doc, err := jsonValue(c.value)
+	if err != nil {
+		return err
+	}
+	switch dialect := writerDialect(w); dialect {
+	case POSTGRES:
+		if len(c.path) > 0 {
+			_, err = fmt.Fprintf(w, "%s#>%s@>?", w.Key(c.col), jsonPath(c.path[0], dialect))
+		} else {
+			_, err = fmt.Fprintf(w, "%s@>?", w.Key(c.col))
+		}
+	case MYSQL, "":
+		if len(c.path) > 0 {
+			_, err = fmt.Fprintf(w, "JSON_CONTAINS(%s,?,%s)", w.Key(c.col), jsonPath(c.path[0], dialect))
+		} else {
+			_, err = fmt.Fprintf(w, "JSON_CONTAINS(%s,?)", w.Key(c.col))
+		}
+	default:
+		return ErrNotSupportDialect
+	}
+	w.Append(doc)
+	return err
+}
+
+func (c condJSONContains) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condJSONContains) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condJSONContains) IsValid() bool {
+	return len(c.col) > 0
+}
+
+type condJSONHasKey struct {
+	col  string
+	path string
+}
+
+var _ Cond = condJSONHasKey{}
+
+// JSONHasKey generates the condition of the JSON column col having the key
+// at path. PostgreSQL's ? operator would be taken as a placeholder, so its
+// function jsonb_exists is written instead.
+func JSONHasKey(col, path string) Cond {
+	return condJSONHasKey{col, path}
+}
+
+func (c condJSONHasKey) WriteTo(w Writer) error {
+	dialect := writerDialect(w)
+	path := jsonPath(c.path, dialect)
+	var err error
+	switch dialect {
+	case POSTGRES:
+		keys := strings.FieldsFunc(strings.TrimPrefix(strings.TrimPrefix(c.path, "$"), "."), func(c rune) bool {
+			return c == '.' || c == '[' || c == ']'
+		})
+		if len(keys) == 0 {
+			return ErrNotSupportType
+		}
+		parent := w.Key(c.col)
+		if len(keys) > 1 {
+			parent += "#>" + jsonPath(strings.Join(keys[:len(keys)-1], "."), dialect)
+		}
+		_, err = fmt.Fprintf(w, "jsonb_exists(%s,%s)", parent, stringLiteral(keys[len(keys)-1], dialect))
+	case SQLITE:
+		_, err = fmt.Fprintf(w, "json_type(%s,%s) IS NOT NULL", w.Key(c.col), path)
+	case MSSQL:
+		_, err = fmt.Fprintf(w, "(JSON_VALUE(%s,%s) IS NOT NULL OR JSON_QUERY(%s,%s) IS NOT NULL)", w.Key(c.col), path, w.Key(c.col), path)
+	case ORACLE:
+		_, err = fmt.Fprintf(w, "JSON_EXISTS(%s,%s)", w.Key(c.col), path)
+	default:
+		_, err = fmt.Fprintf(w, "JSON_CONTAINS_PATH(%s,'one',%s)", w.Key(c.col), path)
+	}
+	return err
+}
+
+func (c condJSONHasKey) And(conds ...Cond) Cond {
+	return And(c, And(conds...))
+}
+
+func (c condJSONHasKey) Or(conds ...Cond) Cond {
+	return Or(c, Or(conds...))
+}
+
+func (c condJSONHasKey) IsValid() bool {
+	return len(c.col) > 0
+}
+
+type jsonSet struct {
+	col    string
+	values map[string]interface{}
+}
+
+var _ Cond = jsonSet{}
+
+// JSONSet generates the expression of the JSON column col whose documents at
+// the paths of values are replaced, or added, by the JSON documents of the
+// values: jsonb_set on PostgreSQL, JSON_SET on MySQL and SQLite,
+// JSON_MODIFY on MSSQL and JSON_TRANSFORM on Oracle. It's meant to be the
+// value of an update, e.g. Update(Eq{"doc": JSONSet("doc", values)}).
+func JSONSet(col string, values map[string]interface{}) Cond {
+	return jsonSet{col, values}
+}
+
+func (s jsonSet) WriteTo(w Writer) error {
+	dialect := writerDialect(w)
+	paths := make([]string, 0, len(s.values))
+	for path := range s.values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	docs := make([]string, len(paths))
+	for i, path := range paths {
+		doc, err := jsonValue(s.values[path])
+		if err != nil {
+			return err
+		}
+		docs[i] = doc
+	}
+
+	expr := "COALESCE(" + w.Key(s.col) + ",'{}')"
+	switch dialect {
+	case POSTGRES:
+		expr = "COALESCE(" + w.Key(s.col) + "::jsonb,'{}')"
+		for i, path := range paths {
+			expr = "jsonb_set(" + expr + "," + jsonPath(path, dialect) + ",?)"
+			w.Append(docs[i])
+		}
+	case MSSQL:
+		for i, path := range paths {
+			// the objects and the arrays are inserted as JSON, the scalars
+			// as their values
+			if strings.HasPrefix(docs[i], "{") || strings.HasPrefix(docs[i], "[") {
+				expr = "JSON_MODIFY(" + expr + "," + jsonPath(path, dialect) + ",JSON_QUERY(?))"
+				w.Append(docs[i])
+			} else {
+				expr = "JSON_MODIFY(" + expr + "," + jsonPath(path, dialect) + ",?)"
+				w.Append(s.values[path])
+			}
+		}
+	case ORACLE:
+		sets := make([]string, len(paths))
+		for i, path := range paths {
+			sets[i] = "SET " + jsonPath(path, dialect) + " = ? FORMAT JSON"
+			w.Append(docs[i])
+		}
+		expr = "JSON_TRANSFORM(" + expr + "," + strings.Join(sets, ",") + ")"
+	default:
+		fn, cast := "JSON_SET", "CAST(? AS JSON)"
+		if dialect == SQLITE {
+			fn, cast = "json_set", "json(?)"
+		}
+		expr = fn + "(" + expr
+		for i, path := range paths {
+			expr += "," + jsonPath(path, dialect) + "," + cast
+			w.Append(docs[i])
+		}
+		expr += ")"
+	}
+	_, err := fmt.Fprint(w, expr)
+	return err
+}
+
+func (s jsonSet) And(conds ...Cond) Cond {
+	return And(s, And(conds...))
+}
+
+func (s jsonSet) Or(conds ...Cond) Cond {
+	return Or(s, Or(conds...))
+}
+
+func (s jsonSet) IsValid() bool {
+	return len(s.col) > 0 && len(s.values) > 0
+}
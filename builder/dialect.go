@@ -0,0 +1,229 @@
+// Copyright 2016 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package builder
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the databases supported by Builder.Dialect, named as the dialects of xorm
+const (
+	MYSQL    = "mysql"
+	POSTGRES = "postgres"
+	SQLITE   = "sqlite3"
+	MSSQL    = "mssql"
+	ORACLE   = "oracle"
+)
+
+// Dialect creates a Builder generating the SQL of dialect, see Builder.Dialect
+func Dialect(dialect string) *Builder {
+	return &Builder{cond: NewCond(), dialect: dialect}
+}
+
+// NewDialectWriter creates a writer quoting the keys by keyFilter, or by the
+// quote character of dialect when it's nil, for the builders which have no
+// dialect. The placeholders are left as ? which makes it fit to the SQL
+// processed by the filters of xorm.
+func NewDialectWriter(dialect string, keyFilter func(string) string) *BytesWriter {
+	w := NewWriter()
+	w.dialect = dialect
+	w.keyFilter = keyFilter
+	return w
+}
+
+// dialectOf returns the dialect of the builder, or the one of the writer
+// when it's a sub query without dialect
+func (b *Builder) dialectOf(w Writer) string {
+	if len(b.dialect) > 0 {
+		return b.dialect
+	}
+	return writerDialect(w)
+}
+
+// writerDialect returns the dialect the conditions are written in by w
+func writerDialect(w Writer) string {
+	if bw, ok := w.(*BytesWriter); ok {
+		return bw.dialect
+	}
+	return ""
+}
+
+// quoteKey quotes the parts of a column or table name by the quote character
+// of dialect, the expressions and the quoted names are left as is
+func quoteKey(dialect, key string) string {
+	var start, end string
+	switch dialect {
+	case MYSQL, SQLITE:
+		start, end = "`", "`"
+	case POSTGRES, ORACLE:
+		start, end = `"`, `"`
+	case MSSQL:
+		start, end = "[", "]"
+	default:
+		return key
+	}
+	for _, c := range key {
+		if c != '_' && c != '.' && c != '*' && (c < '0' || c > '9') && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') {
+			return key
+		}
+	}
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if len(part) == 0 || part == "*" {
+			continue
+		}
+		parts[i] = start + part + end
+	}
+	return strings.Join(parts, ".")
+}
+
+// scanPlaceholders calls fn with the index of every ? out of the string
+// literals and the quoted names of sql
+func scanPlaceholders(sql string, fn func(i int)) {
+	var quote byte
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			fn(i)
+		}
+	}
+}
+
+// ConvertPlaceholder converts the ? placeholders of sql to the ones of
+// dialect: $n for PostgreSQL, :n for Oracle and @pn for MSSQL
+func ConvertPlaceholder(sql, dialect string) string {
+	var prefix string
+	switch dialect {
+	case POSTGRES:
+		prefix = "$"
+	case ORACLE:
+		prefix = ":"
+	case MSSQL:
+		prefix = "@p"
+	default:
+		return sql
+	}
+	var buf bytes.Buffer
+	var last, n int
+	scanPlaceholders(sql, func(i int) {
+		n++
+		buf.WriteString(sql[last:i])
+		buf.WriteString(prefix)
+		buf.WriteString(strconv.Itoa(n))
+		last = i + 1
+	})
+	buf.WriteString(sql[last:])
+	return buf.String()
+}
+
+// ConvertToBoundSQL replaces the ? placeholders of sql by the literals of
+// args in dialect, it's meant to log the statements, not to run them
+func ConvertToBoundSQL(sql string, args []interface{}, dialect string) (string, error) {
+	var buf bytes.Buffer
+	var last, n int
+	var err error
+	scanPlaceholders(sql, func(i int) {
+		if err != nil {
+			return
+		}
+		if n >= len(args) {
+			err = ErrPlaceholderArgs
+			return
+		}
+		buf.WriteString(sql[last:i])
+		var literal string
+		if literal, err = sqlLiteral(args[n], dialect); err != nil {
+			return
+		}
+		buf.WriteString(literal)
+		n++
+		last = i + 1
+	})
+	if err != nil {
+		return "", err
+	}
+	if n != len(args) {
+		return "", ErrPlaceholderArgs
+	}
+	buf.WriteString(sql[last:])
+	return buf.String(), nil
+}
+
+// sqlLiteral returns the SQL literal of arg in dialect
+func sqlLiteral(arg interface{}, dialect string) (string, error) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		arg = v
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case bool:
+		if dialect == POSTGRES {
+			return strconv.FormatBool(v), nil
+		}
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case string:
+		return stringLiteral(v, dialect), nil
+	case []byte:
+		switch dialect {
+		case POSTGRES:
+			return `'\x` + hex.EncodeToString(v) + `'`, nil
+		case MSSQL:
+			return "0x" + hex.EncodeToString(v), nil
+		case ORACLE:
+			return "HEXTORAW('" + hex.EncodeToString(v) + "')", nil
+		}
+		return "X'" + hex.EncodeToString(v) + "'", nil
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'", nil
+	}
+
+	rv := reflect.ValueOf(arg)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "NULL", nil
+		}
+		return sqlLiteral(rv.Elem().Interface(), dialect)
+	}
+	return stringLiteral(fmt.Sprint(arg), dialect), nil
+}
+
+// stringLiteral quotes s as a string literal, the backslashes are escaped
+// on MySQL where they start escape sequences
+func stringLiteral(s, dialect string) string {
+	s = strings.Replace(s, "'", "''", -1)
+	if dialect == MYSQL {
+		s = strings.Replace(s, `\`, `\\`, -1)
+	}
+	return "'" + s + "'"
+}
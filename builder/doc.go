@@ -114,7 +114,69 @@ WARNNING: Currently, only query conditions are supported. Below is the supported
     sql, args, _ := ToSQL(Between("a", 1, 2))
     // a BETWEEN 1 AND 2
 
-11. define yourself conditions
+11. Exists and NotExists
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := ToSQL(Exists(Select("1").From("b").Where(Eq{"c": 1})))
+    // EXISTS (SELECT 1 FROM b WHERE c=?) [1]
+
+12. Select
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := Select("a", "count(*)").From("t1", "t").Where(Eq{"b": 1}).
+        GroupBy("a").Having("count(*) > 1").OrderBy("a").Limit(10, 20).ToSQL()
+    // SELECT a,count(*) FROM t1 t WHERE b=? GROUP BY a HAVING count(*) > 1 ORDER BY a LIMIT 10 OFFSET 20 [1]
+    sql, args, _ := Select("a").From(Select("a").From("t1"), "s").Union(Select("a").From("t2")).ToSQL()
+    // SELECT a FROM (SELECT a FROM t1) s UNION SELECT a FROM t2 []
+
+The limit is written as TOP or OFFSET FETCH for Dialect(MSSQL) and on ROWNUM
+for Dialect(ORACLE).
+
+13. Dialect quotes the names and converts the placeholders
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := Dialect(POSTGRES).Select("a").From("t1").Where(Eq{"b": 1}).ToSQL()
+    // SELECT a FROM "t1" WHERE "b"=$1 [1]
+    sql, _ := Dialect(POSTGRES).Select("a").From("t1").Where(Eq{"b": "c"}).ToBoundSQL()
+    // SELECT a FROM "t1" WHERE "b"='c'
+
+14. Insert, Update and Delete
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := Insert(Eq{"a": 1, "b": 2}, Eq{"a": 3, "b": 4}).Into("t1").ToSQL()
+    // INSERT INTO t1 (a,b) VALUES (?,?),(?,?) [1, 2, 3, 4]
+    sql, args, _ := InsertSelect(Select("a").From("t2"), "a").Into("t1").ToSQL()
+    // INSERT INTO t1 (a) SELECT a FROM t2 []
+    sql, args, _ := Dialect(POSTGRES).Update(Eq{"a": 1}).From("t1").InnerJoin("t2", "t2.id = t1.t2_id").Returning("id").ToSQL()
+    // UPDATE "t1" SET "a"=$1 FROM "t2" WHERE t2.id = t1.t2_id RETURNING "id" [1]
+
+15. JSON conditions, written in the dialect of the builder
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := Dialect(POSTGRES).Select("id").From("t1").Where(JSONEq("doc", "address.city", "Paris")).ToSQL()
+    // SELECT id FROM "t1" WHERE "doc"#>>'{address,city}'=$1 [Paris]
+    sql, args, _ := Dialect(MYSQL).Select("id").From("t1").Where(JSONContains("doc", []int{1}, "tags")).ToSQL()
+    // SELECT id FROM `t1` WHERE JSON_CONTAINS(`doc`,?,'$.tags') [[1]]
+    sql, args, _ := Dialect(MYSQL).Update(Eq{"doc": JSONSet("doc", map[string]interface{}{"a": 1})}).From("t1").ToSQL()
+    // UPDATE `t1` SET `doc`=JSON_SET(COALESCE(`doc`,'{}'),'$.a',CAST(? AS JSON)) [1]
+
+16. PostgreSQL array conditions
+
+    import . "github.com/coscms/xorm/builder"
+
+    sql, args, _ := Dialect(POSTGRES).Select("id").From("t1").Where(ArrayAny("tags", "go")).ToSQL()
+    // SELECT id FROM "t1" WHERE $1=ANY("tags") [go]
+    sql, args, _ := Dialect(POSTGRES).Select("id").From("t1").Where(ArrayContains("ids", []int64{1, 2})).ToSQL()
+    // SELECT id FROM "t1" WHERE "ids"@>$1 [{1,2}]
+    sql, args, _ := Dialect(POSTGRES).Select("id").From("t1").Where(ArrayOverlap("tags", []string{"go", "sql"})).ToSQL()
+    // SELECT id FROM "t1" WHERE "tags"&&$1 [{"go","sql"}]
+
+17. define yourself conditions
 Since Cond is a interface, you can define yourself conditions and compare with them
 */
 package builder
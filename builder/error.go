@@ -13,4 +13,10 @@ var (
 	ErrNoNotInConditions = errors.New("No NOT IN conditions")
 	// ErrNoInConditions no IN params error
 	ErrNoInConditions = errors.New("No IN conditions")
+	// ErrPlaceholderArgs the numbers of placeholders and arguments differ
+	ErrPlaceholderArgs = errors.New("The number of placeholders doesn't match the number of arguments")
+	// ErrNotSupportDialect the statement can't be written in the dialect
+	ErrNotSupportDialect = errors.New("not supported by the dialect")
+	// ErrInsertColumns the inserted rows have different columns
+	ErrInsertColumns = errors.New("The inserted rows have different columns")
 )
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/xorm"
+)
+
+var CmdCopy = &Command{
+	UsageLine: "copy [-data] driverName datasourceName targetDriverName targetDatasourceName [tables=patterns] [exclude=patterns] [batch=size]",
+	Short:     "copy the tables and their data to another database",
+	Long: `
+copy the tables and their rows from the source database to the target one, which may be
+of another type. The missing tables are created, their indexes are built after the rows
+are loaded and the autoincrement sequences are moved after the copied keys.
+
+    -data                   Only copy the data into the existing tables of the target
+    driverName              Source database driver name, now supported four: mysql mymysql sqlite3 postgres
+    datasourceName          Source database connection uri
+    targetDriverName        Target database driver name
+    targetDatasourceName    Target database connection uri
+    tables                  Comma separated patterns of the tables to copy, such as user_*,order
+    exclude                 Comma separated patterns of the tables not to copy
+    batch                   Number of rows per INSERT statement, default is 100
+`,
+}
+
+func init() {
+	CmdCopy.Run = runCopy
+	CmdCopy.Flags = map[string]bool{
+		"-data": false,
+	}
+}
+
+func printCopyPrompt(flag string) {
+}
+
+func runCopy(cmd *Command, args []string) {
+	num := checkFlags(cmd.Flags, args, printCopyPrompt)
+	if num == -1 {
+		return
+	}
+	args = args[num:]
+
+	if len(args) < 4 {
+		fmt.Println("params error, please see xorm help copy")
+		return
+	}
+
+	opts := xorm.CopyOptions{
+		DataOnly: cmd.Flags["-data"],
+	}
+	for _, arg := range args[4:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			fmt.Println("params error, please see xorm help copy")
+			return
+		}
+		switch kv[0] {
+		case "tables":
+			opts.Tables = strings.Split(kv[1], ",")
+		case "exclude":
+			opts.ExcludeTables = strings.Split(kv[1], ",")
+		case "batch":
+			size, err := strconv.Atoi(kv[1])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			opts.BatchSize = size
+		default:
+			fmt.Println("params error, please see xorm help copy")
+			return
+		}
+	}
+
+	source, err := openEngine(args[0], args[1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	target, err := openEngine(args[2], args[3])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if err = source.CopyToWithOptions(target, opts); err != nil {
+		fmt.Println(err)
+		setExitStatus(1)
+		return
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coscms/xorm"
+	"github.com/coscms/xorm/core"
+)
+
+var CmdDiff = &Command{
+	UsageLine: "diff [-sql] driverName datasourceName targetDriverName targetDatasourceName",
+	Short:     "compare the schema of two databases",
+	Long: `
+compare the tables, columns, indexes and primary keys of the target database with the
+source one and print the differences. The exit status is 1 when the schemas differ.
+
+    -sql                    Print the statements altering the target to the source schema
+    driverName              Source database driver name, now supported four: mysql mymysql sqlite3 postgres
+    datasourceName          Source database connection uri
+    targetDriverName        Target database driver name
+    targetDatasourceName    Target database connection uri
+`,
+}
+
+func init() {
+	CmdDiff.Run = runDiff
+	CmdDiff.Flags = map[string]bool{
+		"-sql": false,
+	}
+}
+
+func printDiffPrompt(flag string) {
+}
+
+func openEngine(driverName, dataSourceName string) (*xorm.Engine, error) {
+	e, err := xorm.NewEngine(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	e.ShowSQL(false)
+	e.Logger().SetLevel(core.LOG_UNKNOWN)
+
+	if err = e.Ping(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func runDiff(cmd *Command, args []string) {
+	num := checkFlags(cmd.Flags, args, printDiffPrompt)
+	if num == -1 {
+		return
+	}
+	args = args[num:]
+
+	if len(args) != 4 {
+		fmt.Println("params error, please see xorm help diff")
+		return
+	}
+
+	source, err := openEngine(args[0], args[1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer source.Close()
+	target, err := openEngine(args[2], args[3])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer target.Close()
+
+	expected, err := source.DBMetas()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	actual, err := target.DBMetas()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	diff := xorm.DiffTables(target.Dialect(), expected, actual)
+	if diff.IsEmpty() {
+		return
+	}
+	setExitStatus(1)
+
+	if cmd.Flags["-sql"] {
+		for _, sql := range diff.SQL() {
+			fmt.Printf("%s;\n", sql)
+		}
+		return
+	}
+
+	for _, table := range diff.MissingTables {
+		fmt.Printf("+ table %s\n", table.Name)
+	}
+	for _, table := range diff.ExtraTables {
+		fmt.Printf("- table %s\n", table.Name)
+	}
+	for _, table := range diff.Tables {
+		fmt.Printf("~ table %s\n", table.Name)
+		for _, col := range table.MissingColumns {
+			fmt.Printf("  + column %s\n", col.Name)
+		}
+		for _, col := range table.ExtraColumns {
+			fmt.Printf("  - column %s\n", col.Name)
+		}
+		for _, col := range table.ChangedColumns {
+			fmt.Printf("  ~ column %s\n", col)
+		}
+		for _, index := range table.MissingIndexes {
+			fmt.Printf("  + index %s (%v)\n", index.Name, index.Cols)
+		}
+		for _, index := range table.ExtraIndexes {
+			fmt.Printf("  - index %s (%v)\n", index.Name, index.Cols)
+		}
+		for _, rename := range table.RenamedIndexes {
+			fmt.Printf("  ~ index %s -> %s\n", rename.From.Name, rename.To.Name)
+		}
+		if table.PrimaryKeyChanged {
+			fmt.Printf("  ~ primary key %v -> %v\n", table.Actual.PrimaryKeys, table.Expected.PrimaryKeys)
+		}
+	}
+}
@@ -3,33 +3,86 @@ package main
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/coscms/xorm/core"
 	"github.com/coscms/xorm"
 )
 
 var CmdDump = &Command{
-	UsageLine: "dump driverName datasourceName",
+	UsageLine: "dump [-schema] [-data] [-gzip] driverName datasourceName [tables=patterns] [exclude=patterns] [batch=size] [type=dbType]",
 	Short:     "dump database all table struct's and data to standard output",
 	Long: `
 dump database for sqlite3, mysql, postgres.
 
+    -schema           Only dump the tables and indexes creation
+    -data             Only dump the data
+    -gzip             Compress the output with gzip
     driverName        Database driver name, now supported four: mysql mymysql sqlite3 postgres
     datasourceName    Database connection uri, for detail infomation please visit driver's project page
+    tables            Comma separated patterns of the tables to dump, such as user_*,order
+    exclude           Comma separated patterns of the tables not to dump
+    batch             Number of rows per INSERT statement, default is 100
+    type              Database type of the dumped SQL: mysql sqlite3 postgres mssql oracle
 `,
 }
 
 func init() {
 	CmdDump.Run = runDump
-	CmdDump.Flags = map[string]bool{}
+	CmdDump.Flags = map[string]bool{
+		"-schema": false,
+		"-data":   false,
+		"-gzip":   false,
+	}
+}
+
+func printDumpPrompt(flag string) {
 }
 
 func runDump(cmd *Command, args []string) {
-	if len(args) != 2 {
+	num := checkFlags(cmd.Flags, args, printDumpPrompt)
+	if num == -1 {
+		return
+	}
+	args = args[num:]
+
+	if len(args) < 2 {
 		fmt.Println("params error, please see xorm help dump")
 		return
 	}
 
+	opts := xorm.DumpOptions{
+		SchemaOnly: cmd.Flags["-schema"],
+		DataOnly:   cmd.Flags["-data"],
+		Gzip:       cmd.Flags["-gzip"],
+	}
+	for _, arg := range args[2:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			fmt.Println("params error, please see xorm help dump")
+			return
+		}
+		switch kv[0] {
+		case "tables":
+			opts.Tables = strings.Split(kv[1], ",")
+		case "exclude":
+			opts.ExcludeTables = strings.Split(kv[1], ",")
+		case "batch":
+			size, err := strconv.Atoi(kv[1])
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			opts.BatchSize = size
+		case "type":
+			opts.DBType = core.DbType(kv[1])
+		default:
+			fmt.Println("params error, please see xorm help dump")
+			return
+		}
+	}
+
 	var err error
 	engine, err = xorm.NewEngine(args[0], args[1])
 	if err != nil {
@@ -46,7 +99,7 @@ func runDump(cmd *Command, args []string) {
 		return
 	}
 
-	err = engine.DumpAll(os.Stdout)
+	err = engine.DumpWithOptions(os.Stdout, opts)
 	if err != nil {
 		fmt.Println(err)
 		return
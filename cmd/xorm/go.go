@@ -175,7 +175,7 @@ func genGoImports(tables []*core.Table) map[string]string {
 
 	for _, table := range tables {
 		for _, col := range table.Columns() {
-			if typestring(col) == "time.Time" {
+			if strings.TrimPrefix(typestring(col), "[]") == "time.Time" {
 				imports["time"] = "time"
 			}
 		}
@@ -188,7 +188,10 @@ func typestring(col *core.Column) string {
 	t := core.SQLType2Type(st)
 	s := t.String()
 	if s == "[]uint8" {
-		return "[]byte"
+		s = "[]byte"
+	}
+	if col.IsArray {
+		return "[]" + s
 	}
 	return s
 }
@@ -218,6 +221,9 @@ func tag(table *core.Table, col *core.Column) string {
 	if col.IsUpdated {
 		res = append(res, "updated")
 	}
+	if col.IsArray {
+		res = append(res, "array")
+	}
 	for name := range col.Indexes {
 		index := table.Indexes[name]
 		var uistr string
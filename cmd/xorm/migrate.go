@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/coscms/xorm"
+	"github.com/coscms/xorm/core"
+	"github.com/coscms/xorm/migrate"
+)
+
+var CmdMigrate = &Command{
+	UsageLine: "migrate driverName datasourceName migrationsDir [up|down|status|unlock] [id]",
+	Short:     "apply or roll back the SQL file migrations of migrationsDir",
+	Long: `
+migrate the database with the SQL files of a directory, named <id>_<description>.up.sql
+and <id>_<description>.down.sql. The applied migrations are recorded in the migrations table.
+
+    driverName        Database driver name, now supported four: mysql mymysql sqlite3 postgres
+    datasourceName    Database connection uri, for detail infomation please visit driver's project page
+    migrationsDir     Directory of the SQL files
+    up                Apply the pending migrations up to id, or all of them. It's the default action
+    down              Roll back the migrations after id, or only the last one
+    status            Print the applied and pending migrations
+    unlock            Release the lock left by an interrupted migration
+`,
+}
+
+func init() {
+	CmdMigrate.Run = runMigrate
+	CmdMigrate.Flags = map[string]bool{}
+}
+
+func runMigrate(cmd *Command, args []string) {
+	if len(args) < 3 || len(args) > 5 {
+		fmt.Println("params error, please see xorm help migrate")
+		return
+	}
+
+	action := "up"
+	if len(args) > 3 {
+		action = args[3]
+	}
+	var id string
+	if len(args) > 4 {
+		id = args[4]
+	}
+
+	var err error
+	engine, err = xorm.NewEngine(args[0], args[1])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	engine.ShowSQL(false)
+	engine.Logger().SetLevel(core.LOG_UNKNOWN)
+
+	err = engine.Ping()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	m := migrate.New(engine, nil, nil)
+	err = m.LoadDir(args[2])
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	switch action {
+	case "up":
+		err = m.MigrateTo(id)
+	case "down":
+		if id == "" {
+			err = m.RollbackLast()
+		} else {
+			err = m.RollbackTo(id)
+		}
+	case "status":
+		var status []*migrate.Status
+		status, err = m.Status()
+		for _, s := range status {
+			if s.Applied {
+				fmt.Printf("%s\tapplied at %s\t%s\n", s.ID, s.AppliedAt.Format("2006-01-02 15:04:05"), s.Description)
+			} else {
+				fmt.Printf("%s\tpending\t%s\n", s.ID, s.Description)
+			}
+		}
+	case "unlock":
+		err = m.Unlock()
+	default:
+		fmt.Println("unknown action, please see xorm help migrate")
+		return
+	}
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+}
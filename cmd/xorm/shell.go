@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -142,7 +143,7 @@ func runShell(cmd *Command, args []string) {
 				}
 			}
 		} else if lcmd == "show tables" {
-			tables, err := engine.Dialect().GetTables()
+			tables, err := engine.Dialect().GetTables(context.Background())
 			if err != nil {
 				fmt.Println(err)
 			} else {
@@ -199,7 +200,7 @@ func runShell(cmd *Command, args []string) {
 		} else if strings.HasPrefix(lcmd, "columns") {
 			fields := strings.Fields(strings.TrimRight(scmd, ";"))
 			if len(fields) == 2 {
-				_, columns, err := engine.Dialect().GetColumns(fields[1])
+				_, columns, err := engine.Dialect().GetColumns(context.Background(), fields[1])
 				if err != nil {
 					fmt.Println(err)
 				} else {
@@ -238,7 +239,7 @@ func runShell(cmd *Command, args []string) {
 		} else if strings.HasPrefix(lcmd, "indexes") {
 			fields := strings.Fields(strings.TrimRight(scmd, ";"))
 			if len(fields) == 2 {
-				indexes, err := engine.Dialect().GetIndexes(fields[1])
+				indexes, err := engine.Dialect().GetIndexes(context.Background(), fields[1])
 				if err != nil {
 					fmt.Println(err)
 				} else {
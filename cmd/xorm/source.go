@@ -9,11 +9,14 @@ import (
 )
 
 var CmdSource = &Command{
-	UsageLine: "source driverName datasourceName",
+	UsageLine: "source [-tx] [-force] [-progress] driverName datasourceName",
 	Short:     "source execute std in to datasourceName",
 	Long: `
 source from standard std in for sqlite3, mysql, postgres.
 
+    -tx               Run the whole script in one transaction
+    -force            Continue after a failed statement and report the errors at the end, not with -tx
+    -progress         Print the progress to standard error
     driverName        Database driver name, now supported four: mysql mymysql sqlite3 postgres
     datasourceName    Database connection uri, for detail infomation please visit driver's project page
 `,
@@ -21,10 +24,23 @@ source from standard std in for sqlite3, mysql, postgres.
 
 func init() {
 	CmdSource.Run = runSource
-	CmdSource.Flags = map[string]bool{}
+	CmdSource.Flags = map[string]bool{
+		"-tx":       false,
+		"-force":    false,
+		"-progress": false,
+	}
+}
+
+func printSourcePrompt(flag string) {
 }
 
 func runSource(cmd *Command, args []string) {
+	num := checkFlags(cmd.Flags, args, printSourcePrompt)
+	if num == -1 {
+		return
+	}
+	args = args[num:]
+
 	if len(args) != 2 {
 		fmt.Println("params error, please see xorm help source")
 		return
@@ -46,12 +62,29 @@ func runSource(cmd *Command, args []string) {
 		return
 	}
 
-	_, err = engine.Import(os.Stdin)
-	if err.Error() == "not an error" {
-		err = nil
+	opts := xorm.ImportOptions{
+		UseTransaction:  cmd.Flags["-tx"],
+		ContinueOnError: cmd.Flags["-force"],
+	}
+	if cmd.Flags["-progress"] {
+		opts.Progress = func(p *xorm.ImportProgress) {
+			fmt.Fprintf(os.Stderr, "\r%d statements, %d failed, %d bytes read", p.Statements, p.Failed, p.BytesRead)
+		}
+	}
+	_, err = engine.ImportWithOptions(os.Stdin, opts)
+	if cmd.Flags["-progress"] {
+		fmt.Fprintln(os.Stderr)
+	}
+	if errs, ok := err.(xorm.ImportErrors); ok {
+		for _, e := range errs {
+			fmt.Println(e)
+		}
+		setExitStatus(1)
+		return
 	}
 	if err != nil {
 		fmt.Println(err)
+		setExitStatus(1)
 		return
 	}
 }
@@ -33,6 +33,9 @@ var commands = []*Command{
 	CmdDump,
 	CmdDriver,
 	CmdSource,
+	CmdMigrate,
+	CmdDiff,
+	CmdCopy,
 }
 
 func init() {
@@ -0,0 +1,134 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ArrayLiteral returns the PostgreSQL array literal of the one dimension
+// slice or array v, e.g. {1,2} or {"a","b"}. The nil pointers are NULL.
+func ArrayLiteral(v reflect.Value) (string, error) {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("unsupported array type %v", v.Type())
+	}
+	elems := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem, err := arrayElem(v.Index(i))
+		if err != nil {
+			return "", err
+		}
+		elems[i] = elem
+	}
+	return "{" + strings.Join(elems, ",") + "}", nil
+}
+
+func arrayElem(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "NULL", nil
+		}
+		return arrayElem(v.Elem())
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return "t", nil
+		}
+		return "f", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.String:
+		return quoteArrayElem(v.String()), nil
+	case reflect.Struct:
+		if v.Type().ConvertibleTo(TimeType) {
+			t := v.Convert(TimeType).Interface().(time.Time)
+			return quoteArrayElem(t.Format(time.RFC3339Nano)), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported array element type %v", v.Type())
+}
+
+// quoteArrayElem double quotes s, so that the empty strings, the spaces, the
+// commas, the braces and NULL are kept
+func quoteArrayElem(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return `"` + s + `"`
+}
+
+// ParseArrayLiteral returns the elements of the one dimension PostgreSQL
+// array literal s, the NULL elements are nil
+func ParseArrayLiteral(s string) ([]*string, error) {
+	// skip the dimensions decoration, e.g. [0:1]={1,2}
+	if strings.HasPrefix(s, "[") {
+		i := strings.Index(s, "=")
+		if i < 0 {
+			return nil, ErrArrayLiteral
+		}
+		s = s[i+1:]
+	}
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, ErrArrayLiteral
+	}
+	s = s[1 : len(s)-1]
+
+	var elems []*string
+	for i := 0; i < len(s); {
+		var elem string
+		var quoted bool
+		switch s[i] {
+		case '{':
+			return nil, errors.New("multidimensional arrays are not supported")
+		case '"':
+			quoted = true
+			var buf []byte
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+					if i == len(s) {
+						return nil, ErrArrayLiteral
+					}
+				}
+				buf = append(buf, s[i])
+			}
+			if i == len(s) {
+				return nil, ErrArrayLiteral
+			}
+			i++
+			elem = string(buf)
+		default:
+			j := strings.IndexByte(s[i:], ',')
+			if j < 0 {
+				j = len(s) - i
+			}
+			elem = strings.TrimSpace(s[i : i+j])
+			i += j
+		}
+
+		if !quoted && strings.EqualFold(elem, "NULL") {
+			elems = append(elems, nil)
+		} else {
+			elems = append(elems, &elem)
+		}
+
+		if i < len(s) {
+			if s[i] != ',' {
+				return nil, ErrArrayLiteral
+			}
+			i++
+			if i == len(s) {
+				return nil, ErrArrayLiteral
+			}
+		}
+	}
+	return elems, nil
+}
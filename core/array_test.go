@@ -0,0 +1,69 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArrayLiteral(t *testing.T) {
+	s := "b"
+	var cases = []struct {
+		v       interface{}
+		literal string
+	}{
+		{[]int64{1, -2}, "{1,-2}"},
+		{[]string{}, "{}"},
+		{[]string{"a b", `c"d`, `e\f`, "", "NULL"}, `{"a b","c\"d","e\\f","","NULL"}`},
+		{[]bool{true, false}, "{t,f}"},
+		{[]float64{1.5}, "{1.5}"},
+		{[2]*string{nil, &s}, `{NULL,"b"}`},
+	}
+
+	for _, k := range cases {
+		literal, err := ArrayLiteral(reflect.ValueOf(k.v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if literal != k.literal {
+			t.Fatal("want", k.literal, "get", literal)
+		}
+	}
+
+	if _, err := ArrayLiteral(reflect.ValueOf([]struct{}{{}})); err == nil {
+		t.Fatal("want an error")
+	}
+}
+
+func TestParseArrayLiteral(t *testing.T) {
+	var cases = []struct {
+		literal string
+		elems   []interface{}
+	}{
+		{"{}", []interface{}{}},
+		{"{1,-2}", []interface{}{"1", "-2"}},
+		{`{"a b","c\"d","e\\f","",NULL,"NULL"}`, []interface{}{"a b", `c"d`, `e\f`, "", nil, "NULL"}},
+		{"[0:1]={t,f}", []interface{}{"t", "f"}},
+	}
+
+	for _, k := range cases {
+		elems, err := ParseArrayLiteral(k.literal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]interface{}, len(elems))
+		for i, elem := range elems {
+			if elem != nil {
+				got[i] = *elem
+			}
+		}
+		if !reflect.DeepEqual(got, k.elems) {
+			t.Fatal("want", k.elems, "get", got)
+		}
+	}
+
+	for _, literal := range []string{"", "1,2", "{1,}", `{"a}`, "{{1},{2}}", `{"a"b}`} {
+		if _, err := ParseArrayLiteral(literal); err == nil {
+			t.Fatal("want an error for", literal)
+		}
+	}
+}
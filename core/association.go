@@ -0,0 +1,26 @@
+package core
+
+// kinds of the associations of a struct with other tables
+const (
+	BelongsTo = iota + 1
+	HasOne
+	HasMany
+	ManyToMany
+)
+
+// Association is a field of a struct holding the related records of another
+// table, declared by a belongs_to, has_one, has_many or many_to_many tag. It
+// is not a column of the table.
+type Association struct {
+	Kind      int
+	FieldName string
+	// ForeignKeys are the columns given by the tag: the columns of the table
+	// referencing the primary keys of the related table for BelongsTo, the
+	// columns of the related table referencing the primary keys of the table
+	// for HasOne and HasMany, the columns of the join table referencing the
+	// primary keys of the table then of the related table for ManyToMany.
+	// They are derived from the struct names when empty.
+	ForeignKeys []string
+	// JoinTable is the join table of ManyToMany
+	JoinTable string
+}
@@ -33,6 +33,7 @@ type Column struct {
 	IsDeleted       bool
 	IsCascade       bool
 	IsVersion       bool
+	IsArray         bool // PostgreSQL array of SQLType
 	fieldPath       []string
 	DefaultIsEmpty  bool
 	EnumOptions     []string
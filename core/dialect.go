@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
@@ -66,12 +67,21 @@ type Dialect interface {
 
 	ForUpdateSql(query string) string
 
+	// SavePointSql, RollbackToSavePointSql and ReleaseSavePointSql return
+	// the statements of the savepoints of nested transactions, the release
+	// statement is empty when the database has none
+	SavePointSql(name string) string
+	RollbackToSavePointSql(name string) string
+	ReleaseSavePointSql(name string) string
+
 	//CreateTableIfNotExists(table *Table, tableName, storeEngine, charset string) error
 	//MustDropTable(tableName string) error
 
-	GetColumns(tableName string) ([]string, map[string]*Column, error)
-	GetTables() ([]*Table, error)
-	GetIndexes(tableName string) (map[string]*Index, error)
+	// GetColumns, GetTables and GetIndexes read the metadata of the
+	// database, the queries receive the context
+	GetColumns(ctx context.Context, tableName string) ([]string, map[string]*Column, error)
+	GetTables(ctx context.Context) ([]*Table, error)
+	GetIndexes(ctx context.Context, tableName string) (map[string]*Index, error)
 
 	Filters() []Filter
 
@@ -278,6 +288,18 @@ func (b *Base) ForUpdateSql(query string) string {
 	return query + " FOR UPDATE"
 }
 
+func (b *Base) SavePointSql(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (b *Base) RollbackToSavePointSql(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (b *Base) ReleaseSavePointSql(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
 func (b *Base) LogSQL(sql string, args []interface{}) {
 	if b.logger != nil && b.logger.IsShowSQL() {
 		if len(args) > 0 {
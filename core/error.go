@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrNoMapPointer    = errors.New("mp should be a map's pointer")
 	ErrNoStructPointer = errors.New("mp should be a struct's pointer")
+	ErrArrayLiteral    = errors.New("malformed array literal")
 )
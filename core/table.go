@@ -14,17 +14,22 @@ type Table struct {
 	// 表字段名称或对应的列信息(支持多个相同名称的字段)
 	columnsMap map[string][]*Column
 	// 关联的所有表字段
-	columns       []*Column
-	Indexes       map[string]*Index
-	PrimaryKeys   []string
-	AutoIncrement string
-	Created       map[string]bool
-	Updated       string
-	Deleted       string
-	Version       string
-	Cacher        Cacher
-	StoreEngine   string
-	Charset       string
+	columns     []*Column
+	Indexes     map[string]*Index
+	PrimaryKeys []string
+	// the name of the primary key constraint, read from the database by the
+	// dialects which name it such as PostgreSQL
+	PrimaryKeyName string
+	AutoIncrement  string
+	Created        map[string]bool
+	Updated        string
+	Deleted        string
+	Version        string
+	Cacher         Cacher
+	StoreEngine    string
+	Charset        string
+	// 关联的其它表记录字段
+	Associations []*Association
 
 	// 表关联信息
 	Relation *Relation
@@ -137,6 +142,16 @@ func (table *Table) AddColumn(col *Column) {
 	}
 }
 
+// GetAssociation returns the association of the struct field
+func (table *Table) GetAssociation(fieldName string) *Association {
+	for _, assoc := range table.Associations {
+		if assoc.FieldName == fieldName {
+			return assoc
+		}
+	}
+	return nil
+}
+
 // add an index or an unique to table
 func (table *Table) AddIndex(index *Index) {
 	table.Indexes[index.Name] = index
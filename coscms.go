@@ -299,13 +299,16 @@ func (session *Session) queryRows(sqlStr string, params ...interface{}) (rows *c
 	session.queryPreprocess(&sqlStr, params...)
 
 	if session.IsAutoCommit {
-		return session.innerQueryRows(session.DB(), sqlStr, params...)
+		return session.innerQueryRows(session.rawQueryDB(sqlStr), sqlStr, params...)
 	}
 	return session.txQueryRows(session.Tx, sqlStr, params...)
 }
 
 func (session *Session) txQueryRows(tx *core.Tx, sqlStr string, params ...interface{}) (rows *core.Rows, err error) {
-	rows, err = tx.Query(sqlStr, params...)
+	_, rows, err = session.runQuery(sqlStr, params, func() (*core.Stmt, *core.Rows, error) {
+		rows, err := tx.QueryContext(session.ctx, sqlStr, params...)
+		return nil, rows, err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -313,12 +316,12 @@ func (session *Session) txQueryRows(tx *core.Tx, sqlStr string, params ...interf
 }
 
 func (session *Session) innerQueryRows(db *core.DB, sqlStr string, params ...interface{}) (rows *core.Rows, err error) {
-	stmt, rows, err := session.Engine.logSQLQueryTime(sqlStr, params, func() (*core.Stmt, *core.Rows, error) {
-		stmt, err := db.Prepare(sqlStr)
+	stmt, rows, err := session.runQuery(sqlStr, params, func() (*core.Stmt, *core.Rows, error) {
+		stmt, err := db.PrepareContext(session.ctx, sqlStr)
 		if err != nil {
 			return stmt, nil, err
 		}
-		rows, err := stmt.Query(params...)
+		rows, err := stmt.QueryContext(session.ctx, params...)
 
 		return stmt, rows, err
 	})
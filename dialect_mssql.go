@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -331,7 +332,7 @@ func (db *mssql) TableCheckSql(tableName string) (string, []interface{}) {
 	return sql, args
 }
 
-func (db *mssql) GetColumns(tableName string) ([]string, map[string]*core.Column, error) {
+func (db *mssql) GetColumns(ctx context.Context, tableName string) ([]string, map[string]*core.Column, error) {
 	args := []interface{}{}
 	s := `select a.name as name, b.name as ctype,a.max_length,a.precision,a.scale,a.is_nullable as nullable,
 	      replace(replace(isnull(c.text,''),'(',''),')','') as vdefault   
@@ -340,7 +341,7 @@ func (db *mssql) GetColumns(tableName string) ([]string, map[string]*core.Column
           where a.object_id=object_id('` + tableName + `')`
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -400,12 +401,12 @@ func (db *mssql) GetColumns(tableName string) ([]string, map[string]*core.Column
 	return colSeq, cols, nil
 }
 
-func (db *mssql) GetTables() ([]*core.Table, error) {
+func (db *mssql) GetTables(ctx context.Context) ([]*core.Table, error) {
 	args := []interface{}{}
 	s := `select name from sysobjects where xtype ='U'`
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -425,7 +426,7 @@ func (db *mssql) GetTables() ([]*core.Table, error) {
 	return tables, nil
 }
 
-func (db *mssql) GetIndexes(tableName string) (map[string]*core.Index, error) {
+func (db *mssql) GetIndexes(ctx context.Context, tableName string) (map[string]*core.Index, error) {
 	args := []interface{}{tableName}
 	s := `SELECT
 IXS.NAME                    AS  [INDEX_NAME],
@@ -440,7 +441,7 @@ WHERE IXS.TYPE_DESC='NONCLUSTERED' and OBJECT_NAME(IXS.OBJECT_ID) =?
 `
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -524,6 +525,20 @@ func (db *mssql) ForUpdateSql(query string) string {
 	return query
 }
 
+func (db *mssql) SavePointSql(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+func (db *mssql) RollbackToSavePointSql(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+// ReleaseSavePointSql returns nothing, the savepoints of SQL Server last
+// until the end of the transaction
+func (db *mssql) ReleaseSavePointSql(name string) string {
+	return ""
+}
+
 func (db *mssql) Filters() []core.Filter {
 	return []core.Filter{&core.IdFilter{}, &core.QuoteFilter{}}
 }
@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
@@ -545,6 +546,16 @@ func (db *oracle) SupportInsertMany() bool {
 	return true
 }
 
+// ReleaseSavePointSql returns nothing, the savepoints of Oracle last until the
+// end of the transaction
+func (db *oracle) ReleaseSavePointSql(name string) string {
+	return ""
+}
+
+func (db *oracle) FormatBytes(bs []byte) string {
+	return fmt.Sprintf("HEXTORAW('%x')", bs)
+}
+
 func (db *oracle) IsReserved(name string) bool {
 	_, ok := oracleReservedWords[name]
 	return ok
@@ -677,13 +688,13 @@ func (db *oracle) IsColumnExist(tableName, colName string) (bool, error) {
 	return false, nil
 }
 
-func (db *oracle) GetColumns(tableName string) ([]string, map[string]*core.Column, error) {
+func (db *oracle) GetColumns(ctx context.Context, tableName string) ([]string, map[string]*core.Column, error) {
 	args := []interface{}{tableName}
 	s := "SELECT column_name,data_default,data_type,data_length,data_precision,data_scale," +
 		"nullable FROM USER_TAB_COLUMNS WHERE table_name = :1"
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -775,12 +786,12 @@ func (db *oracle) GetColumns(tableName string) ([]string, map[string]*core.Colum
 	return colSeq, cols, nil
 }
 
-func (db *oracle) GetTables() ([]*core.Table, error) {
+func (db *oracle) GetTables(ctx context.Context) ([]*core.Table, error) {
 	args := []interface{}{}
 	s := "SELECT table_name FROM user_tables"
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -799,13 +810,13 @@ func (db *oracle) GetTables() ([]*core.Table, error) {
 	return tables, nil
 }
 
-func (db *oracle) GetIndexes(tableName string) (map[string]*core.Index, error) {
+func (db *oracle) GetIndexes(ctx context.Context, tableName string) (map[string]*core.Index, error) {
 	args := []interface{}{tableName}
 	s := "SELECT t.column_name,i.uniqueness,i.index_name FROM user_ind_columns t,user_indexes i " +
 		"WHERE t.index_name = i.index_name and t.table_name = i.table_name and t.table_name =:1"
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -776,6 +777,13 @@ func (db *postgres) Init(d *core.DB, uri *core.Uri, drivername, dataSourceName s
 }
 
 func (db *postgres) SqlType(c *core.Column) string {
+	if c.IsArray {
+		return db.sqlType(c) + "[]"
+	}
+	return db.sqlType(c)
+}
+
+func (db *postgres) sqlType(c *core.Column) string {
 	var res string
 	switch t := c.SQLType.Name; t {
 	case core.TinyInt:
@@ -829,6 +837,10 @@ func (db *postgres) SupportInsertMany() bool {
 	return true
 }
 
+func (db *postgres) FormatBytes(bs []byte) string {
+	return fmt.Sprintf("'\\x%x'", bs)
+}
+
 func (db *postgres) IsReserved(name string) bool {
 	_, ok := postgresReservedWords[name]
 	return ok
@@ -911,9 +923,9 @@ func (db *postgres) IsColumnExist(tableName, colName string) (bool, error) {
 	return rows.Next(), nil
 }
 
-func (db *postgres) GetColumns(tableName string) ([]string, map[string]*core.Column, error) {
+func (db *postgres) GetColumns(ctx context.Context, tableName string) ([]string, map[string]*core.Column, error) {
 	args := []interface{}{tableName, db.URI().Schema}
-	s := `SELECT column_name, column_default, is_nullable, data_type, character_maximum_length, numeric_precision, numeric_precision_radix ,
+	s := `SELECT column_name, column_default, is_nullable, data_type, character_maximum_length, numeric_precision, numeric_precision_radix , s.udt_name,
     CASE WHEN p.contype = 'p' THEN true ELSE false END AS primarykey,
     CASE WHEN p.contype = 'u' THEN true ELSE false END AS uniquekey
 FROM pg_attribute f
@@ -926,7 +938,7 @@ FROM pg_attribute f
 WHERE c.relkind = 'r'::char AND c.relname = $1 AND s.table_schema = $2 AND f.attnum > 0 ORDER BY f.attnum;`
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -939,10 +951,10 @@ WHERE c.relkind = 'r'::char AND c.relname = $1 AND s.table_schema = $2 AND f.att
 		col := new(core.Column)
 		col.Indexes = make(map[string]int)
 
-		var colName, isNullable, dataType string
+		var colName, isNullable, dataType, udtName string
 		var maxLenStr, colDefault, numPrecision, numRadix *string
 		var isPK, isUnique bool
-		err = rows.Scan(&colName, &colDefault, &isNullable, &dataType, &maxLenStr, &numPrecision, &numRadix, &isPK, &isUnique)
+		err = rows.Scan(&colName, &colDefault, &isNullable, &dataType, &maxLenStr, &numPrecision, &numRadix, &udtName, &isPK, &isUnique)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -987,6 +999,10 @@ WHERE c.relkind = 'r'::char AND c.relname = $1 AND s.table_schema = $2 AND f.att
 			col.SQLType = core.SQLType{core.Time, 0, 0}
 		case "oid":
 			col.SQLType = core.SQLType{core.BigInt, 0, 0}
+		case "ARRAY":
+			// the element type is the udt name without its leading _
+			col.IsArray = true
+			col.SQLType = core.SQLType{Name: postgresArrayElemType(strings.TrimPrefix(udtName, "_"))}
 		default:
 			col.SQLType = core.SQLType{strings.ToUpper(dataType), 0, 0}
 		}
@@ -996,7 +1012,7 @@ WHERE c.relkind = 'r'::char AND c.relname = $1 AND s.table_schema = $2 AND f.att
 
 		col.Length = maxLen
 
-		if col.SQLType.IsText() || col.SQLType.IsTime() {
+		if (col.SQLType.IsText() || col.SQLType.IsTime()) && !col.IsArray {
 			if col.Default != "" {
 				col.Default = "'" + col.Default + "'"
 			} else {
@@ -1012,12 +1028,42 @@ WHERE c.relkind = 'r'::char AND c.relname = $1 AND s.table_schema = $2 AND f.att
 	return colSeq, cols, nil
 }
 
-func (db *postgres) GetTables() ([]*core.Table, error) {
+// postgresArrayElemType returns the SQL type of the elements of the arrays
+// whose internal type is udtName
+func postgresArrayElemType(udtName string) string {
+	switch udtName {
+	case "int2":
+		return core.SmallInt
+	case "int4":
+		return core.Integer
+	case "int8":
+		return core.BigInt
+	case "float4":
+		return core.Real
+	case "float8":
+		return core.Double
+	case "bool":
+		return core.Bool
+	case "bpchar":
+		return core.Char
+	case "timestamp":
+		return core.DateTime
+	case "timestamptz":
+		return core.TimeStampz
+	}
+	return strings.ToUpper(udtName)
+}
+
+func (db *postgres) GetTables(ctx context.Context) ([]*core.Table, error) {
 	args := []interface{}{}
-	s := fmt.Sprintf("SELECT tablename FROM pg_tables where schemaname = '%s'", db.Uri.Schema)
+	s := fmt.Sprintf(`SELECT t.tablename, p.conname FROM pg_tables t
+    LEFT JOIN pg_namespace n ON n.nspname = t.schemaname
+    LEFT JOIN pg_class c ON c.relname = t.tablename AND c.relnamespace = n.oid
+    LEFT JOIN pg_constraint p ON p.conrelid = c.oid AND p.contype = 'p'
+WHERE t.schemaname = '%s'`, db.Uri.Schema)
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -1027,22 +1073,26 @@ func (db *postgres) GetTables() ([]*core.Table, error) {
 	for rows.Next() {
 		table := core.NewEmptyTable()
 		var name string
-		err = rows.Scan(&name)
+		var pkName *string
+		err = rows.Scan(&name, &pkName)
 		if err != nil {
 			return nil, err
 		}
 		table.Name = name
+		if pkName != nil {
+			table.PrimaryKeyName = *pkName
+		}
 		tables = append(tables, table)
 	}
 	return tables, nil
 }
 
-func (db *postgres) GetIndexes(tableName string) (map[string]*core.Index, error) {
+func (db *postgres) GetIndexes(ctx context.Context, tableName string) (map[string]*core.Index, error) {
 	args := []interface{}{tableName}
 	s := fmt.Sprintf("SELECT indexname, indexdef FROM pg_indexes WHERE schemaname='%s' AND tablename=$1", db.URI().Schema)
 	db.LogSQL(s, args)
 
-	rows, err := db.DB().Query(s, args...)
+	rows, err := db.DB().QueryContext(ctx, s, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -5,8 +5,7 @@
 package xorm
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"database/sql"
 	"encoding/gob"
 	"errors"
@@ -48,6 +47,10 @@ type Engine struct {
 	RelTagIdentifier   string
 	AliasTagIdentifier string
 	TLogger            *TLogger
+
+	hooks  []Hook
+	scopes []*Scope
+	group  *EngineGroup
 }
 
 // ShowSQL show SQL statment or not on logger if log level is great than INFO
@@ -310,6 +313,14 @@ func (engine *Engine) SQL(query interface{}, args ...interface{}) *Session {
 	return session.SQL(query, args...)
 }
 
+// Context creates a session with the context, every database call made by
+// the session will receive the context
+func (engine *Engine) Context(ctx context.Context) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.Context(ctx)
+}
+
 // NoAutoTime Default if your struct has "created" or "updated" filed tag, the fields
 // will automatically be filled with current time when Insert or Update
 // invoked. Call NoAutoTime if you dont' want to fill automatically.
@@ -326,15 +337,27 @@ func (engine *Engine) NoAutoCondition(no ...bool) *Session {
 	return session.NoAutoCondition(no...)
 }
 
+// NoVersionCheck disables the optimistic locking of the version column
+func (engine *Engine) NoVersionCheck(no ...bool) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.NoVersionCheck(no...)
+}
+
 // DBMetas Retrieve all tables, columns, indexes' informations from database.
 func (engine *Engine) DBMetas() ([]*core.Table, error) {
-	tables, err := engine.dialect.GetTables()
+	return engine.dbMetas(context.Background())
+}
+
+// dbMetas retrieves the tables, columns and indexes with the context
+func (engine *Engine) dbMetas(ctx context.Context) ([]*core.Table, error) {
+	tables, err := engine.dialect.GetTables(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, table := range tables {
-		colSeq, cols, err := engine.dialect.GetColumns(table.Name)
+		colSeq, cols, err := engine.dialect.GetColumns(ctx, table.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -343,7 +366,7 @@ func (engine *Engine) DBMetas() ([]*core.Table, error) {
 		}
 		//table.Columns = cols
 		//table.ColumnsSeq = colSeq
-		indexes, err := engine.dialect.GetIndexes(table.Name)
+		indexes, err := engine.dialect.GetIndexes(ctx, table.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -431,217 +454,20 @@ func getTableName(v interface{}) (name string) {
 
 // DumpAll dump database all table structs and data to w with specify db type
 func (engine *Engine) dumpAll(w io.Writer, tp ...core.DbType) error {
-	tables, err := engine.DBMetas()
-	if err != nil {
-		return err
-	}
-
-	var dialect core.Dialect
-	if len(tp) == 0 {
-		dialect = engine.dialect
-	} else {
-		dialect = core.QueryDialect(tp[0])
-		if dialect == nil {
-			return errors.New("Unsupported database type.")
-		}
-		dialect.Init(nil, engine.dialect.URI(), "", "")
-	}
-
-	_, err = io.WriteString(w, fmt.Sprintf("/*Generated by xorm v%s %s*/\n\n",
-		Version, time.Now().In(engine.TZLocation).Format("2006-01-02 15:04:05")))
-	if err != nil {
-		return err
+	var opts DumpOptions
+	if len(tp) > 0 {
+		opts.DBType = tp[0]
 	}
-
-	for i, table := range tables {
-		if i > 0 {
-			_, err = io.WriteString(w, "\n")
-			if err != nil {
-				return err
-			}
-		}
-		_, err = io.WriteString(w, dialect.CreateTableSql(table, "", table.StoreEngine, "")+";\n")
-		if err != nil {
-			return err
-		}
-		for _, index := range table.Indexes {
-			_, err = io.WriteString(w, dialect.CreateIndexSql(table.Name, index)+";\n")
-			if err != nil {
-				return err
-			}
-		}
-
-		rows, err := engine.DB().Query("SELECT * FROM " + engine.Quote(table.Name))
-		if err != nil {
-			return err
-		}
-		defer rows.Close()
-
-		cols, err := rows.Columns()
-		if err != nil {
-			return err
-		}
-		if len(cols) == 0 {
-			continue
-		}
-		for rows.Next() {
-			dest := make([]interface{}, len(cols))
-			err = rows.ScanSlice(&dest)
-			if err != nil {
-				return err
-			}
-
-			_, err = io.WriteString(w, "INSERT INTO "+dialect.Quote(table.Name)+" ("+dialect.Quote(strings.Join(cols, dialect.Quote(", ")))+") VALUES (")
-			if err != nil {
-				return err
-			}
-
-			var temp string
-			for i, d := range dest {
-				col := table.GetColumn(cols[i])
-				if d == nil {
-					temp += ", NULL"
-				} else if col.SQLType.IsText() || col.SQLType.IsTime() {
-					var v = fmt.Sprintf("%s", d)
-					temp += ", '" + strings.Replace(v, "'", "''", -1) + "'"
-				} else if col.SQLType.IsBlob() {
-					if reflect.TypeOf(d).Kind() == reflect.Slice {
-						temp += fmt.Sprintf(", %s", dialect.FormatBytes(d.([]byte)))
-					} else if reflect.TypeOf(d).Kind() == reflect.String {
-						temp += fmt.Sprintf(", '%s'", d.(string))
-					}
-				} else if col.SQLType.IsNumeric() {
-					switch reflect.TypeOf(d).Kind() {
-					case reflect.Slice:
-						temp += fmt.Sprintf(", %s", string(d.([]byte)))
-					default:
-						temp += fmt.Sprintf(", %v", d)
-					}
-				} else {
-					s := fmt.Sprintf("%v", d)
-					if strings.Contains(s, ":") || strings.Contains(s, "-") {
-						temp += fmt.Sprintf(", '%s'", s)
-					} else {
-						temp += fmt.Sprintf(", %s", s)
-					}
-				}
-			}
-			_, err = io.WriteString(w, temp[2:]+");\n")
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	return engine.DumpWithOptions(w, opts)
 }
 
 // DumpAll dump database all table structs and data to w with specify db type
 func (engine *Engine) dumpTables(tables []*core.Table, w io.Writer, tp ...core.DbType) error {
-	var dialect core.Dialect
-	if len(tp) == 0 {
-		dialect = engine.dialect
-	} else {
-		dialect = core.QueryDialect(tp[0])
-		if dialect == nil {
-			return errors.New("Unsupported database type.")
-		}
-		dialect.Init(nil, engine.dialect.URI(), "", "")
-	}
-
-	_, err := io.WriteString(w, fmt.Sprintf("/*Generated by xorm v%s %s, from %s to %s*/\n\n",
-		Version, time.Now().In(engine.TZLocation).Format("2006-01-02 15:04:05"), engine.dialect.DBType(), dialect.DBType()))
-	if err != nil {
-		return err
-	}
-
-	for i, table := range tables {
-		if i > 0 {
-			_, err = io.WriteString(w, "\n")
-			if err != nil {
-				return err
-			}
-		}
-		_, err = io.WriteString(w, dialect.CreateTableSql(table, "", table.StoreEngine, "")+";\n")
-		if err != nil {
-			return err
-		}
-		for _, index := range table.Indexes {
-			_, err = io.WriteString(w, dialect.CreateIndexSql(table.Name, index)+";\n")
-			if err != nil {
-				return err
-			}
-		}
-
-		rows, err := engine.DB().Query("SELECT * FROM " + engine.Quote(table.Name))
-		if err != nil {
-			return err
-		}
-		defer rows.Close()
-
-		cols, err := rows.Columns()
-		if err != nil {
-			return err
-		}
-		if len(cols) == 0 {
-			continue
-		}
-		for rows.Next() {
-			dest := make([]interface{}, len(cols))
-			err = rows.ScanSlice(&dest)
-			if err != nil {
-				return err
-			}
-
-			_, err = io.WriteString(w, "INSERT INTO "+dialect.Quote(table.Name)+" ("+dialect.Quote(strings.Join(cols, dialect.Quote(", ")))+") VALUES (")
-			if err != nil {
-				return err
-			}
-
-			var temp string
-			for i, d := range dest {
-				col := table.GetColumn(cols[i])
-				if d == nil {
-					temp += ", NULL"
-				} else if col.SQLType.IsText() || col.SQLType.IsTime() {
-					var v = fmt.Sprintf("%s", d)
-					if strings.HasSuffix(v, " +0000 UTC") {
-						temp += fmt.Sprintf(", '%s'", v[0:len(v)-len(" +0000 UTC")])
-					} else {
-						temp += ", '" + strings.Replace(v, "'", "''", -1) + "'"
-					}
-				} else if col.SQLType.IsBlob() {
-					if reflect.TypeOf(d).Kind() == reflect.Slice {
-						temp += fmt.Sprintf(", %s", dialect.FormatBytes(d.([]byte)))
-					} else if reflect.TypeOf(d).Kind() == reflect.String {
-						temp += fmt.Sprintf(", '%s'", d.(string))
-					}
-				} else if col.SQLType.IsNumeric() {
-					switch reflect.TypeOf(d).Kind() {
-					case reflect.Slice:
-						temp += fmt.Sprintf(", %s", string(d.([]byte)))
-					default:
-						temp += fmt.Sprintf(", %v", d)
-					}
-				} else {
-					s := fmt.Sprintf("%v", d)
-					if strings.Contains(s, ":") || strings.Contains(s, "-") {
-						if strings.HasSuffix(s, " +0000 UTC") {
-							temp += fmt.Sprintf(", '%s'", s[0:len(s)-len(" +0000 UTC")])
-						} else {
-							temp += fmt.Sprintf(", '%s'", s)
-						}
-					} else {
-						temp += fmt.Sprintf(", %s", s)
-					}
-				}
-			}
-			_, err = io.WriteString(w, temp[2:]+");\n")
-			if err != nil {
-				return err
-			}
-		}
+	var opts DumpOptions
+	if len(tp) > 0 {
+		opts.DBType = tp[0]
 	}
-	return nil
+	return engine.DumpTablesWithOptions(tables, w, opts)
 }
 
 // Cascade use cascade or not
@@ -790,6 +616,14 @@ func (engine *Engine) SetExpr(column string, expression string) *Session {
 	return session.SetExpr(column, expression)
 }
 
+// SetJSON provides a update string like "column = {column with value at
+// path}" which updates a part of a JSON column
+func (engine *Engine) SetJSON(column, path string, value interface{}) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.SetJSON(column, path, value)
+}
+
 // Table temporarily change the Get, Find, Update's table
 func (engine *Engine) Table(tableNameOrBean interface{}) *Session {
 	session := engine.NewSession()
@@ -965,6 +799,10 @@ func (engine *Engine) mapType(v reflect.Value, args ...*core.Relation) *core.Tab
 				if tags[0] == "-" {
 					continue
 				}
+				if assoc := parseAssociation(tags[0], t.Field(i).Name); assoc != nil {
+					table.Associations = append(table.Associations, assoc)
+					continue
+				}
 				if strings.ToUpper(tags[0]) == "EXTENDS" {
 					switch fieldValue.Kind() {
 					case reflect.Ptr:
@@ -1066,6 +904,8 @@ func (engine *Engine) mapType(v reflect.Value, args ...*core.Relation) *core.Tab
 						col.IsUpdated = true
 					case k == "DELETED":
 						col.IsDeleted = true
+					case k == "ARRAY":
+						col.IsArray = true
 					case strings.HasPrefix(k, "INDEX(") && strings.HasSuffix(k, ")"):
 						indexName := k[len("INDEX")+1 : len(k)-1]
 						indexNames[indexName] = core.IndexType
@@ -1145,6 +985,28 @@ func (engine *Engine) mapType(v reflect.Value, args ...*core.Relation) *core.Tab
 					}
 					preKey = k
 				}
+				if col.IsArray {
+					// only PostgreSQL has the arrays, the other databases keep
+					// the slices as JSON, whatever the type of the elements
+					arrayType := fieldType
+					if arrayType.Kind() == reflect.Ptr {
+						arrayType = arrayType.Elem()
+					}
+					if engine.dialect.DBType() != core.POSTGRES ||
+						(arrayType.Kind() != reflect.Slice && arrayType.Kind() != reflect.Array) ||
+						arrayType.Elem().Kind() == reflect.Uint8 {
+						col.IsArray = false
+						if !col.SQLType.IsText() && !col.SQLType.IsBlob() {
+							col.SQLType = core.SQLType{}
+							col.Length, col.Length2 = 0, 0
+						}
+					} else if col.SQLType.Name == "" {
+						col.SQLType = core.Type2SQLType(arrayType.Elem())
+						if col.SQLType.Name == core.Varchar {
+							col.SQLType = core.SQLType{Name: core.Text}
+						}
+					}
+				}
 				if col.SQLType.Name == "" {
 					col.SQLType = core.Type2SQLType(fieldType)
 				}
@@ -1154,6 +1016,10 @@ func (engine *Engine) mapType(v reflect.Value, args ...*core.Relation) *core.Tab
 				if col.Length2 == 0 {
 					col.Length2 = col.SQLType.DefaultLength2
 				}
+				if col.IsVersion && col.SQLType.IsTime() && col.Default == "1" {
+					// a time version has no first value but the insert time
+					col.Default = ""
+				}
 
 				if col.Name == "" {
 					col.Name = engine.ColumnMapper.Obj2Table(t.Field(i).Name)
@@ -1563,6 +1429,59 @@ func (engine *Engine) InsertOne(bean interface{}) (int64, error) {
 	return session.InsertOne(bean)
 }
 
+// Upsert insert one record or update it when it conflicts with conflictCols
+func (engine *Engine) Upsert(bean interface{}, conflictCols ...string) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Upsert(bean, conflictCols...)
+}
+
+// OnConflictUpdate only update the given columns when Upsert conflicts
+func (engine *Engine) OnConflictUpdate(columns ...string) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.OnConflictUpdate(columns...)
+}
+
+// OnConflictDoNothing keep the existing record when Upsert conflicts
+func (engine *Engine) OnConflictDoNothing() *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.OnConflictDoNothing()
+}
+
+// Paginate fetch the page of pageSize records following the cursor, the
+// cursors around the page are returned by the session's Page after Find
+func (engine *Engine) Paginate(cursor string, pageSize int) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.Paginate(cursor, pageSize)
+}
+
+// Preload loads the relations of the paths into the records found by Find or
+// Get, see Session.Preload
+func (engine *Engine) Preload(paths ...string) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.Preload(paths...)
+}
+
+// PreloadWith loads the relation of the path with the conditions set by fn,
+// see Session.PreloadWith
+func (engine *Engine) PreloadWith(path string, fn func(*Session) *Session) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.PreloadWith(path, fn)
+}
+
+// CascadeWrite writes the relations of the paths with the records, see
+// Session.CascadeWrite
+func (engine *Engine) CascadeWrite(paths ...string) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.CascadeWrite(paths...)
+}
+
 // Update records, bean's non-empty fields are updated contents,
 // condiBean' non-empty filds are conditions
 // CAUTION:
@@ -1582,6 +1501,21 @@ func (engine *Engine) Delete(bean interface{}) (int64, error) {
 	return session.Delete(bean)
 }
 
+// ForceDelete deletes records for good, even soft deletable ones
+func (engine *Engine) ForceDelete(bean interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.ForceDelete(bean)
+}
+
+// Restore restores the soft deleted records, bean's non-empty fields are
+// conditions
+func (engine *Engine) Restore(bean interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Restore(bean)
+}
+
 // Get retrieve one record from table, bean's non-empty fields
 // are conditions
 func (engine *Engine) Get(bean interface{}) (bool, error) {
@@ -1599,6 +1533,21 @@ func (engine *Engine) Find(beans interface{}, condiBeans ...interface{}) error {
 	return session.Find(beans, condiBeans...)
 }
 
+// Exist returns true if any record matches the conditions
+func (engine *Engine) Exist(bean ...interface{}) (bool, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.Exist(bean...)
+}
+
+// FindAndCount retrieve records like Find and count all the records matching
+// the conditions
+func (engine *Engine) FindAndCount(beans interface{}, condiBeans ...interface{}) (int64, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.FindAndCount(beans, condiBeans...)
+}
+
 // Iterate record by record handle records from table, bean's non-empty fields
 // are conditions.
 func (engine *Engine) Iterate(bean interface{}, fun IterFunc) error {
@@ -1652,43 +1601,10 @@ func (engine *Engine) ImportFile(ddlPath string) ([]sql.Result, error) {
 	return engine.Import(file)
 }
 
-// Import SQL DDL from io.Reader
+// Import SQL DDL from io.Reader, the statements are split by NewSQLScanner
+// and the first failed one stops the import
 func (engine *Engine) Import(r io.Reader) ([]sql.Result, error) {
-	var results []sql.Result
-	var lastError error
-	scanner := bufio.NewScanner(r)
-
-	semiColSpliter := func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-		if atEOF && len(data) == 0 {
-			return 0, nil, nil
-		}
-		if i := bytes.IndexByte(data, ';'); i >= 0 {
-			return i + 1, data[0:i], nil
-		}
-		// If we're at EOF, we have a final, non-terminated line. Return it.
-		if atEOF {
-			return len(data), data, nil
-		}
-		// Request more data.
-		return 0, nil, nil
-	}
-
-	scanner.Split(semiColSpliter)
-
-	for scanner.Scan() {
-		query := strings.Trim(scanner.Text(), " \t\n\r")
-		if len(query) > 0 {
-			engine.logSQL(query)
-			result, err := engine.DB().Exec(query)
-			results = append(results, result)
-			if err != nil {
-				return nil, err
-				//lastError = err
-			}
-		}
-	}
-
-	return results, lastError
+	return engine.ImportWithOptions(r, ImportOptions{})
 }
 
 // TZTime change one time to xorm time location
@@ -1768,3 +1684,10 @@ func (engine *Engine) Unscoped() *Session {
 	session.IsAutoClose = true
 	return session.Unscoped()
 }
+
+// OnlyDeleted selects the soft deleted records only
+func (engine *Engine) OnlyDeleted() *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.OnlyDeleted()
+}
@@ -0,0 +1,340 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+// CopyOptions are the options of CopyToWithOptions
+type CopyOptions struct {
+	// Tables are the patterns, as matched by path.Match, of the tables to
+	// copy; all the tables are copied when it's empty
+	Tables []string
+	// ExcludeTables are the patterns of the tables not to copy
+	ExcludeTables []string
+	// DataOnly only copies the rows into the tables of the target
+	DataOnly bool
+	// BatchSize is the number of rows per INSERT, DefaultDumpBatchSize by
+	// default; it's lowered to the number of parameters the target accepts
+	BatchSize int
+}
+
+// CopyTo copies the tables matching the patterns, or all the tables, with
+// their rows to the dst database, see CopyToWithOptions
+func (engine *Engine) CopyTo(dst *Engine, tables ...string) error {
+	return engine.CopyToWithOptions(dst, CopyOptions{Tables: tables})
+}
+
+// CopyToWithOptions copies the tables selected by the options to the dst
+// database, which may be of another type. The missing tables are created in
+// the dialect of dst, the rows are streamed in batches with their values
+// converted to the column types of dst, the indexes of the created tables are
+// built after the load, then the autoincrement sequences or identities of dst
+// are moved after the copied keys.
+func (engine *Engine) CopyToWithOptions(dst *Engine, opts CopyOptions) error {
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return err
+	}
+	tables, err = filterTables(tables, opts.Tables, opts.ExcludeTables)
+	if err != nil {
+		return err
+	}
+
+	var created []*core.Table
+	if !opts.DataOnly {
+		for _, table := range tables {
+			exist, err := dst.IsTableExist(table.Name)
+			if err != nil {
+				return err
+			}
+			if exist {
+				continue
+			}
+			table = copyTableSchema(table)
+			if _, err = dst.Exec(dst.dialect.CreateTableSql(table, "", table.StoreEngine, "")); err != nil {
+				return err
+			}
+			created = append(created, table)
+		}
+	}
+
+	dstTables, err := dst.DBMetas()
+	if err != nil {
+		return err
+	}
+	dstTablesMap := make(map[string]*core.Table, len(dstTables))
+	for _, table := range dstTables {
+		dstTablesMap[strings.ToLower(table.Name)] = table
+	}
+
+	for _, table := range tables {
+		dstTable, ok := dstTablesMap[strings.ToLower(table.Name)]
+		if !ok {
+			return fmt.Errorf("table %s does not exist in the target database", table.Name)
+		}
+		if err = engine.copyTableData(dst, table, dstTable, opts.BatchSize); err != nil {
+			return fmt.Errorf("copy table %s: %v", table.Name, err)
+		}
+	}
+
+	for _, table := range created {
+		for _, name := range sortedIndexNames(table.Indexes) {
+			if _, err = dst.Exec(dst.dialect.CreateIndexSql(table.Name, table.Indexes[name])); err != nil {
+				return err
+			}
+		}
+	}
+
+	session := dst.NewSession()
+	defer session.Close()
+	for _, table := range tables {
+		if err = session.resetAutoIncr(dstTablesMap[strings.ToLower(table.Name)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyTableSchema returns a copy of the table whose columns can be changed by
+// the target dialect, the defaults of the autoincrement columns, such as the
+// nextval of PostgreSQL, are not kept
+func copyTableSchema(table *core.Table) *core.Table {
+	t := core.NewEmptyTable()
+	t.Name = table.Name
+	t.StoreEngine = table.StoreEngine
+	t.Charset = table.Charset
+	for _, col := range table.Columns() {
+		c := *col
+		if c.IsAutoIncrement {
+			c.Default = ""
+		}
+		t.AddColumn(&c)
+	}
+	for name, index := range table.Indexes {
+		t.Indexes[name] = index
+	}
+	return t
+}
+
+// copyBatchSize returns the number of rows per INSERT the dialect accepts
+func copyBatchSize(dialect core.Dialect, batchSize, columns int) int {
+	if batchSize <= 0 {
+		batchSize = DefaultDumpBatchSize
+	}
+	maxArgs := 65535
+	switch dialect.DBType() {
+	case core.ORACLE:
+		// Oracle inserts many rows with INSERT ALL only
+		return 1
+	case core.MSSQL:
+		maxArgs = 2100 - 1
+		if batchSize > 1000 {
+			batchSize = 1000
+		}
+	case core.SQLITE:
+		maxArgs = 999
+	}
+	if !dialect.SupportInsertMany() {
+		return 1
+	}
+	if batchSize*columns > maxArgs {
+		batchSize = maxArgs / columns
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return batchSize
+}
+
+// copyTableData streams the rows of the table into dstTable, each table is
+// loaded in one transaction of dst
+func (engine *Engine) copyTableData(dst *Engine, table, dstTable *core.Table, batchSize int) error {
+	cols := table.ColumnsSeq()
+	if len(cols) == 0 {
+		return nil
+	}
+	batchSize = copyBatchSize(dst.dialect, batchSize, len(cols))
+
+	srcCols := make([]string, len(cols))
+	dstCols := make([]string, len(cols))
+	columns := make([]*core.Column, len(cols))
+	for i, name := range cols {
+		srcCols[i] = engine.dialect.Quote(name)
+		dstCols[i] = dst.dialect.Quote(name)
+		columns[i] = dstTable.GetColumn(name)
+		if columns[i] == nil {
+			return fmt.Errorf("column %s does not exist in the target table", name)
+		}
+	}
+
+	src := engine.NewSession()
+	defer src.Close()
+	rows, err := engine.DB().QueryContext(src.ctx, "SELECT "+strings.Join(srcCols, ", ")+" FROM "+engine.dialect.Quote(table.Name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	session := dst.NewSession()
+	defer session.Close()
+	if err = session.Begin(); err != nil {
+		return err
+	}
+
+	tableName := dst.dialect.Quote(dstTable.Name)
+	identity := dst.dialect.DBType() == core.MSSQL && dstTable.AutoIncrement != ""
+	if identity {
+		if _, err = session.exec("SET IDENTITY_INSERT " + tableName + " ON"); err != nil {
+			return err
+		}
+	}
+
+	insertStr := "INSERT INTO " + tableName + " (" + strings.Join(dstCols, ", ") + ") VALUES "
+	placeholders := "(" + strings.Repeat("?, ", len(cols)-1) + "?)"
+	insert := func(args []interface{}) error {
+		n := len(args) / len(cols)
+		_, err := session.exec(insertStr+strings.Repeat(placeholders+", ", n-1)+placeholders, args...)
+		return err
+	}
+
+	args := make([]interface{}, 0, batchSize*len(cols))
+	for rows.Next() {
+		dest := make([]interface{}, len(cols))
+		if err = rows.ScanSlice(&dest); err != nil {
+			return err
+		}
+		for i, d := range dest {
+			v, err := src.copyValue(columns[i], d)
+			if err != nil {
+				return fmt.Errorf("column %s: %v", cols[i], err)
+			}
+			args = append(args, v)
+		}
+		if len(args) == batchSize*len(cols) {
+			if err = insert(args); err != nil {
+				return err
+			}
+			args = args[:0]
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		if err = insert(args); err != nil {
+			return err
+		}
+	}
+
+	if identity {
+		if _, err = session.exec("SET IDENTITY_INSERT " + tableName + " OFF"); err != nil {
+			return err
+		}
+	}
+	return session.Commit()
+}
+
+// copyValue converts a value scanned from the source database to the type of
+// the target column
+func (session *Session) copyValue(col *core.Column, v interface{}) (interface{}, error) {
+	if b, ok := v.([]byte); ok {
+		if col.SQLType.IsBlob() {
+			return b, nil
+		}
+		v = string(b)
+	}
+
+	switch x := v.(type) {
+	case string:
+		switch {
+		case col.SQLType.Name == core.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(x))
+			if err != nil {
+				return nil, err
+			}
+			return b, nil
+		case col.SQLType.IsTime() && col.SQLType.Name != core.Time:
+			t, err := session.str2Time(col, x)
+			if err != nil {
+				return nil, err
+			}
+			return t, nil
+		}
+	case int64:
+		if col.SQLType.Name == core.Bool {
+			return x != 0, nil
+		}
+	case bool:
+		if col.SQLType.Name != core.Bool && col.SQLType.IsNumeric() {
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+	case time.Time:
+		if col.SQLType.IsText() {
+			return x.Format("2006-01-02 15:04:05.999999999"), nil
+		}
+	}
+	return v, nil
+}
+
+// resetAutoIncr moves the sequence or identity of the autoincrement column of
+// the table after its greatest value; MySQL and SQLite do it on insert
+func (session *Session) resetAutoIncr(table *core.Table) error {
+	if table == nil || table.AutoIncrement == "" {
+		return nil
+	}
+	dialect := session.Engine.dialect
+	switch dialect.DBType() {
+	case core.POSTGRES, core.MSSQL, core.ORACLE:
+	default:
+		return nil
+	}
+
+	// a replica may lag behind the rows just copied
+	forcePrimary := session.forcePrimary
+	session.forcePrimary = true
+	var max sql.NullInt64
+	err := session.queryRowScan("SELECT MAX("+dialect.Quote(table.AutoIncrement)+") FROM "+dialect.Quote(table.Name), nil, func(row *core.Row) error {
+		return row.Scan(&max)
+	})
+	session.forcePrimary = forcePrimary
+	if err != nil {
+		return err
+	}
+
+	switch dialect.DBType() {
+	case core.POSTGRES:
+		// the sequence of an empty table restarts at 1
+		next, called := int64(1), false
+		if max.Valid {
+			next, called = max.Int64, true
+		}
+		_, err = session.exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', '%s'), %d, %v)",
+			dialect.Quote(table.Name), table.AutoIncrement, next, called))
+	case core.MSSQL:
+		if max.Valid {
+			_, err = session.exec(fmt.Sprintf("DBCC CHECKIDENT ('%s', RESEED, %d)", table.Name, max.Int64))
+		}
+	case core.ORACLE:
+		// the sequence named after the table, as used by Insert, which may
+		// not exist yet (ORA-02289)
+		seq := "seq_" + table.Name
+		if _, err = session.exec("DROP SEQUENCE " + seq); err != nil && !strings.Contains(err.Error(), "ORA-02289") {
+			return err
+		}
+		_, err = session.exec(fmt.Sprintf("CREATE SEQUENCE %s START WITH %d", seq, max.Int64+1))
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+func TestCopyValue(t *testing.T) {
+	engine := newFakeEngine(t, core.POSTGRES)
+	defer engine.Close()
+	engine.DatabaseTZ = time.UTC
+	engine.TZLocation = time.UTC
+	session := engine.NewSession()
+	defer session.Close()
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var cases = []struct {
+		sqlType string
+		v       interface{}
+		value   interface{}
+	}{
+		{core.Bool, []byte("true"), true},
+		{core.Bool, " 0", false},
+		{core.Bool, int64(1), true},
+		{core.Int, true, int64(1)},
+		{core.Int, false, int64(0)},
+		{core.Int, int64(5), int64(5)},
+		{core.Varchar, []byte("a"), "a"},
+		{core.Varchar, created, "2020-01-02 03:04:05"},
+		{core.Blob, []byte{0, 1}, []byte{0, 1}},
+		{core.DateTime, "2020-01-02 03:04:05", created},
+		{core.Time, "03:04:05", "03:04:05"},
+		{core.Varchar, nil, nil},
+	}
+
+	for _, c := range cases {
+		value, err := session.copyValue(testColumn("a", c.sqlType, 0), c.v)
+		if err != nil {
+			t.Fatal(c.sqlType, err)
+		}
+		if tm, ok := value.(time.Time); ok {
+			value = tm.UTC()
+		}
+		if !reflect.DeepEqual(value, c.value) {
+			t.Errorf("%s %#v: want %#v, get %#v", c.sqlType, c.v, c.value, value)
+		}
+	}
+
+	if _, err := session.copyValue(testColumn("a", core.Bool, 0), "maybe"); err == nil {
+		t.Error("want an error")
+	}
+}
+
+func TestCopyBatchSize(t *testing.T) {
+	var cases = []struct {
+		dbType    core.DbType
+		batchSize int
+		columns   int
+		size      int
+	}{
+		{core.MYSQL, 0, 10, DefaultDumpBatchSize},
+		{core.MYSQL, 10000, 10, 6553},
+		{core.SQLITE, 500, 10, 99},
+		{core.MSSQL, 5000, 1, 1000},
+		{core.MSSQL, 500, 10, 209},
+		{core.POSTGRES, 1, 100000, 1},
+		{core.ORACLE, 100, 2, 1},
+	}
+	for _, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		if size := copyBatchSize(engine.dialect, c.batchSize, c.columns); size != c.size {
+			t.Errorf("%s %d %d: want %d, get %d", c.dbType, c.batchSize, c.columns, c.size, size)
+		}
+		engine.Close()
+	}
+}
+
+func TestResetAutoIncr(t *testing.T) {
+	id := testColumn("id", core.BigInt, 0)
+	id.IsPrimaryKey = true
+	id.IsAutoIncrement = true
+	table := testTable("user", id)
+	table.AutoIncrement = "id"
+
+	max := `SELECT MAX("id") FROM "user"`
+	var cases = []struct {
+		dbType core.DbType
+		max    driver.Value
+		errs   []error
+		sqls   []string
+		err    bool
+	}{
+		{core.POSTGRES, int64(7), nil, []string{max, `SELECT setval(pg_get_serial_sequence('"user"', 'id'), 7, true)`}, false},
+		// the sequence of an empty table restarts at 1
+		{core.POSTGRES, nil, nil, []string{max, `SELECT setval(pg_get_serial_sequence('"user"', 'id'), 1, false)`}, false},
+		{core.MSSQL, int64(7), nil, []string{max, "DBCC CHECKIDENT ('user', RESEED, 7)"}, false},
+		{core.MSSQL, nil, nil, []string{max}, false},
+		{core.ORACLE, int64(7), nil, []string{max, "DROP SEQUENCE seq_user", "CREATE SEQUENCE seq_user START WITH 8"}, false},
+		// the sequence is created when it does not exist
+		{core.ORACLE, int64(7), []error{nil, errors.New("ORA-02289: sequence does not exist")},
+			[]string{max, "DROP SEQUENCE seq_user", "CREATE SEQUENCE seq_user START WITH 8"}, false},
+		{core.ORACLE, int64(7), []error{nil, errors.New("ORA-01031: insufficient privileges")},
+			[]string{max, "DROP SEQUENCE seq_user"}, true},
+		// MySQL moves its counter on insert
+		{core.MYSQL, int64(7), nil, nil, false},
+	}
+
+	for _, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		dsn := string(c.dbType) + "/" + t.Name()
+		takeFakeStmts(dsn)
+
+		// the session is reused for the next table
+		session := engine.NewSession()
+		for i := 0; i < 2; i++ {
+			if c.sqls != nil {
+				queueFakeResults(dsn, [][]driver.Value{{"max"}, {c.max}})
+			}
+			queueFakeErrors(dsn, c.errs...)
+			if err := session.resetAutoIncr(table); (err != nil) != c.err {
+				t.Fatal(c.dbType, c.max, err)
+			}
+			if stmts := takeFakeStmts(dsn); !(len(stmts) == 0 && len(c.sqls) == 0) && !reflect.DeepEqual(stmts, c.sqls) {
+				t.Errorf("%s %v: want\n%v\nget\n%v", c.dbType, c.max, c.sqls, stmts)
+			}
+		}
+		session.Close()
+		engine.Close()
+	}
+}
@@ -0,0 +1,318 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+// DefaultDumpBatchSize is the number of rows per INSERT of a dump when
+// DumpOptions.BatchSize is 0
+const DefaultDumpBatchSize = 100
+
+// DumpOptions are the options of DumpWithOptions
+type DumpOptions struct {
+	// DBType is the database type of the dumped SQL, the engine's by default
+	DBType core.DbType
+	// Tables are the patterns, as matched by path.Match, of the tables to
+	// dump; all the tables are dumped when it's empty
+	Tables []string
+	// ExcludeTables are the patterns of the tables not to dump
+	ExcludeTables []string
+	// SchemaOnly only dumps the tables and indexes creation
+	SchemaOnly bool
+	// DataOnly only dumps the rows
+	DataOnly bool
+	// BatchSize is the number of rows per INSERT when the dialect supports
+	// inserting many rows at once
+	BatchSize int
+	// Gzip compresses the output
+	Gzip bool
+}
+
+// DumpWithOptions dumps the database tables selected by the options to w,
+// the rows are streamed table by table
+func (engine *Engine) DumpWithOptions(w io.Writer, opts DumpOptions) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.DumpWithOptions(w, opts)
+}
+
+// DumpWithOptionsToFile dumps the database tables selected by the options to
+// a file
+func (engine *Engine) DumpWithOptionsToFile(fp string, opts DumpOptions) error {
+	f, err := os.Create(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return engine.DumpWithOptions(f, opts)
+}
+
+// DumpTablesWithOptions dumps the tables selected by the options to w
+func (engine *Engine) DumpTablesWithOptions(tables []*core.Table, w io.Writer, opts DumpOptions) error {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.DumpTablesWithOptions(tables, w, opts)
+}
+
+// DumpWithOptions dumps the database tables selected by the options to w
+// with the session's context, the rows are read like the ones of Find, from a
+// replica when the engine belongs to an EngineGroup
+func (session *Session) DumpWithOptions(w io.Writer, opts DumpOptions) error {
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+	tables, err := session.Engine.dbMetas(session.ctx)
+	if err != nil {
+		return err
+	}
+	return session.dumpTables(tables, w, opts)
+}
+
+// DumpTablesWithOptions dumps the tables selected by the options to w with
+// the session's context
+func (session *Session) DumpTablesWithOptions(tables []*core.Table, w io.Writer, opts DumpOptions) error {
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+	return session.dumpTables(tables, w, opts)
+}
+
+func (session *Session) dumpTables(tables []*core.Table, w io.Writer, opts DumpOptions) (err error) {
+	engine := session.Engine
+	if opts.SchemaOnly && opts.DataOnly {
+		return errors.New("SchemaOnly and DataOnly cannot be both set")
+	}
+
+	dialect := engine.dialect
+	if opts.DBType != "" && opts.DBType != engine.dialect.DBType() {
+		dialect = core.QueryDialect(opts.DBType)
+		if dialect == nil {
+			return errors.New("Unsupported database type.")
+		}
+		uri := *engine.dialect.URI()
+		uri.DbType = opts.DBType
+		dialect.Init(nil, &uri, "", "")
+	}
+
+	tables, err = filterTables(tables, opts.Tables, opts.ExcludeTables)
+	if err != nil {
+		return err
+	}
+
+	var gz *gzip.Writer
+	if opts.Gzip {
+		gz = gzip.NewWriter(w)
+		w = gz
+	}
+	bw := bufio.NewWriter(w)
+	// a dump failing partway is written up to the failure, as a valid gzip
+	// stream when compressed
+	defer func() {
+		if flushErr := bw.Flush(); err == nil {
+			err = flushErr
+		}
+		if gz != nil {
+			if closeErr := gz.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}()
+
+	_, err = fmt.Fprintf(bw, "/*Generated by xorm v%s %s, from %s to %s*/\n\n",
+		Version, time.Now().In(engine.TZLocation).Format("2006-01-02 15:04:05"), engine.dialect.DBType(), dialect.DBType())
+	if err != nil {
+		return err
+	}
+
+	for i, table := range tables {
+		if i > 0 {
+			if _, err = io.WriteString(bw, "\n"); err != nil {
+				return err
+			}
+		}
+		if !opts.DataOnly {
+			if _, err = io.WriteString(bw, dialect.CreateTableSql(table, "", table.StoreEngine, "")+";\n"); err != nil {
+				return err
+			}
+			for _, name := range sortedIndexNames(table.Indexes) {
+				if _, err = io.WriteString(bw, dialect.CreateIndexSql(table.Name, table.Indexes[name])+";\n"); err != nil {
+					return err
+				}
+			}
+		}
+		if !opts.SchemaOnly {
+			if err = session.dumpTableData(bw, dialect, table, opts.BatchSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// filterTables returns the tables matching the include patterns, or all the
+// tables when there is none, and none of the exclude patterns
+func filterTables(tables []*core.Table, includes, excludes []string) ([]*core.Table, error) {
+	if len(includes) == 0 && len(excludes) == 0 {
+		return tables, nil
+	}
+	var filtered []*core.Table
+	for _, table := range tables {
+		included := len(includes) == 0
+		for _, pattern := range includes {
+			matched, err := path.Match(pattern, table.Name)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				included = true
+				break
+			}
+		}
+		for _, pattern := range excludes {
+			if !included {
+				break
+			}
+			matched, err := path.Match(pattern, table.Name)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				included = false
+			}
+		}
+		if included {
+			filtered = append(filtered, table)
+		}
+	}
+	return filtered, nil
+}
+
+// dumpTableData writes the rows of the table as INSERT statements of
+// batchSize rows when the dialect supports it
+func (session *Session) dumpTableData(w io.Writer, dialect core.Dialect, table *core.Table, batchSize int) error {
+	if batchSize <= 0 {
+		batchSize = DefaultDumpBatchSize
+	}
+	switch {
+	case !dialect.SupportInsertMany(), dialect.DBType() == core.ORACLE:
+		// Oracle inserts many rows with INSERT ALL only
+		batchSize = 1
+	case dialect.DBType() == core.MSSQL && batchSize > 1000:
+		// the maximum number of rows of a VALUES list
+		batchSize = 1000
+	}
+
+	rows, err := session.dbQuery(session.queryDB(), "SELECT * FROM "+session.Engine.Quote(table.Name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	columns := make([]*core.Column, len(cols))
+	for i, name := range cols {
+		columns[i] = table.GetColumn(name)
+	}
+	insertStr := "INSERT INTO " + dialect.Quote(table.Name) + " (" +
+		dialect.Quote(strings.Join(cols, dialect.Quote(", "))) + ") VALUES "
+
+	var n int
+	values := make([]string, len(cols))
+	for rows.Next() {
+		dest := make([]interface{}, len(cols))
+		if err = rows.ScanSlice(&dest); err != nil {
+			return err
+		}
+		for i, d := range dest {
+			values[i] = formatDumpValue(dialect, columns[i], d)
+		}
+
+		var s string
+		if n == 0 {
+			s = insertStr
+		} else {
+			s = ",\n"
+		}
+		s += "(" + strings.Join(values, ", ") + ")"
+		n++
+		if n == batchSize {
+			s += ";\n"
+			n = 0
+		}
+		if _, err = io.WriteString(w, s); err != nil {
+			return err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if n > 0 {
+		_, err = io.WriteString(w, ";\n")
+	}
+	return err
+}
+
+// formatDumpValue returns the SQL literal of a scanned value for the dialect
+func formatDumpValue(dialect core.Dialect, col *core.Column, d interface{}) string {
+	switch v := d.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if col != nil && col.SQLType.IsBlob() {
+			return dialect.FormatBytes(v)
+		}
+		return formatDumpString(dialect, col, string(v))
+	case string:
+		return formatDumpString(dialect, col, v)
+	case time.Time:
+		return quoteDumpString(dialect, v.Format("2006-01-02 15:04:05.999999999"))
+	case bool:
+		if dialect.DBType() == core.POSTGRES {
+			return strconv.FormatBool(v)
+		}
+		if v {
+			return "1"
+		}
+		return "0"
+	}
+	return fmt.Sprintf("%v", d)
+}
+
+func formatDumpString(dialect core.Dialect, col *core.Column, s string) string {
+	if col != nil && col.SQLType.IsNumeric() {
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s
+		}
+	}
+	return quoteDumpString(dialect, s)
+}
+
+// quoteDumpString quotes a string literal, MySQL also treats backslashes as
+// escape characters
+func quoteDumpString(dialect core.Dialect, s string) string {
+	if dialect.DBType() == core.MYSQL {
+		s = strings.Replace(s, `\`, `\\`, -1)
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,179 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+func TestFormatDumpValue(t *testing.T) {
+	text := testColumn("a", core.Varchar, 20)
+	number := testColumn("a", core.Int, 0)
+	blob := testColumn("a", core.Blob, 0)
+	created := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	var cases = []struct {
+		dbType core.DbType
+		col    *core.Column
+		v      interface{}
+		s      string
+	}{
+		{core.MYSQL, text, nil, "NULL"},
+		{core.MYSQL, text, "it's", "'it''s'"},
+		{core.MYSQL, text, []byte(`a\b`), `'a\\b'`},
+		{core.POSTGRES, text, []byte(`a\b`), `'a\b'`},
+		{core.SQLITE, text, `a\b'`, `'a\b'''`},
+		{core.MSSQL, text, "it's", "'it''s'"},
+		{core.MYSQL, number, []byte("12"), "12"},
+		{core.MYSQL, number, []byte("1e3"), "1e3"},
+		{core.MYSQL, number, []byte("x"), "'x'"},
+		{core.MYSQL, nil, []byte("12"), "'12'"},
+		{core.MYSQL, number, int64(-5), "-5"},
+		{core.MYSQL, number, 1.5, "1.5"},
+		{core.MYSQL, blob, []byte{0, 255}, "0x00ff"},
+		{core.POSTGRES, blob, []byte{0, 255}, `'\x00ff'`},
+		{core.SQLITE, blob, []byte{0, 255}, "X'00ff'"},
+		{core.MYSQL, text, created, "'2020-01-02 03:04:05.0000006'"},
+		{core.POSTGRES, number, true, "true"},
+		{core.MYSQL, number, true, "1"},
+		{core.MSSQL, number, false, "0"},
+	}
+
+	engines := make(map[core.DbType]*Engine)
+	for _, c := range cases {
+		engine, ok := engines[c.dbType]
+		if !ok {
+			engine = newFakeEngine(t, c.dbType)
+			defer engine.Close()
+			engines[c.dbType] = engine
+		}
+		if s := formatDumpValue(engine.dialect, c.col, c.v); s != c.s {
+			t.Errorf("%s %#v: want %s, get %s", c.dbType, c.v, c.s, s)
+		}
+	}
+}
+
+func TestFilterTables(t *testing.T) {
+	tables := []*core.Table{testTable("user"), testTable("user_log"), testTable("post"), testTable("tmp_post")}
+	names := func(tables []*core.Table) (s []string) {
+		for _, table := range tables {
+			s = append(s, table.Name)
+		}
+		return
+	}
+
+	var cases = []struct {
+		includes, excludes []string
+		names              []string
+	}{
+		{nil, nil, []string{"user", "user_log", "post", "tmp_post"}},
+		{[]string{"user*"}, nil, []string{"user", "user_log"}},
+		{[]string{"user", "*post"}, nil, []string{"user", "post", "tmp_post"}},
+		{nil, []string{"tmp_*", "*_log"}, []string{"user", "post"}},
+		{[]string{"*post"}, []string{"tmp_*"}, []string{"post"}},
+		{[]string{"none"}, nil, nil},
+	}
+	for _, c := range cases {
+		filtered, err := filterTables(tables, c.includes, c.excludes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := names(filtered); !reflect.DeepEqual(got, c.names) {
+			t.Errorf("%v %v: want %v, get %v", c.includes, c.excludes, c.names, got)
+		}
+	}
+
+	if _, err := filterTables(tables, []string{"["}, nil); err == nil {
+		t.Error("want an error for a bad pattern")
+	}
+}
+
+func TestDumpTablesWithOptions(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	id := testColumn("id", core.BigInt, 0)
+	id.IsPrimaryKey = true
+	id.Nullable = false
+	table := testTable("user", id, testColumn("name", core.Varchar, 20))
+	rows := [][]driver.Value{{"id", "name"}, {int64(1), "a"}, {int64(2), nil}, {int64(3), "it's"}}
+
+	var cases = []struct {
+		opts DumpOptions
+		data bool
+		sql  string
+	}{
+		{DumpOptions{BatchSize: 2, Gzip: true}, true,
+			"CREATE TABLE IF NOT EXISTS `user` (`id` BIGINT(20) PRIMARY KEY NOT NULL, `name` VARCHAR(20) NULL);\n" +
+				"INSERT INTO `user` (`id`, `name`) VALUES (1, 'a'),\n(2, NULL);\n" +
+				"INSERT INTO `user` (`id`, `name`) VALUES (3, 'it''s');\n"},
+		{DumpOptions{DataOnly: true, DBType: core.POSTGRES}, true,
+			`INSERT INTO "user" ("id", "name") VALUES (1, 'a'),` + "\n" + `(2, NULL),` + "\n" + `(3, 'it''s');` + "\n"},
+		{DumpOptions{SchemaOnly: true, Tables: []string{"user"}}, false,
+			"CREATE TABLE IF NOT EXISTS `user` (`id` BIGINT(20) PRIMARY KEY NOT NULL, `name` VARCHAR(20) NULL);\n"},
+		{DumpOptions{ExcludeTables: []string{"u*"}}, false, ""},
+	}
+
+	for i, c := range cases {
+		if c.data {
+			queueFakeResults(dsn, rows)
+		}
+		var buf bytes.Buffer
+		if err := engine.DumpTablesWithOptions([]*core.Table{table}, &buf, c.opts); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.Bytes()
+		if c.opts.Gzip {
+			r, err := gzip.NewReader(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out, err = ioutil.ReadAll(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// the header holds the time of the dump
+		parts := strings.SplitN(string(out), "\n\n", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "/*Generated by xorm") || parts[1] != c.sql {
+			t.Errorf("%d: want\n%s\nget\n%s", i, c.sql, out)
+		}
+		if stmts := takeFakeStmts(dsn); c.data != (len(stmts) == 1) {
+			t.Errorf("%d: get the queries %v", i, stmts)
+		}
+	}
+
+	if err := engine.DumpTablesWithOptions(nil, ioutil.Discard, DumpOptions{SchemaOnly: true, DataOnly: true}); err == nil {
+		t.Error("want an error")
+	}
+
+	// a dump failing partway is still a valid gzip stream
+	queueFakeErrors(dsn, errors.New("connection lost"))
+	var buf bytes.Buffer
+	if err := engine.DumpTablesWithOptions([]*core.Table{table}, &buf, DumpOptions{Gzip: true}); err == nil || err.Error() != "connection lost" {
+		t.Fatalf("want the query error, get %v", err)
+	}
+	r, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "CREATE TABLE IF NOT EXISTS `user`"; !strings.Contains(string(out), want) {
+		t.Errorf("want %s, get\n%s", want, out)
+	}
+}
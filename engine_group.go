@@ -0,0 +1,208 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/coscms/xorm/core"
+)
+
+// EngineGroup holds a primary engine and its replicas. All sessions are created
+// on the primary, then Find, Get, Count, Exist, Iterate, Rows and the raw
+// SELECT queries are routed to a replica chosen by the policy, while writes,
+// locking reads, the other raw queries and transactions stay on the primary.
+type EngineGroup struct {
+	*Engine
+	replicas []*Engine
+	policy   GroupPolicy
+}
+
+// NewEngineGroup opens a primary engine with the first data source and replicas
+// with the others
+func NewEngineGroup(driverName string, dataSourceNames []string, policies ...GroupPolicy) (*EngineGroup, error) {
+	if len(dataSourceNames) == 0 {
+		return nil, errors.New("At least one data source is required")
+	}
+	engines := make([]*Engine, 0, len(dataSourceNames))
+	for _, dataSourceName := range dataSourceNames {
+		engine, err := NewEngine(driverName, dataSourceName)
+		if err != nil {
+			for _, e := range engines {
+				e.Close()
+			}
+			return nil, err
+		}
+		engines = append(engines, engine)
+	}
+	return NewGroup(engines[0], engines[1:], policies...)
+}
+
+// NewGroup groups existing engines, the replicas will share the primary's
+// table mapping cache and cacher
+func NewGroup(primary *Engine, replicas []*Engine, policies ...GroupPolicy) (*EngineGroup, error) {
+	if primary == nil {
+		return nil, errors.New("Primary engine is required")
+	}
+	group := &EngineGroup{
+		Engine:   primary,
+		replicas: replicas,
+	}
+	if len(policies) > 0 && policies[0] != nil {
+		group.policy = policies[0]
+	} else {
+		group.policy = RoundRobinPolicy()
+	}
+	for _, replica := range replicas {
+		replica.Tables = primary.Tables
+		replica.mutex = primary.mutex
+		replica.Cacher = primary.Cacher
+	}
+	primary.group = group
+	return group, nil
+}
+
+// Primary returns the primary engine
+func (eg *EngineGroup) Primary() *Engine {
+	return eg.Engine
+}
+
+// Replicas returns the replica engines
+func (eg *EngineGroup) Replicas() []*Engine {
+	return eg.replicas
+}
+
+// Replica returns a replica chosen by the policy, or the primary when
+// there's no replica
+func (eg *EngineGroup) Replica() *Engine {
+	switch len(eg.replicas) {
+	case 0:
+		return eg.Engine
+	case 1:
+		return eg.replicas[0]
+	}
+	if replica := eg.policy.Replica(eg); replica != nil {
+		return replica
+	}
+	return eg.Engine
+}
+
+// SetPolicy sets the load balancing policy of the replicas
+func (eg *EngineGroup) SetPolicy(policy GroupPolicy) *EngineGroup {
+	eg.policy = policy
+	return eg
+}
+
+// SetDefaultCacher sets the default cacher on all members
+func (eg *EngineGroup) SetDefaultCacher(cacher core.Cacher) {
+	eg.Engine.SetDefaultCacher(cacher)
+	for _, replica := range eg.replicas {
+		replica.SetDefaultCacher(cacher)
+	}
+}
+
+// SetLogger sets the logger on all members
+func (eg *EngineGroup) SetLogger(logger core.ILogger) {
+	eg.Engine.SetLogger(logger)
+	for _, replica := range eg.replicas {
+		replica.SetLogger(logger)
+	}
+}
+
+// ShowSQL shows SQL statements on all members
+func (eg *EngineGroup) ShowSQL(show ...bool) {
+	eg.Engine.ShowSQL(show...)
+	for _, replica := range eg.replicas {
+		replica.ShowSQL(show...)
+	}
+}
+
+// SetMaxOpenConns sets the max open connections on all members
+func (eg *EngineGroup) SetMaxOpenConns(conns int) {
+	eg.Engine.SetMaxOpenConns(conns)
+	for _, replica := range eg.replicas {
+		replica.SetMaxOpenConns(conns)
+	}
+}
+
+// SetMaxIdleConns sets the max idle connections on all members
+func (eg *EngineGroup) SetMaxIdleConns(conns int) {
+	eg.Engine.SetMaxIdleConns(conns)
+	for _, replica := range eg.replicas {
+		replica.SetMaxIdleConns(conns)
+	}
+}
+
+// Ping tests if all members are alive
+func (eg *EngineGroup) Ping() error {
+	if err := eg.Engine.Ping(); err != nil {
+		return err
+	}
+	for _, replica := range eg.replicas {
+		if err := replica.Ping(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all members
+func (eg *EngineGroup) Close() error {
+	err := eg.Engine.Close()
+	for _, replica := range eg.replicas {
+		if e := replica.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	eg.Engine.group = nil
+	return err
+}
+
+// ForcePrimary creates a session whose reads are sent to the primary
+func (engine *Engine) ForcePrimary() *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.ForcePrimary()
+}
+
+// ForcePrimary sends the session's reads to the primary when the engine
+// belongs to an EngineGroup, e.g. to read your own writes
+func (session *Session) ForcePrimary() *Session {
+	session.forcePrimary = true
+	return session
+}
+
+// queryDB returns the database the reads of Find, Get, Count, Exist, Iterate
+// and Rows are sent to, the locking reads of ForUpdate stay on the primary
+func (session *Session) queryDB() *core.DB {
+	db := session.DB()
+	group := session.Engine.group
+	if group == nil || session.forcePrimary || !session.IsAutoCommit || session.Statement.IsForUpdate {
+		return db
+	}
+	return group.Replica().db
+}
+
+// rawQueryDB returns the database the raw query sqlStr is sent to: a SELECT
+// is a read, the other statements, e.g. INSERT ... RETURNING, and the locking
+// reads, e.g. SELECT ... FOR UPDATE, go to the primary
+func (session *Session) rawQueryDB(sqlStr string) *core.DB {
+	if isSelectSQL(sqlStr) {
+		return session.queryDB()
+	}
+	return session.DB()
+}
+
+// lockingReadRegexp matches the row locking clauses of MySQL, PostgreSQL and
+// Oracle, e.g. FOR UPDATE, FOR NO KEY UPDATE, FOR SHARE and LOCK IN SHARE MODE
+var lockingReadRegexp = regexp.MustCompile(`(?i)\bFOR\s+((NO\s+KEY\s+)?UPDATE|(KEY\s+)?SHARE)\b|\bLOCK\s+IN\s+SHARE\s+MODE\b`)
+
+func isSelectSQL(sqlStr string) bool {
+	sqlStr = strings.TrimLeft(sqlStr, " \t\r\n(")
+	return len(sqlStr) >= 6 && strings.EqualFold(sqlStr[:6], "SELECT") &&
+		!lockingReadRegexp.MatchString(sqlStr)
+}
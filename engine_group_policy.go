@@ -0,0 +1,133 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// GroupPolicy chooses the replica a read should be sent to
+type GroupPolicy interface {
+	Replica(*EngineGroup) *Engine
+}
+
+// GroupPolicyHandler is a function which implements GroupPolicy
+type GroupPolicyHandler func(*EngineGroup) *Engine
+
+// Replica implements GroupPolicy
+func (h GroupPolicyHandler) Replica(eg *EngineGroup) *Engine {
+	return h(eg)
+}
+
+// RandomPolicy chooses a replica randomly
+func RandomPolicy() GroupPolicyHandler {
+	var r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	var mutex sync.Mutex
+	return func(g *EngineGroup) *Engine {
+		replicas := g.Replicas()
+		mutex.Lock()
+		idx := r.Intn(len(replicas))
+		mutex.Unlock()
+		return replicas[idx]
+	}
+}
+
+// WeightRandomPolicy chooses a replica randomly with the given weights,
+// the weights are matched to the replicas by index
+func WeightRandomPolicy(weights []int) GroupPolicyHandler {
+	var rands = make([]int, 0, len(weights))
+	for i := 0; i < len(weights); i++ {
+		for n := 0; n < weights[i]; n++ {
+			rands = append(rands, i)
+		}
+	}
+	var r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	var mutex sync.Mutex
+
+	return func(g *EngineGroup) *Engine {
+		replicas := g.Replicas()
+		if len(rands) == 0 {
+			return replicas[0]
+		}
+		mutex.Lock()
+		idx := rands[r.Intn(len(rands))]
+		mutex.Unlock()
+		if idx >= len(replicas) {
+			idx = len(replicas) - 1
+		}
+		return replicas[idx]
+	}
+}
+
+// RoundRobinPolicy chooses the replicas in turn
+func RoundRobinPolicy() GroupPolicyHandler {
+	var pos = -1
+	var mutex sync.Mutex
+	return func(g *EngineGroup) *Engine {
+		replicas := g.Replicas()
+
+		mutex.Lock()
+		pos++
+		if pos >= len(replicas) {
+			pos = 0
+		}
+		idx := pos
+		mutex.Unlock()
+
+		return replicas[idx]
+	}
+}
+
+// WeightRoundRobinPolicy chooses the replicas in turn with the given weights,
+// the weights are matched to the replicas by index
+func WeightRoundRobinPolicy(weights []int) GroupPolicyHandler {
+	var rands = make([]int, 0, len(weights))
+	for i := 0; i < len(weights); i++ {
+		for n := 0; n < weights[i]; n++ {
+			rands = append(rands, i)
+		}
+	}
+	var pos = -1
+	var mutex sync.Mutex
+
+	return func(g *EngineGroup) *Engine {
+		replicas := g.Replicas()
+		if len(rands) == 0 {
+			return replicas[0]
+		}
+
+		mutex.Lock()
+		pos++
+		if pos >= len(rands) {
+			pos = 0
+		}
+		idx := rands[pos]
+		mutex.Unlock()
+
+		if idx >= len(replicas) {
+			idx = len(replicas) - 1
+		}
+		return replicas[idx]
+	}
+}
+
+// LeastConnPolicy chooses the replica with the least open connections
+func LeastConnPolicy() GroupPolicyHandler {
+	return func(g *EngineGroup) *Engine {
+		replicas := g.Replicas()
+		connections := 0
+		idx := 0
+		for i, replica := range replicas {
+			openConnections := replica.DB().Stats().OpenConnections
+			if i == 0 || openConnections < connections {
+				connections = openConnections
+				idx = i
+			}
+		}
+		return replicas[idx]
+	}
+}
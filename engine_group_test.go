@@ -0,0 +1,116 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+type GroupUser struct {
+	Id   int64
+	Name string
+}
+
+func TestEngineGroupRouting(t *testing.T) {
+	for _, dbType := range []string{"postgres", "oracle"} {
+		primary, replica := dbType+"/primary", dbType+"/replica"
+		group, err := NewEngineGroup("xorm-fake", []string{primary, replica})
+		if err != nil {
+			t.Fatal(err)
+		}
+		group.SetDefaultCacher(NewLRUCacher(NewMemoryStore(), 100))
+
+		var cases = []struct {
+			name    string
+			run     func() error
+			primary bool
+		}{
+			{"insert", func() error {
+				_, err := group.Insert(&GroupUser{Name: "a"})
+				return err
+			}, true},
+			{"upsert", func() error {
+				if dbType != "postgres" {
+					return nil
+				}
+				_, err := group.Upsert(&GroupUser{Name: "a"}, "name")
+				return err
+			}, true},
+			{"delete", func() error {
+				_, err := group.Delete(&GroupUser{Name: "a"})
+				return err
+			}, true},
+			{"raw insert", func() error {
+				_, err := group.Query("INSERT INTO group_user (name) VALUES (?) RETURNING id", "a")
+				return err
+			}, true},
+			{"force primary", func() error {
+				return group.ForcePrimary().Find(&[]GroupUser{})
+			}, true},
+			{"transaction", func() error {
+				return group.Transaction(func(session *Session) error {
+					return session.Find(&[]GroupUser{})
+				})
+			}, true},
+			{"find for update", func() error {
+				return group.NoCache().ForUpdate().Find(&[]GroupUser{})
+			}, true},
+			{"get for update", func() error {
+				_, err := group.NoCache().ForUpdate().Get(&GroupUser{Id: 1})
+				return err
+			}, true},
+			{"raw select for update", func() error {
+				_, err := group.Query("SELECT id FROM group_user WHERE id = ? FOR UPDATE", 1)
+				return err
+			}, true},
+			{"raw select for share", func() error {
+				_, err := group.Query("SELECT id FROM group_user WHERE id = ?\nFOR SHARE", 1)
+				return err
+			}, true},
+			{"find", func() error {
+				return group.NoCache().Find(&[]GroupUser{})
+			}, false},
+			{"get", func() error {
+				_, err := group.NoCache().Get(&GroupUser{Id: 1})
+				return err
+			}, false},
+			{"count", func() error {
+				_, err := group.Count(new(GroupUser))
+				return err
+			}, false},
+			{"exist", func() error {
+				_, err := group.Exist(&GroupUser{Name: "a"})
+				return err
+			}, false},
+			{"raw select", func() error {
+				_, err := group.Query("SELECT id FROM group_user")
+				return err
+			}, false},
+			{"dump", func() error {
+				tables := []*core.Table{group.TableInfo(new(GroupUser)).Table}
+				return group.DumpTablesWithOptions(tables, ioutil.Discard, DumpOptions{DataOnly: true})
+			}, false},
+		}
+
+		for _, c := range cases {
+			takeFakeStmts(primary)
+			takeFakeStmts(replica)
+			if err = c.run(); err != nil {
+				t.Fatal(dbType, c.name, err)
+			}
+			onPrimary, onReplica := takeFakeStmts(primary), takeFakeStmts(replica)
+			if c.primary && len(onReplica) > 0 {
+				t.Error(dbType, c.name, "sent to the replica:", onReplica)
+			}
+			if !c.primary && (len(onReplica) == 0 || len(onPrimary) > 0) {
+				t.Error(dbType, c.name, "sent to the primary:", onPrimary)
+			}
+		}
+		group.Close()
+	}
+}
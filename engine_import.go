@@ -0,0 +1,144 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ImportOptions are the options of ImportWithOptions
+type ImportOptions struct {
+	// UseTransaction runs the whole script in one transaction, which is
+	// committed only when all the statements succeed
+	UseTransaction bool
+	// ContinueOnError runs the statements following a failed one, the
+	// failures are returned together as ImportErrors. It cannot be used
+	// with UseTransaction.
+	ContinueOnError bool
+	// Progress is called after each statement
+	Progress func(*ImportProgress)
+}
+
+// ImportProgress reports the progress of ImportWithOptions
+type ImportProgress struct {
+	Statements int   // number of the statements run so far
+	Failed     int   // number of the failed statements
+	BytesRead  int64 // bytes of the script read so far
+	Line       int   // line of the last statement
+	SQL        string
+	Err        error // error of the last statement
+}
+
+// ImportError is the error of a statement of an imported script
+type ImportError struct {
+	Line int
+	SQL  string
+	Err  error
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// ImportErrors are the errors of the statements which failed when the import
+// continues on error
+type ImportErrors []*ImportError
+
+func (e ImportErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d statements failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// ImportFileWithOptions imports a SQL script file, see ImportWithOptions
+func (engine *Engine) ImportFileWithOptions(ddlPath string, opts ImportOptions) ([]sql.Result, error) {
+	file, err := os.Open(ddlPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return engine.ImportWithOptions(file, opts)
+}
+
+// ImportWithOptions runs the statements of a SQL script written for the
+// engine's dialect. It stops at the first failed statement, returned as an
+// *ImportError, unless ContinueOnError is set.
+func (engine *Engine) ImportWithOptions(r io.Reader, opts ImportOptions) ([]sql.Result, error) {
+	// the statements following a failed one would fail too on PostgreSQL,
+	// and would be rolled back anyway
+	if opts.UseTransaction && opts.ContinueOnError {
+		return nil, ErrImportOptions
+	}
+
+	session := engine.NewSession()
+	defer session.Close()
+
+	if opts.UseTransaction {
+		if err := session.Begin(); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []sql.Result
+	var errs ImportErrors
+	progress := &ImportProgress{}
+	scanner := NewSQLScanner(r, engine.dialect.DBType())
+	for scanner.Scan() {
+		sqlStr := scanner.Statement()
+		result, err := session.runExec(sqlStr, nil, func() (sql.Result, error) {
+			if session.Tx != nil {
+				return session.Tx.ExecContext(session.ctx, sqlStr)
+			}
+			return session.DB().ExecContext(session.ctx, sqlStr)
+		})
+
+		progress.Statements++
+		progress.BytesRead = scanner.BytesRead()
+		progress.Line = scanner.Line()
+		progress.SQL = sqlStr
+		progress.Err = err
+		if err != nil {
+			progress.Failed++
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+
+		if err != nil {
+			importErr := &ImportError{Line: scanner.Line(), SQL: sqlStr, Err: err}
+			if !opts.ContinueOnError {
+				if opts.UseTransaction {
+					session.Rollback()
+				}
+				return results, importErr
+			}
+			errs = append(errs, importErr)
+			continue
+		}
+		results = append(results, result)
+	}
+	if err := scanner.Err(); err != nil {
+		if opts.UseTransaction {
+			session.Rollback()
+		}
+		return results, err
+	}
+
+	if opts.UseTransaction {
+		if err := session.Commit(); err != nil {
+			return results, err
+		}
+	}
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
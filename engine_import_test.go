@@ -0,0 +1,60 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+func TestImportWithOptions(t *testing.T) {
+	engine := newFakeEngine(t, core.POSTGRES)
+	defer engine.Close()
+	dsn := "postgres/" + t.Name()
+	script := "INSERT INTO a VALUES (1);\nINSERT INTO b VALUES (2);\nINSERT INTO a VALUES (3);"
+
+	// a failed statement aborts the transaction, the next ones cannot run
+	if _, err := engine.ImportWithOptions(strings.NewReader(script), ImportOptions{UseTransaction: true, ContinueOnError: true}); err != ErrImportOptions {
+		t.Fatal("want", ErrImportOptions, "get", err)
+	}
+	if stmts := takeFakeStmts(dsn); len(stmts) > 0 {
+		t.Fatalf("get %v", stmts)
+	}
+
+	var cases = []struct {
+		opts  ImportOptions
+		stmts []string
+		lines []int
+	}{
+		{ImportOptions{}, []string{"INSERT INTO a VALUES (1)", "INSERT INTO b VALUES (2)"}, []int{2}},
+		{ImportOptions{UseTransaction: true}, []string{"INSERT INTO a VALUES (1)", "INSERT INTO b VALUES (2)"}, []int{2}},
+		{ImportOptions{ContinueOnError: true},
+			[]string{"INSERT INTO a VALUES (1)", "INSERT INTO b VALUES (2)", "INSERT INTO a VALUES (3)"}, []int{2}},
+	}
+	for i, c := range cases {
+		queueFakeErrors(dsn, nil, errors.New("relation b does not exist"))
+		var failed []int
+		c.opts.Progress = func(p *ImportProgress) {
+			if p.Err != nil {
+				failed = append(failed, p.Line)
+			}
+		}
+		results, err := engine.ImportWithOptions(strings.NewReader(script), c.opts)
+		if err == nil {
+			t.Fatalf("%d: want an error", i)
+		}
+		if _, ok := err.(ImportErrors); ok != c.opts.ContinueOnError {
+			t.Errorf("%d: get %T %v", i, err, err)
+		}
+		if stmts := takeFakeStmts(dsn); !reflect.DeepEqual(stmts, c.stmts) || !reflect.DeepEqual(failed, c.lines) ||
+			len(results) != len(c.stmts)-1 {
+			t.Errorf("%d: want %v %v, get %v %v %d", i, c.stmts, c.lines, stmts, failed, len(results))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+// the defaults of TxOptions
+var (
+	DefaultTxMaxRetries = 3
+	DefaultTxBackoff    = 20 * time.Millisecond
+)
+
+// TxOptions are the options of Transaction
+type TxOptions struct {
+	// Isolation is the isolation level, the one of the database by default
+	Isolation sql.IsolationLevel
+	ReadOnly  bool
+	// MaxRetries is the number of times the function is run again after a
+	// deadlock or a serialization failure, DefaultTxMaxRetries when it's 0;
+	// it's not run again when it's negative
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubled at each retry,
+	// DefaultTxBackoff when it's 0
+	Backoff time.Duration
+}
+
+// Transaction runs fn in a transaction, committed when fn returns nil and
+// rolled back when it returns an error or panics. When the database reports
+// a deadlock, a serialization failure or a busy lock, the transaction is
+// rolled back and fn is run again in a new one after a backoff. The after
+// processors of the records are only called once the transaction commits.
+// The nested transactions begun by fn must be committed or rolled back by it,
+// otherwise the whole transaction is rolled back and ErrOpenSavePoint is
+// returned.
+func (engine *Engine) Transaction(fn func(*Session) error, opts ...TxOptions) error {
+	var opt TxOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	maxRetries := opt.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = DefaultTxMaxRetries
+	}
+	backoff := opt.Backoff
+	if backoff <= 0 {
+		backoff = DefaultTxBackoff
+	}
+
+	for retry := 0; ; retry++ {
+		err := engine.transaction(fn, &sql.TxOptions{Isolation: opt.Isolation, ReadOnly: opt.ReadOnly})
+		if err == nil || retry >= maxRetries || !isRetryableTxError(engine.dialect.DBType(), err) {
+			return err
+		}
+		engine.logger.Warnf("transaction retried after: %v", err)
+		time.Sleep(backoff << uint(retry))
+	}
+}
+
+// transaction runs one attempt of Transaction in a new session, so the
+// processors waiting for the commit of a failed attempt are dropped
+func (engine *Engine) transaction(fn func(*Session) error, opts *sql.TxOptions) (err error) {
+	session := engine.NewSession()
+	defer session.Close()
+	if err = session.BeginTx(opts); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			session.Rollback()
+			err = fmt.Errorf("panic in transaction: %v", r)
+		}
+	}()
+
+	if err = fn(session); err != nil {
+		session.Rollback()
+		return err
+	}
+	// committing would only release the savepoint of the nested transaction
+	// left open by fn, the whole transaction is rolled back instead
+	if len(session.savePoints) > 0 {
+		session.savePoints = nil
+		session.Rollback()
+		return ErrOpenSavePoint
+	}
+	return session.Commit()
+}
+
+// isRetryableTxError reports whether the error, or an error it wraps, is a
+// deadlock, a serialization failure or a busy lock of the database. The
+// errors of the drivers are read by reflection not to import them.
+func isRetryableTxError(dbType core.DbType, err error) bool {
+	for err != nil {
+		if state, ok := err.(interface {
+			SQLState() string
+		}); ok && dbType == core.POSTGRES {
+			code := state.SQLState()
+			return code == "40001" || code == "40P01"
+		}
+
+		v := reflect.Indirect(reflect.ValueOf(err))
+		if v.Kind() == reflect.Struct {
+			switch dbType {
+			case core.MYSQL:
+				// go-sql-driver/mysql, then mymysql
+				n, ok := errorField(v, "Number")
+				if !ok {
+					n, ok = errorField(v, "Code")
+				}
+				if ok {
+					return n == 1213 || n == 1205
+				}
+			case core.MSSQL:
+				if n, ok := errorField(v, "Number"); ok {
+					return n == 1205
+				}
+			case core.POSTGRES:
+				if code := v.FieldByName("Code"); code.IsValid() && code.Kind() == reflect.String {
+					return code.String() == "40001" || code.String() == "40P01"
+				}
+			case core.SQLITE:
+				// SQLITE_BUSY
+				if n, ok := errorField(v, "Code"); ok {
+					return n == 5
+				}
+			}
+		}
+
+		wrapper, ok := err.(interface {
+			Unwrap() error
+		})
+		if !ok {
+			break
+		}
+		err = wrapper.Unwrap()
+	}
+	return false
+}
+
+// errorField returns the integer field of an error struct
+func errorField(v reflect.Value, name string) (int64, bool) {
+	f := v.FieldByName(name)
+	switch f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return f.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(f.Uint()), true
+	}
+	return 0, false
+}
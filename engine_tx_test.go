@@ -0,0 +1,96 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+// the shapes of the errors of the drivers
+type (
+	pqErrorCode  string
+	pqError      struct{ Code pqErrorCode }
+	pgxError     struct{ Code string }
+	mysqlError   struct{ Number uint16 }
+	mymysqlError struct{ Code uint16 }
+	mssqlError   struct{ Number int32 }
+	sqliteErrNo  int
+	sqliteError  struct{ Code sqliteErrNo }
+)
+
+func (e *pqError) Error() string      { return string(e.Code) }
+func (e *pgxError) Error() string     { return e.Code }
+func (e *pgxError) SQLState() string  { return e.Code }
+func (e *mysqlError) Error() string   { return fmt.Sprint(e.Number) }
+func (e *mymysqlError) Error() string { return fmt.Sprint(e.Code) }
+func (e mssqlError) Error() string    { return fmt.Sprint(e.Number) }
+func (e sqliteError) Error() string   { return fmt.Sprint(e.Code) }
+
+func TestIsRetryableTxError(t *testing.T) {
+	var cases = []struct {
+		dbType core.DbType
+		err    error
+		retry  bool
+	}{
+		{core.POSTGRES, &pqError{"40001"}, true},
+		{core.POSTGRES, &pqError{"40P01"}, true},
+		{core.POSTGRES, &pqError{"23505"}, false},
+		{core.POSTGRES, &pgxError{"40P01"}, true},
+		{core.POSTGRES, &pgxError{"42P01"}, false},
+		{core.MYSQL, &mysqlError{1213}, true},
+		{core.MYSQL, &mysqlError{1205}, true},
+		{core.MYSQL, &mysqlError{1062}, false},
+		{core.MYSQL, &mymysqlError{1213}, true},
+		{core.MYSQL, &mymysqlError{1146}, false},
+		{core.MSSQL, mssqlError{1205}, true},
+		{core.MSSQL, mssqlError{2627}, false},
+		{core.SQLITE, sqliteError{5}, true},
+		{core.SQLITE, sqliteError{19}, false},
+		{core.SQLITE, fmt.Errorf("insert: %w", sqliteError{5}), true},
+		{core.MYSQL, fmt.Errorf("tx: %w", fmt.Errorf("exec: %w", &mysqlError{1213})), true},
+		// the codes are only read for the database of the engine
+		{core.MSSQL, &mymysqlError{1205}, false},
+		{core.SQLITE, &pgxError{"40001"}, false},
+		{core.POSTGRES, errors.New("40001"), false},
+		{core.POSTGRES, nil, false},
+	}
+
+	for i, c := range cases {
+		if retry := isRetryableTxError(c.dbType, c.err); retry != c.retry {
+			t.Errorf("%d: %s %#v: want %v, get %v", i, c.dbType, c.err, c.retry, retry)
+		}
+	}
+}
+
+func TestTransactionOpenSavePoint(t *testing.T) {
+	engine, err := NewEngine("xorm-fake", "postgres/tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	var runs int
+	err = engine.Transaction(func(session *Session) error {
+		runs++
+		return session.Begin()
+	})
+	if err != ErrOpenSavePoint || runs != 1 {
+		t.Fatal("want", ErrOpenSavePoint, "get", err, runs)
+	}
+
+	err = engine.Transaction(func(session *Session) error {
+		if err := session.Begin(); err != nil {
+			return err
+		}
+		return session.Commit()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,9 @@ package xorm
 
 import (
 	"errors"
+	"fmt"
+
+	"github.com/coscms/xorm/core"
 )
 
 var (
@@ -16,4 +19,29 @@ var (
 	ErrCacheFailed     error = errors.New("Cache failed")
 	ErrNeedDeletedCond error = errors.New("Delete need at least one condition")
 	ErrNotImplemented  error = errors.New("Not implemented.")
+	ErrInvalidCursor   error = errors.New("Invalid page cursor")
+	ErrPaginateOrder   error = errors.New("Paginate needs to order by columns of the fetched struct")
+	ErrCursorDirection error = errors.New("Rows only supports next page cursors")
+	ErrPaginateNull    error = errors.New("Paginate cannot seek from a NULL value of an ordering column")
+	ErrOptimisticLock  error = errors.New("Optimistic lock failed")
+	ErrNoDeletedColumn error = errors.New("Table has no deleted column")
+	ErrOpenSavePoint   error = errors.New("Transaction function returned with a nested transaction neither committed nor rolled back")
+	ErrImportOptions   error = errors.New("Import cannot continue on error in a transaction, a failed statement aborts it")
 )
+
+// OptimisticLockError is returned by Update and Delete when the record, given
+// by its primary key, was changed or deleted since its version was read
+type OptimisticLockError struct {
+	Table   string
+	PK      core.PK
+	Version interface{}
+}
+
+func (e *OptimisticLockError) Error() string {
+	return fmt.Sprintf("Optimistic lock failed: %s %v was changed or deleted since version %v", e.Table, []interface{}(e.PK), e.Version)
+}
+
+// Is makes errors.Is match ErrOptimisticLock
+func (e *OptimisticLockError) Is(target error) bool {
+	return target == ErrOptimisticLock
+}
@@ -0,0 +1,201 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+// fakeDriver is a database/sql driver recording the statements sent to each
+// data source, whose queries return the results queued by queueFakeResults
+// or else a single row of the column id equal to 1. Its execs affect the
+// numbers of rows queued by queueFakeAffected or else 1 row. The statements
+// fail with the errors queued by queueFakeErrors. Its data source names are
+// the database type followed by a name, e.g. "postgres/primary".
+type fakeDriver struct{}
+
+var fakeStmts = struct {
+	sync.Mutex
+	m        map[string][]string
+	results  map[string][]*fakeRows
+	errs     map[string][]error
+	affected map[string][]int64
+}{
+	m:        make(map[string][]string),
+	results:  make(map[string][]*fakeRows),
+	errs:     make(map[string][]error),
+	affected: make(map[string][]int64),
+}
+
+func init() {
+	sql.Register("xorm-fake", fakeDriver{})
+	core.RegisterDriver("xorm-fake", fakeDriver{})
+}
+
+func (fakeDriver) Parse(driverName, dataSourceName string) (*core.Uri, error) {
+	return &core.Uri{
+		DbType: core.DbType(strings.SplitN(dataSourceName, "/", 2)[0]),
+		DbName: dataSourceName,
+	}, nil
+}
+
+func (fakeDriver) Open(dataSourceName string) (driver.Conn, error) {
+	return fakeConn(dataSourceName), nil
+}
+
+// takeFakeStmts returns and forgets the statements sent to dataSourceName
+func takeFakeStmts(dataSourceName string) []string {
+	fakeStmts.Lock()
+	defer fakeStmts.Unlock()
+	stmts := fakeStmts.m[dataSourceName]
+	delete(fakeStmts.m, dataSourceName)
+	return stmts
+}
+
+// queueFakeResults sets the results of the next queries sent to
+// dataSourceName, each one is the columns followed by the rows
+func queueFakeResults(dataSourceName string, results ...[][]driver.Value) {
+	fakeStmts.Lock()
+	defer fakeStmts.Unlock()
+	for _, result := range results {
+		rows := &fakeRows{rows: result[1:]}
+		for _, col := range result[0] {
+			rows.cols = append(rows.cols, col.(string))
+		}
+		fakeStmts.results[dataSourceName] = append(fakeStmts.results[dataSourceName], rows)
+	}
+}
+
+// queueFakeErrors sets the errors of the next statements sent to
+// dataSourceName, a nil error lets its statement succeed
+func queueFakeErrors(dataSourceName string, errs ...error) {
+	fakeStmts.Lock()
+	fakeStmts.errs[dataSourceName] = append(fakeStmts.errs[dataSourceName], errs...)
+	fakeStmts.Unlock()
+}
+
+// queueFakeAffected sets the numbers of rows affected by the next execs sent
+// to dataSourceName
+func queueFakeAffected(dataSourceName string, affected ...int64) {
+	fakeStmts.Lock()
+	fakeStmts.affected[dataSourceName] = append(fakeStmts.affected[dataSourceName], affected...)
+	fakeStmts.Unlock()
+}
+
+type fakeConn string
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return fakeStmt{string(c), query}, nil
+}
+
+func (c fakeConn) Close() error {
+	return nil
+}
+
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error {
+	return nil
+}
+
+func (fakeTx) Rollback() error {
+	return nil
+}
+
+type fakeStmt struct {
+	dataSourceName string
+	query          string
+}
+
+// record records the statement and returns its queued error
+func (s fakeStmt) record() error {
+	fakeStmts.Lock()
+	defer fakeStmts.Unlock()
+	fakeStmts.m[s.dataSourceName] = append(fakeStmts.m[s.dataSourceName], s.query)
+	if errs := fakeStmts.errs[s.dataSourceName]; len(errs) > 0 {
+		fakeStmts.errs[s.dataSourceName] = errs[1:]
+		return errs[0]
+	}
+	return nil
+}
+
+func (s fakeStmt) Close() error {
+	return nil
+}
+
+func (s fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.record(); err != nil {
+		return nil, err
+	}
+	fakeStmts.Lock()
+	defer fakeStmts.Unlock()
+	if affected := fakeStmts.affected[s.dataSourceName]; len(affected) > 0 {
+		fakeStmts.affected[s.dataSourceName] = affected[1:]
+		return driver.RowsAffected(affected[0]), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if err := s.record(); err != nil {
+		return nil, err
+	}
+	fakeStmts.Lock()
+	defer fakeStmts.Unlock()
+	if results := fakeStmts.results[s.dataSourceName]; len(results) > 0 {
+		fakeStmts.results[s.dataSourceName] = results[1:]
+		return results[0], nil
+	}
+	return &fakeRows{cols: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}, nil
+}
+
+type fakeRows struct {
+	cols []string
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.cols
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+// newFakeEngine returns an engine of dbType on the fake driver, for the tests
+// of the generated SQL
+func newFakeEngine(t *testing.T, dbType core.DbType) *Engine {
+	engine, err := NewEngine("xorm-fake", string(dbType)+"/"+t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.SetLogger(NewSimpleLogger(ioutil.Discard))
+	return engine
+}
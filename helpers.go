@@ -502,6 +502,10 @@ func genCols(table *core.Table, session *Session, bean interface{}, useCol bool,
 
 	for _, col := range table.Columns() {
 		lColName := strings.ToLower(col.Name)
+		if useCol && col.IsVersion && session.Statement.checkVersion {
+			// the version is moved on by Update
+			continue
+		}
 		if useCol && !col.IsVersion && !col.IsCreated && !col.IsUpdated {
 			if _, ok := session.Statement.columnMap[lColName]; !ok {
 				continue
@@ -557,7 +561,7 @@ func genCols(table *core.Table, session *Session, bean interface{}, useCol bool,
 			}
 		}
 
-		if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime {
+		if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime || isTimeVersion(col, session) {
 			val, t := session.Engine.NowTime2(col.SQLType.Name)
 			args = append(args, val)
 
@@ -0,0 +1,169 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+// HookContext describes one SQL execution, it's passed to every Hook before
+// and after the statement is sent to the database
+type HookContext struct {
+	Ctx          context.Context
+	SQL          string
+	Args         []interface{}
+	ExecuteTime  time.Duration
+	RowsAffected int64 // -1 when the statement is a query or the driver doesn't report it
+	Err          error
+}
+
+// Hook is invoked around every SQL execution, raw or generated by the ORM.
+// BeforeProcess may return a derived context, e.g. carrying a tracing span,
+// which will be used to run the statement; returning an error aborts it.
+type Hook interface {
+	BeforeProcess(c *HookContext) (context.Context, error)
+	AfterProcess(c *HookContext) error
+}
+
+// AddHook registers hooks which will be invoked by all sessions of the
+// engine, it may be called while sessions are running
+func (engine *Engine) AddHook(hooks ...Hook) {
+	// the slice is copied on write, the ones returned by registeredHooks are
+	// never modified
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	registered := make([]Hook, len(engine.hooks), len(engine.hooks)+len(hooks))
+	copy(registered, engine.hooks)
+	engine.hooks = append(registered, hooks...)
+}
+
+func (engine *Engine) registeredHooks() []Hook {
+	engine.mutex.RLock()
+	defer engine.mutex.RUnlock()
+	return engine.hooks
+}
+
+// Hooks replaces the engine's hooks for this session only, call it
+// without arguments to disable hooks on the session
+func (session *Session) Hooks(hooks ...Hook) *Session {
+	if hooks == nil {
+		hooks = []Hook{}
+	}
+	session.hooks = hooks
+	return session
+}
+
+func (session *Session) activeHooks() []Hook {
+	if session.hooks != nil {
+		return session.hooks
+	}
+	return session.Engine.registeredHooks()
+}
+
+// processHooks runs fn between the BeforeProcess and AfterProcess of the
+// active hooks, fn returns the affected rows or -1
+func (session *Session) processHooks(sqlStr string, args []interface{}, fn func() (int64, error)) error {
+	hooks := session.activeHooks()
+	if len(hooks) == 0 {
+		_, err := fn()
+		return err
+	}
+
+	c := &HookContext{
+		Ctx:          session.ctx,
+		SQL:          sqlStr,
+		Args:         args,
+		RowsAffected: -1,
+	}
+	for i, hook := range hooks {
+		ctx, err := hook.BeforeProcess(c)
+		if err != nil {
+			// the hooks which already ran may have to end what they began
+			c.Err = err
+			for _, hook := range hooks[:i] {
+				hook.AfterProcess(c)
+			}
+			return err
+		}
+		if ctx != nil {
+			c.Ctx = ctx
+		}
+	}
+
+	oldCtx := session.ctx
+	session.ctx = c.Ctx
+	b4ExecTime := time.Now()
+	c.RowsAffected, c.Err = fn()
+	c.ExecuteTime = time.Since(b4ExecTime)
+	session.ctx = oldCtx
+
+	var hookErr error
+	for _, hook := range hooks {
+		if err := hook.AfterProcess(c); err != nil && hookErr == nil {
+			hookErr = err
+		}
+	}
+	if c.Err != nil {
+		return c.Err
+	}
+	return hookErr
+}
+
+// runQuery executes a query block with hooks and execution time logging
+func (session *Session) runQuery(sqlStr string, args []interface{}, executionBlock func() (*core.Stmt, *core.Rows, error)) (*core.Stmt, *core.Rows, error) {
+	var stmt *core.Stmt
+	var rows *core.Rows
+	err := session.processHooks(sqlStr, args, func() (int64, error) {
+		var err error
+		stmt, rows, err = session.Engine.logSQLQueryTime(sqlStr, args, executionBlock)
+		return -1, err
+	})
+	if err != nil && rows != nil {
+		rows.Close()
+		rows = nil
+	}
+	return stmt, rows, err
+}
+
+// runExec executes an exec block with hooks and execution time logging
+func (session *Session) runExec(sqlStr string, args []interface{}, executionBlock func() (sql.Result, error)) (sql.Result, error) {
+	var res sql.Result
+	err := session.processHooks(sqlStr, args, func() (int64, error) {
+		var err error
+		res, err = session.Engine.logSQLExecutionTime(sqlStr, args, executionBlock)
+		if err != nil || res == nil {
+			return -1, err
+		}
+		affected, e := res.RowsAffected()
+		if e != nil {
+			return -1, nil
+		}
+		return affected, nil
+	})
+	return res, err
+}
+
+// dbQuery queries on the db without prepared statement
+func (session *Session) dbQuery(db *core.DB, sqlStr string, args ...interface{}) (*core.Rows, error) {
+	_, rows, err := session.runQuery(sqlStr, args, func() (*core.Stmt, *core.Rows, error) {
+		rows, err := db.QueryContext(session.ctx, sqlStr, args...)
+		return nil, rows, err
+	})
+	return rows, err
+}
+
+// queryRowScan queries a single row in or out of the transaction and scans it
+func (session *Session) queryRowScan(sqlStr string, args []interface{}, scan func(*core.Row) error) error {
+	return session.processHooks(sqlStr, args, func() (int64, error) {
+		if session.IsAutoCommit {
+			return -1, scan(session.queryDB().QueryRowContext(session.ctx, sqlStr, args...))
+		}
+		return -1, scan(session.Tx.QueryRowContext(session.ctx, sqlStr, args...))
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+type hookKey struct{}
+
+// testHook records its calls in events, its BeforeProcess derives the
+// context with its name or fails with err
+type testHook struct {
+	name   string
+	err    error
+	events *[]string
+}
+
+func (h *testHook) BeforeProcess(c *HookContext) (context.Context, error) {
+	*h.events = append(*h.events, "before "+h.name)
+	if h.err != nil {
+		return nil, h.err
+	}
+	return context.WithValue(c.Ctx, hookKey{}, h.name), nil
+}
+
+func (h *testHook) AfterProcess(c *HookContext) error {
+	event := "after " + h.name
+	if c.Err != nil {
+		event += ": " + c.Err.Error()
+	}
+	*h.events = append(*h.events, event)
+	return nil
+}
+
+// ctxHook records the context value and the affected rows seen after the
+// statements
+type ctxHook struct {
+	values   []interface{}
+	affected []int64
+}
+
+func (h *ctxHook) BeforeProcess(c *HookContext) (context.Context, error) {
+	return nil, nil
+}
+
+func (h *ctxHook) AfterProcess(c *HookContext) error {
+	h.values = append(h.values, c.Ctx.Value(hookKey{}))
+	h.affected = append(h.affected, c.RowsAffected)
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	var events []string
+	seen := &ctxHook{}
+	engine.AddHook(&testHook{name: "a", events: &events}, &testHook{name: "b", events: &events})
+	engine.AddHook(seen)
+
+	if _, err := engine.Exec("UPDATE user SET name = ?", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := engine.Query("SELECT id FROM user"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"before a", "before b", "after a", "after b", "before a", "before b", "after a", "after b"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("want %v, get %v", want, events)
+	}
+	// the context is replaced by the last hook returning one
+	if !reflect.DeepEqual(seen.values, []interface{}{"b", "b"}) || !reflect.DeepEqual(seen.affected, []int64{1, -1}) {
+		t.Errorf("get %v %v", seen.values, seen.affected)
+	}
+	takeFakeStmts(dsn)
+
+	// the statement fails
+	events = nil
+	queueFakeErrors(dsn, errors.New("bad"))
+	if _, err := engine.Exec("UPDATE user SET name = ?", "a"); err == nil || err.Error() != "bad" {
+		t.Fatal("want bad, get", err)
+	}
+	want = []string{"before a", "before b", "after a: bad", "after b: bad"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("want %v, get %v", want, events)
+	}
+	takeFakeStmts(dsn)
+
+	// a hook fails, the statement is not run and only the hooks which ran
+	// before it are ended
+	events = nil
+	session := engine.NewSession()
+	defer session.Close()
+	denied := errors.New("denied")
+	session.Hooks(&testHook{name: "a", events: &events}, &testHook{name: "b", err: denied, events: &events},
+		&testHook{name: "c", events: &events})
+	if _, err := session.Exec("UPDATE user SET name = ?", "a"); err != denied {
+		t.Fatal("want", denied, "get", err)
+	}
+	want = []string{"before a", "before b", "after a: denied"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("want %v, get %v", want, events)
+	}
+	if stmts := takeFakeStmts(dsn); len(stmts) > 0 {
+		t.Errorf("get the statements %v", stmts)
+	}
+	if session.ctx != context.Background() {
+		t.Error("the context of the session is changed")
+	}
+
+	// the hooks of the engine are disabled for the session
+	events = nil
+	if _, err := session.Hooks().Exec("UPDATE user SET name = ?", "a"); err != nil || len(events) > 0 {
+		t.Errorf("get %v %v", err, events)
+	}
+}
+
+func TestAddHookConcurrently(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			engine.AddHook(&ctxHook{})
+		}()
+		go func() {
+			defer wg.Done()
+			engine.Query("SELECT id FROM user")
+		}()
+	}
+	wg.Wait()
+	if hooks := engine.registeredHooks(); len(hooks) != 4 {
+		t.Errorf("want 4 hooks, get %d", len(hooks))
+	}
+}
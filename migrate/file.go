@@ -0,0 +1,104 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/coscms/xorm"
+)
+
+const (
+	upSuffix   = ".up.sql"
+	downSuffix = ".down.sql"
+)
+
+// LoadDir adds the SQL file migrations found in dir. A migration is made of
+// <id>_<description>.up.sql and an optional <id>_<description>.down.sql.
+func (m *Migrator) LoadDir(dir string) error {
+	migrations, err := ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	m.Add(migrations...)
+	return nil
+}
+
+// ReadDir reads the SQL file migrations in dir
+func ReadDir(dir string) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*Migration)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		name := file.Name()
+		var isUp bool
+		switch {
+		case strings.HasSuffix(name, upSuffix):
+			isUp = true
+			name = strings.TrimSuffix(name, upSuffix)
+		case strings.HasSuffix(name, downSuffix):
+			name = strings.TrimSuffix(name, downSuffix)
+		default:
+			continue
+		}
+
+		id, description := parseFileName(name)
+		content, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byID[id]
+		if !ok {
+			migration = &Migration{ID: id, Description: description}
+			byID[id] = migration
+		}
+		if isUp {
+			migration.Migrate = sqlMigrateFunc(string(content))
+		} else {
+			migration.Rollback = sqlMigrateFunc(string(content))
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byID))
+	for _, migration := range byID {
+		if migration.Migrate == nil {
+			return nil, fmt.Errorf("Migration %v has no %v file", migration.ID, upSuffix)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].ID < migrations[j].ID
+	})
+	return migrations, nil
+}
+
+func parseFileName(name string) (id string, description string) {
+	if i := strings.Index(name, "_"); i > 0 {
+		return name[:i], strings.Replace(name[i+1:], "_", " ", -1)
+	}
+	return name, ""
+}
+
+func sqlMigrateFunc(content string) MigrateFunc {
+	return func(session *xorm.Session) error {
+		for _, sqlStr := range xorm.SplitSQL(content, session.Engine.Dialect().DBType()) {
+			if _, err := session.Exec(sqlStr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,419 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package migrate runs ordered and versioned schema changes on a xorm engine.
+//
+// Every applied migration is recorded in a bookkeeping table, so that pending
+// ones can be applied and applied ones rolled back in order:
+//
+//	m := migrate.New(engine, migrate.DefaultOptions, []*migrate.Migration{
+//	    {
+//	        ID: "201701011200",
+//	        Migrate: func(s *xorm.Session) error {
+//	            return s.Sync2(new(User))
+//	        },
+//	        Rollback: func(s *xorm.Session) error {
+//	            return s.DropTable(new(User))
+//	        },
+//	    },
+//	})
+//	err := m.Migrate()
+//
+// Migrations can also be loaded from a directory of SQL files named
+// <id>_<description>.up.sql and <id>_<description>.down.sql.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/coscms/xorm"
+	"github.com/coscms/xorm/core"
+)
+
+var (
+	ErrNoMigrations     = errors.New("No migrations defined")
+	ErrNoRollback       = errors.New("Migration has no rollback")
+	ErrNoRunMigration   = errors.New("No migration has been run")
+	ErrUnknownMigration = errors.New("Unknown migration")
+	ErrDuplicatedID     = errors.New("Duplicated migration ID")
+	ErrMissingID        = errors.New("Missing migration ID")
+	ErrLocked           = errors.New("Migrations are locked by another process")
+)
+
+// MigrateFunc changes the schema, the session is inside a transaction when the
+// dialect supports transactional DDL
+type MigrateFunc func(*xorm.Session) error
+
+// Migration is one versioned change, migrations are applied in the order of
+// their ID
+type Migration struct {
+	ID          string
+	Description string
+	Migrate     MigrateFunc
+	Rollback    MigrateFunc
+}
+
+// Options configures a Migrator
+type Options struct {
+	// TableName is the bookkeeping table of applied migrations
+	TableName string
+	// LockTableName is the table used to prevent concurrent migrations
+	LockTableName string
+	// LockTimeout is how long to wait for another process to release the
+	// lock, zero means fail at once
+	LockTimeout time.Duration
+	// UseTransaction runs every migration in its own transaction. It has
+	// no effect on MySQL and Oracle whose DDL statements commit implicitly.
+	UseTransaction bool
+}
+
+// DefaultOptions is used when New is given nil options
+var DefaultOptions = &Options{
+	TableName:      "migrations",
+	LockTableName:  "migrations_lock",
+	LockTimeout:    time.Minute,
+	UseTransaction: true,
+}
+
+// Record is a row of the bookkeeping table
+type Record struct {
+	Id          string    `xorm:"'id' varchar(255) pk not null"`
+	Description string    `xorm:"'description' varchar(255)"`
+	AppliedAt   time.Time `xorm:"'applied_at' not null"`
+}
+
+// lockRecord is the only row of the lock table while migrations run
+type lockRecord struct {
+	Id       int       `xorm:"'id' pk not null"`
+	Owner    string    `xorm:"'owner' varchar(255)"`
+	LockedAt time.Time `xorm:"'locked_at' not null"`
+}
+
+// Status tells if a migration has been applied
+type Status struct {
+	ID          string
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+// Migrator applies and rolls back migrations on an engine
+type Migrator struct {
+	engine     *xorm.Engine
+	options    *Options
+	migrations []*Migration
+	owner      string
+}
+
+// New creates a Migrator
+func New(engine *xorm.Engine, options *Options, migrations []*Migration) *Migrator {
+	if options == nil {
+		options = DefaultOptions
+	}
+	m := &Migrator{
+		engine:  engine,
+		options: options,
+		owner:   fmt.Sprintf("%d", time.Now().UnixNano()),
+	}
+	m.Add(migrations...)
+	return m
+}
+
+// Add adds migrations
+func (m *Migrator) Add(migrations ...*Migration) {
+	m.migrations = append(m.migrations, migrations...)
+}
+
+// Migrations returns the migrations sorted by ID
+func (m *Migrator) Migrations() ([]*Migration, error) {
+	if len(m.migrations) == 0 {
+		return nil, ErrNoMigrations
+	}
+	ids := make(map[string]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		if migration.ID == "" {
+			return nil, ErrMissingID
+		}
+		if ids[migration.ID] {
+			return nil, fmt.Errorf("%v: %v", ErrDuplicatedID, migration.ID)
+		}
+		ids[migration.ID] = true
+	}
+	sort.SliceStable(m.migrations, func(i, j int) bool {
+		return m.migrations[i].ID < m.migrations[j].ID
+	})
+	return m.migrations, nil
+}
+
+// Migrate applies all the pending migrations
+func (m *Migrator) Migrate() error {
+	return m.MigrateTo("")
+}
+
+// MigrateTo applies the pending migrations up to and including id, an empty id
+// applies all of them
+func (m *Migrator) MigrateTo(id string) error {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	if id != "" && m.find(id) == nil {
+		return fmt.Errorf("%v: %v", ErrUnknownMigration, id)
+	}
+
+	return m.locked(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for _, migration := range migrations {
+			if id != "" && migration.ID > id {
+				break
+			}
+			if _, ok := applied[migration.ID]; ok {
+				continue
+			}
+			if err = m.runMigration(migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RollbackLast rolls back the last applied migration
+func (m *Migrator) RollbackLast() error {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+
+	return m.locked(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[migrations[i].ID]; ok {
+				return m.runRollback(migrations[i])
+			}
+		}
+		return ErrNoRunMigration
+	})
+}
+
+// RollbackTo rolls back the applied migrations after id, the migration id
+// itself stays applied
+func (m *Migrator) RollbackTo(id string) error {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	if m.find(id) == nil {
+		return fmt.Errorf("%v: %v", ErrUnknownMigration, id)
+	}
+
+	return m.locked(func() error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0; i-- {
+			migration := migrations[i]
+			if migration.ID <= id {
+				break
+			}
+			if _, ok := applied[migration.ID]; !ok {
+				continue
+			}
+			if err = m.runRollback(migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status returns the status of every known migration
+func (m *Migrator) Status() ([]*Status, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if err = m.createTables(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]*Status, 0, len(migrations))
+	for _, migration := range migrations {
+		s := &Status{
+			ID:          migration.ID,
+			Description: migration.Description,
+		}
+		if record, ok := applied[migration.ID]; ok {
+			s.Applied = true
+			s.AppliedAt = record.AppliedAt
+		}
+		status = append(status, s)
+	}
+	return status, nil
+}
+
+// Unlock releases the lock left by a crashed process
+func (m *Migrator) Unlock() error {
+	if err := m.createTables(); err != nil {
+		return err
+	}
+	_, err := m.engine.Table(m.options.LockTableName).Where("id = ?", 1).Delete(new(lockRecord))
+	return err
+}
+
+func (m *Migrator) find(id string) *Migration {
+	for _, migration := range m.migrations {
+		if migration.ID == id {
+			return migration
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) createTables() error {
+	tables := []struct {
+		name string
+		bean interface{}
+	}{
+		{m.options.TableName, new(Record)},
+		{m.options.LockTableName, new(lockRecord)},
+	}
+	for _, table := range tables {
+		exist, err := m.engine.IsTableExist(table.name)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			if err = m.engine.Table(table.name).CreateTable(table.bean); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) applied() (map[string]*Record, error) {
+	var records []*Record
+	err := m.engine.Table(m.options.TableName).Find(&records)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]*Record, len(records))
+	for _, record := range records {
+		applied[record.Id] = record
+	}
+	return applied, nil
+}
+
+// locked runs fn while holding the migration lock
+func (m *Migrator) locked(fn func() error) error {
+	if err := m.createTables(); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(m.options.LockTimeout)
+	for {
+		lock := &lockRecord{Id: 1, Owner: m.owner, LockedAt: time.Now()}
+		_, err := m.engine.Table(m.options.LockTableName).Insert(lock)
+		if err == nil {
+			break
+		}
+		// the insert fails on the primary key while another process holds the lock
+		locked, e := m.engine.Table(m.options.LockTableName).Where("id = ?", 1).Exist()
+		if e != nil {
+			return e
+		}
+		if !locked {
+			return err
+		}
+		if !time.Now().Before(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(time.Second)
+	}
+
+	defer m.engine.Table(m.options.LockTableName).Where("id = ? AND owner = ?", 1, m.owner).Delete(new(lockRecord))
+	return fn()
+}
+
+func (m *Migrator) useTransaction() bool {
+	if !m.options.UseTransaction {
+		return false
+	}
+	switch m.engine.Dialect().DBType() {
+	case core.MYSQL, core.ORACLE:
+		return false
+	}
+	return true
+}
+
+// run runs fn and updates the bookkeeping table in one transaction if
+// possible
+func (m *Migrator) run(fn MigrateFunc, record func(*xorm.Session) error) error {
+	session := m.engine.NewSession()
+	defer session.Close()
+
+	useTx := m.useTransaction()
+	if useTx {
+		if err := session.Begin(); err != nil {
+			return err
+		}
+	}
+	if err := fn(session); err != nil {
+		if useTx {
+			session.Rollback()
+		}
+		return err
+	}
+	if err := record(session); err != nil {
+		if useTx {
+			session.Rollback()
+		}
+		return err
+	}
+	if useTx {
+		return session.Commit()
+	}
+	return nil
+}
+
+func (m *Migrator) runMigration(migration *Migration) error {
+	if migration.Migrate == nil {
+		return fmt.Errorf("Migration %v has no migrate function", migration.ID)
+	}
+	m.engine.Logger().Infof("[migrate] applying %v %v", migration.ID, migration.Description)
+	return m.run(migration.Migrate, func(session *xorm.Session) error {
+		_, err := session.Table(m.options.TableName).Insert(&Record{
+			Id:          migration.ID,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		})
+		return err
+	})
+}
+
+func (m *Migrator) runRollback(migration *Migration) error {
+	if migration.Rollback == nil {
+		return fmt.Errorf("%v: %v", ErrNoRollback, migration.ID)
+	}
+	m.engine.Logger().Infof("[migrate] rolling back %v %v", migration.ID, migration.Description)
+	return m.run(migration.Rollback, func(session *xorm.Session) error {
+		_, err := session.Table(m.options.TableName).Where("id = ?", migration.ID).Delete(new(Record))
+		return err
+	})
+}
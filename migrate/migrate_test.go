@@ -0,0 +1,253 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestEngine(t *testing.T) (*xorm.Engine, func()) {
+	dir, err := ioutil.TempDir("", "xorm-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine, err := xorm.NewEngine("sqlite3", filepath.Join(dir, "test.db"))
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	engine.SetLogger(xorm.NewSimpleLogger(ioutil.Discard))
+	return engine, func() {
+		engine.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func testMigration(id string, runs *[]string) *Migration {
+	return &Migration{
+		ID:          id,
+		Description: "create t" + id,
+		Migrate: func(s *xorm.Session) error {
+			*runs = append(*runs, "+"+id)
+			_, err := s.Exec("CREATE TABLE t" + id + " (id INTEGER)")
+			return err
+		},
+		Rollback: func(s *xorm.Session) error {
+			*runs = append(*runs, "-"+id)
+			_, err := s.Exec("DROP TABLE t" + id)
+			return err
+		},
+	}
+}
+
+func appliedIDs(t *testing.T, m *Migrator) (ids []string) {
+	status, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.Applied {
+			ids = append(ids, s.ID)
+		}
+	}
+	return
+}
+
+func lockOwners(t *testing.T, engine *xorm.Engine) (owners []string) {
+	var locks []*lockRecord
+	if err := engine.Table(DefaultOptions.LockTableName).Find(&locks); err != nil {
+		t.Fatal(err)
+	}
+	for _, lock := range locks {
+		owners = append(owners, lock.Owner)
+	}
+	return
+}
+
+func TestReadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "xorm-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"002_add_email.up.sql":     "ALTER TABLE user ADD email VARCHAR(255);",
+		"001_create_user.up.sql":   "CREATE TABLE user (id INT);",
+		"001_create_user.down.sql": "DROP TABLE user;",
+		"README.md":                "not a migration",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	migrations, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("got %d migrations, want 2", len(migrations))
+	}
+	if migrations[0].ID != "001" || migrations[0].Description != "create user" ||
+		migrations[0].Migrate == nil || migrations[0].Rollback == nil {
+		t.Errorf("unexpected migration %#v", migrations[0])
+	}
+	if migrations[1].ID != "002" || migrations[1].Rollback != nil {
+		t.Errorf("unexpected migration %#v", migrations[1])
+	}
+}
+
+func TestMigrateBookkeeping(t *testing.T) {
+	engine, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	var runs []string
+	m := New(engine, nil, []*Migration{testMigration("003", &runs), testMigration("001", &runs), testMigration("002", &runs)})
+
+	var cases = []struct {
+		name    string
+		fn      func() error
+		runs    []string
+		applied []string
+	}{
+		{"migrate to", func() error { return m.MigrateTo("002") }, []string{"+001", "+002"}, []string{"001", "002"}},
+		// the applied migrations are not run again
+		{"migrate", m.Migrate, []string{"+003"}, []string{"001", "002", "003"}},
+		{"migrate nothing", m.Migrate, nil, []string{"001", "002", "003"}},
+		{"rollback last", m.RollbackLast, []string{"-003"}, []string{"001", "002"}},
+		{"rollback to", func() error { return m.RollbackTo("001") }, []string{"-002"}, []string{"001"}},
+		{"rollback nothing", func() error { return m.RollbackTo("001") }, nil, []string{"001"}},
+	}
+	for _, c := range cases {
+		runs = nil
+		if err := c.fn(); err != nil {
+			t.Fatal(c.name, err)
+		}
+		if applied := appliedIDs(t, m); !reflect.DeepEqual(runs, c.runs) || !reflect.DeepEqual(applied, c.applied) {
+			t.Errorf("%s: want %v %v, get %v %v", c.name, c.runs, c.applied, runs, applied)
+		}
+		// the lock is released after every run
+		if owners := lockOwners(t, engine); len(owners) > 0 {
+			t.Errorf("%s: the lock is held by %v", c.name, owners)
+		}
+	}
+
+	var record Record
+	has, err := engine.Table(DefaultOptions.TableName).Where("id = ?", "001").Get(&record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !has || record.Description != "create t001" || record.AppliedAt.IsZero() {
+		t.Errorf("get %v %+v", has, record)
+	}
+
+	if err = m.MigrateTo("004"); err == nil {
+		t.Error("want an error for an unknown migration")
+	}
+	if err = m.RollbackLast(); err != nil {
+		t.Fatal(err)
+	}
+	if err = m.RollbackLast(); err != ErrNoRunMigration {
+		t.Error("want", ErrNoRunMigration, "get", err)
+	}
+}
+
+func TestMigrateFailure(t *testing.T) {
+	engine, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	var runs []string
+	failed := testMigration("002", &runs)
+	failed.Migrate = func(s *xorm.Session) error {
+		if _, err := s.Exec("CREATE TABLE t002 (id INTEGER)"); err != nil {
+			return err
+		}
+		_, err := s.Exec("INSERT INTO missing VALUES (1)")
+		return err
+	}
+	m := New(engine, nil, []*Migration{testMigration("001", &runs), failed, testMigration("003", &runs)})
+
+	if err := m.Migrate(); err == nil {
+		t.Fatal("want an error")
+	}
+	// the failed migration is neither recorded nor half applied
+	if applied := appliedIDs(t, m); !reflect.DeepEqual(applied, []string{"001"}) {
+		t.Errorf("want [001], get %v", applied)
+	}
+	if exist, err := engine.IsTableExist("t002"); err != nil || exist {
+		t.Errorf("want no table t002, get %v %v", exist, err)
+	}
+	if owners := lockOwners(t, engine); len(owners) > 0 {
+		t.Errorf("the lock is held by %v", owners)
+	}
+}
+
+func TestMigrateLock(t *testing.T) {
+	engine, cleanup := newTestEngine(t)
+	defer cleanup()
+
+	var runs []string
+	options := *DefaultOptions
+	options.LockTimeout = 0
+	m := New(engine, &options, []*Migration{testMigration("001", &runs)})
+	other := New(engine, &options, nil)
+
+	// the lock is held by the migrator while it runs
+	migrate := m.migrations[0].Migrate
+	m.migrations[0].Migrate = func(s *xorm.Session) error {
+		var lock lockRecord
+		has, err := s.Table(options.LockTableName).Get(&lock)
+		if err != nil {
+			return err
+		}
+		if !has || lock.Owner != m.owner {
+			t.Errorf("want the lock of %s, get %v %+v", m.owner, has, lock)
+		}
+		return migrate(s)
+	}
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crashed process left its lock
+	m.migrations[0].Migrate = migrate
+	if err := m.RollbackLast(); err != nil {
+		t.Fatal(err)
+	}
+	runs = nil
+	if _, err := engine.Table(options.LockTableName).Insert(&lockRecord{Id: 1, Owner: other.owner, LockedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Migrate(); err != ErrLocked {
+		t.Fatal("want", ErrLocked, "get", err)
+	}
+	// the lock of another process is not released
+	if owners := lockOwners(t, engine); len(runs) > 0 || !reflect.DeepEqual(owners, []string{other.owner}) {
+		t.Fatalf("get %v %v", runs, owners)
+	}
+
+	// the lock is waited for until it is released
+	m.options = &Options{TableName: options.TableName, LockTableName: options.LockTableName, LockTimeout: 5 * time.Second}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		other.Unlock()
+	}()
+	if err := m.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if owners := lockOwners(t, engine); len(owners) > 0 || !reflect.DeepEqual(runs, []string{"+001"}) {
+		t.Errorf("get %v %v", runs, owners)
+	}
+}
@@ -23,6 +23,11 @@ type Rows struct {
 	fieldsCount int
 	beanType    reflect.Type
 	lastError   error
+
+	page       *pageState
+	read       int
+	hasMore    bool
+	lastValues []interface{}
 }
 
 func newRows(session *Session, bean interface{}) (*Rows, error) {
@@ -40,6 +45,17 @@ func newRows(session *Session, bean interface{}) (*Rows, error) {
 		return nil, ErrTableNotFound
 	}
 
+	if rows.session.Statement.paginate != nil {
+		page, err := rows.session.preparePage(rows.beanType)
+		if err != nil {
+			return nil, err
+		}
+		if page.cursor != nil && page.cursor.Prev {
+			return nil, ErrCursorDirection
+		}
+		rows.page = page
+	}
+
 	if rows.session.Statement.RawSQL == "" {
 		sqlStr, args = rows.session.Statement.genGetSQL(bean)
 	} else {
@@ -53,22 +69,23 @@ func newRows(session *Session, bean interface{}) (*Rows, error) {
 
 	rows.session.saveLastSQL(sqlStr, args)
 	var err error
+	db := rows.session.queryDB()
 	if rows.session.prepareStmt {
-		rows.stmt, err = rows.session.DB().Prepare(sqlStr)
-		if err != nil {
-			rows.lastError = err
-			rows.Close()
-			return nil, err
-		}
-
-		rows.rows, err = rows.stmt.Query(args...)
+		rows.stmt, rows.rows, err = rows.session.runQuery(sqlStr, args, func() (*core.Stmt, *core.Rows, error) {
+			stmt, err := db.PrepareContext(session.ctx, sqlStr)
+			if err != nil {
+				return nil, nil, err
+			}
+			res, err := stmt.QueryContext(session.ctx, args...)
+			return stmt, res, err
+		})
 		if err != nil {
 			rows.lastError = err
 			rows.Close()
 			return nil, err
 		}
 	} else {
-		rows.rows, err = rows.session.DB().Query(sqlStr, args...)
+		rows.rows, err = rows.session.dbQuery(db, sqlStr, args...)
 		if err != nil {
 			rows.lastError = err
 			rows.Close()
@@ -91,6 +108,14 @@ func newRows(session *Session, bean interface{}) (*Rows, error) {
 func (rows *Rows) Next() bool {
 	if rows.lastError == nil && rows.rows != nil {
 		hasNext := rows.rows.Next()
+		if hasNext && rows.page != nil {
+			// the extra record only tells whether another page follows
+			if rows.read == rows.page.pageSize {
+				rows.hasMore = true
+				hasNext = false
+			}
+			rows.read++
+		}
 		if !hasNext {
 			rows.lastError = sql.ErrNoRows
 		}
@@ -99,6 +124,15 @@ func (rows *Rows) Next() bool {
 	return false
 }
 
+// NextCursor returns the cursor of the page following the one iterated by a
+// paginated Rows, once Next has returned false. It's empty on the last page.
+func (rows *Rows) NextCursor() (string, error) {
+	if rows.page == nil || !rows.hasMore || rows.lastValues == nil {
+		return "", nil
+	}
+	return encodeCursor(false, rows.lastValues)
+}
+
 // Err returns the error, if any, that was encountered during iteration. Err may be called after an explicit or implicit Close.
 func (rows *Rows) Err() error {
 	return rows.lastError
@@ -114,7 +148,15 @@ func (rows *Rows) Scan(bean interface{}) error {
 		return fmt.Errorf("scan arg is incompatible type to [%v]", rows.beanType)
 	}
 
-	return rows.session.row2Bean(rows.rows, rows.fields, rows.fieldsCount, bean)
+	if err := rows.session.row2Bean(rows.rows, rows.fields, rows.fieldsCount, bean); err != nil {
+		return err
+	}
+	if rows.page != nil {
+		var err error
+		rows.lastValues, err = rows.session.cursorValues(rows.page.orders, reflect.ValueOf(bean))
+		return err
+	}
+	return nil
 }
 
 // Close session if session.IsAutoClose is true, and claimed any opened resources
@@ -0,0 +1,584 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coscms/xorm/core"
+)
+
+// SchemaDiff is the changeset between the expected tables, usually mapped from
+// structs, and the actual tables of a database
+type SchemaDiff struct {
+	MissingTables []*core.Table // expected but not in the database
+	ExtraTables   []*core.Table // in the database but not expected
+	Tables        []*TableDiff  // tables in both having differences
+
+	dialect core.Dialect
+}
+
+// TableDiff is the changeset of one table
+type TableDiff struct {
+	Name     string
+	Expected *core.Table
+	Actual   *core.Table
+
+	MissingColumns []*core.Column
+	ExtraColumns   []*core.Column
+	ChangedColumns []*ColumnDiff
+
+	MissingIndexes []*core.Index
+	ExtraIndexes   []*core.Index
+	RenamedIndexes []*IndexRename
+
+	PrimaryKeyChanged bool
+}
+
+// IndexRename is an index declared with another name in the database
+type IndexRename struct {
+	From *core.Index // the index in the database
+	To   *core.Index // the expected index
+}
+
+// ColumnDiff is the changeset of one column
+type ColumnDiff struct {
+	Name     string
+	Expected *core.Column
+	Actual   *core.Column
+
+	TypeChanged     bool
+	LengthChanged   bool
+	NullableChanged bool
+	DefaultChanged  bool
+}
+
+// IsEmpty returns true when the schemas are the same
+func (diff *SchemaDiff) IsEmpty() bool {
+	return len(diff.MissingTables) == 0 && len(diff.ExtraTables) == 0 && len(diff.Tables) == 0
+}
+
+// IsEmpty returns true when the tables are the same
+func (diff *TableDiff) IsEmpty() bool {
+	return len(diff.MissingColumns) == 0 && len(diff.ExtraColumns) == 0 &&
+		len(diff.ChangedColumns) == 0 && len(diff.MissingIndexes) == 0 &&
+		len(diff.ExtraIndexes) == 0 && len(diff.RenamedIndexes) == 0 && !diff.PrimaryKeyChanged
+}
+
+// String describes the changes of the column
+func (diff *ColumnDiff) String() string {
+	var changes []string
+	if diff.TypeChanged || diff.LengthChanged {
+		changes = append(changes, fmt.Sprintf("type %s -> %s",
+			columnTypeString(diff.Actual), columnTypeString(diff.Expected)))
+	}
+	if diff.NullableChanged {
+		changes = append(changes, fmt.Sprintf("nullable %v -> %v", diff.Actual.Nullable, diff.Expected.Nullable))
+	}
+	if diff.DefaultChanged {
+		changes = append(changes, fmt.Sprintf("default %q -> %q", diff.Actual.Default, diff.Expected.Default))
+	}
+	return diff.Name + ": " + strings.Join(changes, ", ")
+}
+
+// the ranks of the types of a family, by the values they can hold
+type typeRank struct {
+	family string
+	rank   int
+}
+
+var typeRanks = map[string]typeRank{
+	core.Bit:        {"int", 0},
+	core.TinyInt:    {"int", 1},
+	core.SmallInt:   {"int", 2},
+	core.MediumInt:  {"int", 3},
+	core.Int:        {"int", 4},
+	core.Integer:    {"int", 4},
+	core.Serial:     {"int", 4},
+	core.BigInt:     {"int", 5},
+	core.BigSerial:  {"int", 5},
+	core.Decimal:    {"decimal", 1},
+	core.Numeric:    {"decimal", 1},
+	core.Real:       {"float", 1},
+	core.Float:      {"float", 1},
+	core.Double:     {"float", 2},
+	core.Char:       {"text", 1},
+	core.Varchar:    {"text", 1},
+	core.NVarchar:   {"text", 1},
+	core.TinyText:   {"text", 2},
+	core.Text:       {"text", 3},
+	core.Clob:       {"text", 3},
+	core.MediumText: {"text", 4},
+	core.LongText:   {"text", 5},
+	core.Date:       {"time", 1},
+	core.DateTime:   {"time", 2},
+	core.TimeStamp:  {"time", 2},
+	core.TimeStampz: {"time", 3},
+	core.Binary:     {"blob", 1},
+	core.VarBinary:  {"blob", 1},
+	core.TinyBlob:   {"blob", 2},
+	core.Blob:       {"blob", 3},
+	core.Bytea:      {"blob", 3},
+	core.MediumBlob: {"blob", 4},
+	core.LongBlob:   {"blob", 5},
+}
+
+// the decimal digits of the largest values of the integer types, by rank
+var intDigits = map[int]int{0: 1, 1: 3, 2: 5, 3: 8, 4: 10, 5: 19}
+
+// IsNarrowing reports whether the change of the type or of the length of the
+// column may truncate or reject the values of the database, e.g. BIGINT to
+// INT, TEXT to VARCHAR(n), DATETIME to DATE or VARCHAR(50) to VARCHAR(20).
+// The changes between the types of different families are narrowing, except
+// to a text type and from an integer type to a DECIMAL holding all its
+// values, so are the changes between unknown types. A DECIMAL is narrowed when
+// its scale or the digits before its decimal point are reduced.
+func (diff *ColumnDiff) IsNarrowing() bool {
+	if !diff.TypeChanged && !diff.LengthChanged {
+		return false
+	}
+	expected, actual := diff.Expected, diff.Actual
+	to, ok1 := typeRanks[strings.ToUpper(expected.SQLType.Name)]
+	from, ok2 := typeRanks[strings.ToUpper(actual.SQLType.Name)]
+	if diff.TypeChanged {
+		switch {
+		case !ok1 || !ok2:
+			return true
+		case from.family == "int" && to.family == "decimal":
+			return expected.Length-expected.Length2 < intDigits[from.rank]
+		case to.family != from.family:
+			return to.family != "text" || to.rank < typeRanks[core.Text].rank
+		case to.rank != from.rank:
+			return to.rank < from.rank
+		}
+	}
+	// the same type, or types of the same rank such as CHAR and VARCHAR
+	if to.family == "decimal" {
+		// a DECIMAL without precision has the database's default or none
+		return expected.Length > 0 && (actual.Length == 0 ||
+			expected.Length-expected.Length2 < actual.Length-actual.Length2) ||
+			expected.Length2 < actual.Length2
+	}
+	return expected.Length > 0 && expected.Length < actual.Length ||
+		expected.Length2 < actual.Length2
+}
+
+// Diff compares the tables mapped from beans with the database, tables of the
+// database which are not mapped by beans are ignored
+func (engine *Engine) Diff(beans ...interface{}) (*SchemaDiff, error) {
+	expected := make([]*core.Table, 0, len(beans))
+	for _, bean := range beans {
+		expected = append(expected, engine.autoMapType(rValue(bean)))
+	}
+
+	tables, err := engine.DBMetas()
+	if err != nil {
+		return nil, err
+	}
+	actual := make([]*core.Table, 0, len(expected))
+	for _, table := range tables {
+		for _, t := range expected {
+			if equalNoCase(table.Name, t.Name) {
+				actual = append(actual, table)
+				break
+			}
+		}
+	}
+
+	return DiffTables(engine.dialect, expected, actual), nil
+}
+
+// DiffTables compares the expected tables with the actual ones, the dialect
+// is the one of the actual database
+func DiffTables(dialect core.Dialect, expected, actual []*core.Table) *SchemaDiff {
+	diff := &SchemaDiff{dialect: dialect}
+
+	for _, table := range expected {
+		oriTable := findTable(actual, table.Name)
+		if oriTable == nil {
+			diff.MissingTables = append(diff.MissingTables, table)
+			continue
+		}
+		if tableDiff := diffTable(dialect, table, oriTable); !tableDiff.IsEmpty() {
+			diff.Tables = append(diff.Tables, tableDiff)
+		}
+	}
+	for _, table := range actual {
+		if findTable(expected, table.Name) == nil {
+			diff.ExtraTables = append(diff.ExtraTables, table)
+		}
+	}
+	return diff
+}
+
+func findTable(tables []*core.Table, name string) *core.Table {
+	for _, table := range tables {
+		if equalNoCase(table.Name, name) {
+			return table
+		}
+	}
+	return nil
+}
+
+func diffTable(dialect core.Dialect, table, oriTable *core.Table) *TableDiff {
+	diff := &TableDiff{
+		Name:     oriTable.Name,
+		Expected: table,
+		Actual:   oriTable,
+	}
+
+	for _, col := range table.Columns() {
+		if col.MapType == core.ONLYFROMDB {
+			continue
+		}
+		oriCol := oriTable.GetColumn(col.Name)
+		if oriCol == nil {
+			diff.MissingColumns = append(diff.MissingColumns, col)
+			continue
+		}
+		if colDiff := diffColumn(dialect, col, oriCol); colDiff != nil {
+			diff.ChangedColumns = append(diff.ChangedColumns, colDiff)
+		}
+	}
+	for _, oriCol := range oriTable.Columns() {
+		if table.GetColumn(oriCol.Name) == nil {
+			diff.ExtraColumns = append(diff.ExtraColumns, oriCol)
+		}
+	}
+
+	// match the indexes with the same name first, then the renamed ones
+	var foundIndexNames = make(map[string]bool)
+	var unmatched []*core.Index
+	for _, name := range sortedIndexNames(table.Indexes) {
+		index := table.Indexes[name]
+		var found bool
+		for _, name2 := range sortedIndexNames(oriTable.Indexes) {
+			oriIndex := oriTable.Indexes[name2]
+			if !foundIndexNames[name2] && equalNoCase(index.Name, oriIndex.Name) && equalIndex(index, oriIndex) {
+				foundIndexNames[name2] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, index)
+		}
+	}
+	for _, index := range unmatched {
+		var found bool
+		for _, name2 := range sortedIndexNames(oriTable.Indexes) {
+			oriIndex := oriTable.Indexes[name2]
+			if !foundIndexNames[name2] && equalIndex(index, oriIndex) {
+				foundIndexNames[name2] = true
+				found = true
+				diff.RenamedIndexes = append(diff.RenamedIndexes, &IndexRename{From: oriIndex, To: index})
+				break
+			}
+		}
+		if !found {
+			diff.MissingIndexes = append(diff.MissingIndexes, index)
+		}
+	}
+	for _, name2 := range sortedIndexNames(oriTable.Indexes) {
+		if !foundIndexNames[name2] {
+			diff.ExtraIndexes = append(diff.ExtraIndexes, oriTable.Indexes[name2])
+		}
+	}
+
+	diff.PrimaryKeyChanged = !equalColumnNames(table.PrimaryKeys, oriTable.PrimaryKeys)
+	return diff
+}
+
+func diffColumn(dialect core.Dialect, col, oriCol *core.Column) *ColumnDiff {
+	diff := &ColumnDiff{
+		Name:     oriCol.Name,
+		Expected: col,
+		Actual:   oriCol,
+	}
+
+	// SqlType may adjust the column, so work on copies
+	expectedCol, curCol := *col, *oriCol
+	expectedType := strings.ToUpper(dialect.SqlType(&expectedCol))
+	curType := strings.ToUpper(dialect.SqlType(&curCol))
+	if baseType(expectedType) != baseType(curType) {
+		diff.TypeChanged = true
+	} else if hasLength(baseType(expectedType)) && expectedCol.Length > 0 && curCol.Length > 0 &&
+		(expectedCol.Length != curCol.Length || expectedCol.Length2 != curCol.Length2) {
+		diff.LengthChanged = true
+	}
+
+	if !col.IsPrimaryKey && col.Nullable != oriCol.Nullable {
+		diff.NullableChanged = true
+	}
+	if !col.IsAutoIncrement && normalizeDefault(col.Default) != normalizeDefault(oriCol.Default) {
+		diff.DefaultChanged = true
+	}
+
+	if !diff.TypeChanged && !diff.LengthChanged && !diff.NullableChanged && !diff.DefaultChanged {
+		return nil
+	}
+	return diff
+}
+
+func baseType(sqlType string) string {
+	if i := strings.Index(sqlType, "("); i > 0 {
+		return strings.TrimSpace(sqlType[:i])
+	}
+	return sqlType
+}
+
+func hasLength(sqlType string) bool {
+	switch sqlType {
+	case core.Varchar, core.NVarchar, core.Char, core.Decimal, core.Numeric, core.Binary, core.VarBinary:
+		return true
+	}
+	return false
+}
+
+// normalizeDefault removes the quotes and the type casts the databases add to
+// the default values
+func normalizeDefault(def string) string {
+	def = strings.TrimSpace(def)
+	if i := strings.Index(def, "::"); i > 0 {
+		def = def[:i]
+	}
+	for len(def) >= 2 && def[0] == '(' && def[len(def)-1] == ')' {
+		def = def[1 : len(def)-1]
+	}
+	if len(def) >= 2 && def[0] == '\'' && def[len(def)-1] == '\'' {
+		def = def[1 : len(def)-1]
+	}
+	if strings.EqualFold(def, "NULL") {
+		return ""
+	}
+	return strings.ToLower(def)
+}
+
+func sortedIndexNames(indexes map[string]*core.Index) []string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalIndex(index, oriIndex *core.Index) bool {
+	return index.Type == oriIndex.Type && equalColumnNames(index.Cols, oriIndex.Cols)
+}
+
+func equalColumnNames(cols, oriCols []string) bool {
+	if len(cols) != len(oriCols) {
+		return false
+	}
+	a := make([]string, len(cols))
+	b := make([]string, len(oriCols))
+	for i := range cols {
+		a[i] = strings.ToLower(cols[i])
+		b[i] = strings.ToLower(oriCols[i])
+	}
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func columnTypeString(col *core.Column) string {
+	s := col.SQLType.Name
+	if col.Length2 > 0 {
+		s += fmt.Sprintf("(%d,%d)", col.Length, col.Length2)
+	} else if col.Length > 0 {
+		s += fmt.Sprintf("(%d)", col.Length)
+	}
+	return s
+}
+
+// SQL returns the statements which alter the actual database to the expected
+// schema. Changes the dialect cannot apply are returned as SQL comments.
+func (diff *SchemaDiff) SQL() []string {
+	dialect := diff.dialect
+	var sqls []string
+
+	for _, table := range diff.MissingTables {
+		sqls = append(sqls, dialect.CreateTableSql(table, table.Name, "", ""))
+		for _, name := range sortedIndexNames(table.Indexes) {
+			sqls = append(sqls, dialect.CreateIndexSql(table.Name, table.Indexes[name]))
+		}
+	}
+
+	for _, tableDiff := range diff.Tables {
+		sqls = append(sqls, tableDiff.SQL(dialect)...)
+	}
+
+	// the dialects write DROP TABLE with the quotes of MySQL
+	quoteFilter := &core.QuoteFilter{}
+	for _, table := range diff.ExtraTables {
+		sqls = append(sqls, quoteFilter.Do(dialect.DropTableSql(table.Name), dialect, table))
+	}
+	return sqls
+}
+
+// SQL returns the statements which alter the actual table to the expected one
+func (diff *TableDiff) SQL(dialect core.Dialect) []string {
+	var sqls []string
+	tableName := dialect.Quote(diff.Name)
+
+	for _, index := range diff.ExtraIndexes {
+		sqls = append(sqls, dialect.DropIndexSql(diff.Name, index))
+	}
+
+	for _, rename := range diff.RenamedIndexes {
+		sqls = append(sqls, renameIndexSQL(dialect, diff.Name, rename)...)
+	}
+
+	for _, c := range diff.MissingColumns {
+		col := *c
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ADD %v", tableName,
+			strings.TrimSpace(col.StringNoPk(dialect))))
+	}
+
+	for _, colDiff := range diff.ChangedColumns {
+		sqls = append(sqls, alterColumnSQL(dialect, diff.Name, colDiff)...)
+	}
+
+	if diff.PrimaryKeyChanged {
+		sqls = append(sqls, alterPrimaryKeySQL(dialect, diff)...)
+	}
+
+	for _, col := range diff.ExtraColumns {
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v", tableName, dialect.Quote(col.Name)))
+	}
+
+	for _, index := range diff.MissingIndexes {
+		sqls = append(sqls, dialect.CreateIndexSql(diff.Name, index))
+	}
+	return sqls
+}
+
+func alterColumnSQL(dialect core.Dialect, name string, diff *ColumnDiff) []string {
+	tableName := dialect.Quote(name)
+	colName := dialect.Quote(diff.Name)
+	col := *diff.Expected
+
+	switch dialect.DBType() {
+	case core.MYSQL:
+		return []string{fmt.Sprintf("ALTER TABLE %v MODIFY COLUMN %v", tableName,
+			strings.TrimSpace(col.StringNoPk(dialect)))}
+	case core.POSTGRES:
+		var sqls []string
+		if diff.TypeChanged || diff.LengthChanged {
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v", tableName, colName, dialect.SqlType(&col)))
+		}
+		if diff.NullableChanged {
+			if col.Nullable {
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v DROP NOT NULL", tableName, colName))
+			} else {
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET NOT NULL", tableName, colName))
+			}
+		}
+		if diff.DefaultChanged {
+			if col.Default == "" {
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v DROP DEFAULT", tableName, colName))
+			} else {
+				sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DEFAULT %v", tableName, colName, col.Default))
+			}
+		}
+		return sqls
+	case core.MSSQL:
+		var sqls []string
+		if diff.TypeChanged || diff.LengthChanged || diff.NullableChanged {
+			nullable := "NOT NULL"
+			if col.Nullable {
+				nullable = "NULL"
+			}
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v %v %v", tableName, colName, dialect.SqlType(&col), nullable))
+		}
+		if diff.DefaultChanged {
+			sqls = append(sqls, fmt.Sprintf("-- default of %v.%v must be changed through its constraint: %v",
+				name, diff.Name, col.Default))
+		}
+		return sqls
+	case core.ORACLE:
+		def := dialect.SqlType(&col)
+		if diff.DefaultChanged {
+			if col.Default == "" {
+				def += " DEFAULT NULL"
+			} else {
+				def += " DEFAULT " + col.Default
+			}
+		}
+		if diff.NullableChanged {
+			if col.Nullable {
+				def += " NULL"
+			} else {
+				def += " NOT NULL"
+			}
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %v MODIFY (%v %v)", tableName, colName, def)}
+	}
+	return []string{fmt.Sprintf("-- %v: column %v cannot be altered, the table has to be rebuilt", name, diff.String())}
+}
+
+func alterPrimaryKeySQL(dialect core.Dialect, diff *TableDiff) []string {
+	tableName := dialect.Quote(diff.Name)
+	pks := make([]string, len(diff.Expected.PrimaryKeys))
+	for i, pk := range diff.Expected.PrimaryKeys {
+		pks[i] = dialect.Quote(pk)
+	}
+
+	var sqls []string
+	switch dialect.DBType() {
+	case core.MYSQL:
+		if len(diff.Actual.PrimaryKeys) > 0 {
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v DROP PRIMARY KEY", tableName))
+		}
+	case core.POSTGRES:
+		if len(diff.Actual.PrimaryKeys) > 0 {
+			// the default name of PostgreSQL for the tables not read from it
+			pkName := diff.Actual.PrimaryKeyName
+			if pkName == "" {
+				pkName = diff.Actual.Name + "_pkey"
+			}
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", tableName, dialect.Quote(pkName)))
+		}
+	default:
+		return []string{fmt.Sprintf("-- %v: primary key (%v) cannot be altered, the table has to be rebuilt",
+			diff.Name, strings.Join(diff.Expected.PrimaryKeys, ", "))}
+	}
+	if len(pks) > 0 {
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ADD PRIMARY KEY (%v)", tableName, strings.Join(pks, ", ")))
+	}
+	return sqls
+}
+
+// dbIndexName returns the name of an index read from the database, the
+// dialects strip the IDX_ and UQE_ prefixes of the indexes created by xorm
+func dbIndexName(dialect core.Dialect, tableName string, index *core.Index) string {
+	if dialect.DBType() == core.MYSQL && !index.IsRegular {
+		return index.Name
+	}
+	return index.XName(tableName)
+}
+
+func renameIndexSQL(dialect core.Dialect, tableName string, rename *IndexRename) []string {
+	from := dbIndexName(dialect, tableName, rename.From)
+	to := rename.To.XName(tableName)
+	switch dialect.DBType() {
+	case core.MYSQL:
+		return []string{fmt.Sprintf("ALTER TABLE %v RENAME INDEX %v TO %v",
+			dialect.Quote(tableName), dialect.Quote(from), dialect.Quote(to))}
+	case core.POSTGRES, core.ORACLE:
+		return []string{fmt.Sprintf("ALTER INDEX %v RENAME TO %v", dialect.Quote(from), dialect.Quote(to))}
+	case core.MSSQL:
+		return []string{fmt.Sprintf("EXEC sp_rename '%v.%v', '%v', 'INDEX'", tableName, from, to)}
+	}
+	return []string{dialect.DropIndexSql(tableName, rename.From), dialect.CreateIndexSql(tableName, rename.To)}
+}
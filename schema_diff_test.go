@@ -0,0 +1,185 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+func testPkColumn(name, sqlType string) *core.Column {
+	col := testColumn(name, sqlType, 0)
+	col.IsPrimaryKey = true
+	col.Nullable = false
+	return col
+}
+
+func TestDiffTables(t *testing.T) {
+	engine := newFakeEngine(t, core.POSTGRES)
+	defer engine.Close()
+
+	expected := []*core.Table{
+		testTable("user", testPkColumn("id", core.BigInt), testColumn("name", core.Varchar, 50), testColumn("age", core.Int, 0)),
+		testTable("post", testPkColumn("id", core.BigInt)),
+		testTable("tag", testPkColumn("id", core.BigInt)),
+	}
+	actual := []*core.Table{
+		testTable("USER", testColumn("id", core.BigInt, 0), testColumn("name", core.Varchar, 20), testColumn("nick", core.Varchar, 20)),
+		testTable("tag", testPkColumn("id", core.BigInt)),
+		testTable("old", testPkColumn("id", core.BigInt)),
+	}
+	diff := DiffTables(engine.dialect, expected, actual)
+
+	names := func(tables []*core.Table) (s []string) {
+		for _, table := range tables {
+			s = append(s, table.Name)
+		}
+		return
+	}
+	if !reflect.DeepEqual(names(diff.MissingTables), []string{"post"}) ||
+		!reflect.DeepEqual(names(diff.ExtraTables), []string{"old"}) || len(diff.Tables) != 1 {
+		t.Fatalf("get %+v", diff)
+	}
+
+	// the table is named as in the database
+	tableDiff := diff.Tables[0]
+	if tableDiff.Name != "USER" || !tableDiff.PrimaryKeyChanged ||
+		len(tableDiff.MissingColumns) != 1 || tableDiff.MissingColumns[0].Name != "age" ||
+		len(tableDiff.ExtraColumns) != 1 || tableDiff.ExtraColumns[0].Name != "nick" ||
+		len(tableDiff.ChangedColumns) != 1 {
+		t.Fatalf("get %+v", tableDiff)
+	}
+	if colDiff := tableDiff.ChangedColumns[0]; colDiff.Name != "name" || !colDiff.LengthChanged || colDiff.TypeChanged {
+		t.Errorf("get %+v", colDiff)
+	}
+
+	if diff = DiffTables(engine.dialect, expected[2:], actual[1:2]); !diff.IsEmpty() {
+		t.Errorf("want no difference, get %+v", diff)
+	}
+}
+
+func TestSchemaDiffSQL(t *testing.T) {
+	pkTable := func(pks ...string) *core.Table {
+		table := testTable("user")
+		for _, name := range []string{"id", "tenant_id"} {
+			col := testColumn(name, core.BigInt, 0)
+			for _, pk := range pks {
+				if pk == name {
+					col.IsPrimaryKey = true
+					col.Nullable = false
+				}
+			}
+			table.AddColumn(col)
+		}
+		return table
+	}
+	namedPk := func(table *core.Table, name string) *core.Table {
+		table.PrimaryKeyName = name
+		return table
+	}
+
+	var cases = []struct {
+		name     string
+		dbType   core.DbType
+		expected []*core.Table
+		actual   []*core.Table
+		sqls     []string
+	}{
+		{
+			"primary key changed",
+			core.POSTGRES,
+			[]*core.Table{pkTable("id", "tenant_id")}, []*core.Table{pkTable("id")},
+			[]string{
+				`ALTER TABLE "user" DROP CONSTRAINT "user_pkey"`,
+				`ALTER TABLE "user" ADD PRIMARY KEY ("id", "tenant_id")`,
+			},
+		},
+		{
+			"primary key named in the database changed",
+			core.POSTGRES,
+			[]*core.Table{pkTable("id", "tenant_id")}, []*core.Table{namedPk(pkTable("id"), "user_id_key")},
+			[]string{
+				`ALTER TABLE "user" DROP CONSTRAINT "user_id_key"`,
+				`ALTER TABLE "user" ADD PRIMARY KEY ("id", "tenant_id")`,
+			},
+		},
+		{
+			"primary key added",
+			core.POSTGRES,
+			[]*core.Table{pkTable("id")}, []*core.Table{pkTable()},
+			[]string{
+				`ALTER TABLE "user" ADD PRIMARY KEY ("id")`,
+			},
+		},
+		{
+			"primary key changed",
+			core.MYSQL,
+			[]*core.Table{pkTable("tenant_id")}, []*core.Table{pkTable("id")},
+			[]string{
+				"ALTER TABLE `user` MODIFY COLUMN `id` BIGINT(20) NULL",
+				"ALTER TABLE `user` DROP PRIMARY KEY",
+				"ALTER TABLE `user` ADD PRIMARY KEY (`tenant_id`)",
+			},
+		},
+		{
+			"primary key cannot be changed",
+			core.SQLITE,
+			[]*core.Table{pkTable("tenant_id")}, []*core.Table{pkTable("tenant_id", "id")},
+			[]string{
+				"-- user: column id: nullable false -> true cannot be altered, the table has to be rebuilt",
+				"-- user: primary key (tenant_id) cannot be altered, the table has to be rebuilt",
+			},
+		},
+		{
+			"columns and tables",
+			core.POSTGRES,
+			[]*core.Table{
+				testTable("user", testColumn("id", core.BigInt, 0), testColumn("name", core.Varchar, 20)),
+				testTable("post", testColumn("id", core.BigInt, 0)),
+			},
+			[]*core.Table{
+				testTable("user", testColumn("id", core.BigInt, 0), testColumn("nick", core.Varchar, 20)),
+				testTable("old", testColumn("id", core.BigInt, 0)),
+			},
+			[]string{
+				`CREATE TABLE IF NOT EXISTS "post" ("id" BIGINT NULL)`,
+				`ALTER TABLE "user" ADD "name" VARCHAR(20) NULL`,
+				`ALTER TABLE "user" DROP COLUMN "nick"`,
+				`DROP TABLE IF EXISTS "old"`,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		sqls := DiffTables(engine.dialect, c.expected, c.actual).SQL()
+		for i := range sqls {
+			sqls[i] = strings.TrimSpace(sqls[i])
+		}
+		if !reflect.DeepEqual(sqls, c.sqls) {
+			t.Errorf("%s %s: want\n%s\nget\n%s", c.dbType, c.name, strings.Join(c.sqls, "\n"), strings.Join(sqls, "\n"))
+		}
+		engine.Close()
+	}
+}
+
+func TestPostgresPrimaryKeyName(t *testing.T) {
+	engine := newFakeEngine(t, core.POSTGRES)
+	defer engine.Close()
+
+	queueFakeResults("postgres/"+t.Name(), [][]driver.Value{{"tablename", "conname"}, {"user", "user_id_key"}, {"log", nil}})
+	tables, err := engine.dialect.GetTables(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 || tables[0].PrimaryKeyName != "user_id_key" || tables[1].PrimaryKeyName != "" {
+		t.Errorf("get %+v", tables)
+	}
+}
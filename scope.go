@@ -0,0 +1,236 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+// Scope is a named condition added to the queries, the updates and the
+// deletes of the tables it applies to, together with the column values set
+// on the records inserted into them. Both functions are given the context of
+// the session, see Session.Context.
+type Scope struct {
+	Name string
+
+	// Cond returns the condition on table, nil leaves the table unscoped.
+	// colName quotes a column of the table, prefixed by the table name or
+	// alias when the statement joins other tables.
+	Cond func(ctx context.Context, table *core.Table, colName func(string) string) builder.Cond
+
+	// Values returns the values of the columns set on the inserted records,
+	// they override the values of the fields.
+	Values func(ctx context.Context, table *core.Table) map[string]interface{}
+
+	types map[reflect.Type]bool // nil: all the tables
+}
+
+// ColumnScope returns a scope restricting the tables having column to the
+// value returned by value, which is also set on insert, e.g.
+//
+//	engine.AddScope(xorm.ColumnScope("tenant", "tenant_id", func(ctx context.Context) interface{} {
+//		return ctx.Value(tenantKey{})
+//	}))
+//
+// A nil value restricts the tables to the records whose column is NULL.
+func ColumnScope(name, column string, value func(ctx context.Context) interface{}) *Scope {
+	return &Scope{
+		Name: name,
+		Cond: func(ctx context.Context, table *core.Table, colName func(string) string) builder.Cond {
+			if table.GetColumn(column) == nil {
+				return nil
+			}
+			v := value(ctx)
+			if v == nil {
+				return builder.IsNull{colName(column)}
+			}
+			return builder.Eq{colName(column): v}
+		},
+		Values: func(ctx context.Context, table *core.Table) map[string]interface{} {
+			if table.GetColumn(column) == nil {
+				return nil
+			}
+			return map[string]interface{}{column: value(ctx)}
+		},
+	}
+}
+
+func (scope *Scope) appliesTo(table *core.Table) bool {
+	return scope.types == nil || scope.types[table.Type]
+}
+
+// AddScope registers a scope on the tables of beans, or on all the tables
+// when no bean is given. The scopes are applied by every session until they
+// are disabled by NoScope. It may be called while sessions are running, the
+// scope applies to their next statements.
+func (engine *Engine) AddScope(scope *Scope, beans ...interface{}) {
+	s := *scope
+	if len(beans) > 0 {
+		s.types = make(map[reflect.Type]bool, len(beans))
+		for _, bean := range beans {
+			s.types[rType(bean)] = true
+		}
+	}
+
+	// the slice is copied on write, the ones returned by registeredScopes
+	// are never modified
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	scopes := make([]*Scope, len(engine.scopes), len(engine.scopes)+1)
+	copy(scopes, engine.scopes)
+	engine.scopes = append(scopes, &s)
+}
+
+// RemoveScope unregisters the scopes named name
+func (engine *Engine) RemoveScope(name string) {
+	engine.mutex.Lock()
+	defer engine.mutex.Unlock()
+	scopes := make([]*Scope, 0, len(engine.scopes))
+	for _, scope := range engine.scopes {
+		if scope.Name != name {
+			scopes = append(scopes, scope)
+		}
+	}
+	engine.scopes = scopes
+}
+
+// registeredScopes returns the scopes of the engine, the slice must not be
+// modified
+func (engine *Engine) registeredScopes() []*Scope {
+	engine.mutex.RLock()
+	defer engine.mutex.RUnlock()
+	return engine.scopes
+}
+
+// NoScope disables the named scopes, or all of them when no name is given,
+// on the returned session
+func (engine *Engine) NoScope(names ...string) *Session {
+	session := engine.NewSession()
+	session.IsAutoClose = true
+	return session.NoScope(names...)
+}
+
+// NoScope disables the named scopes, or all of them when no name is given,
+// for the remaining statements of the session
+func (session *Session) NoScope(names ...string) *Session {
+	session.Statement.NoScope(names...)
+	return session
+}
+
+// NoScope disables the named scopes, or all of them when no name is given.
+// Unlike the other settings it isn't reset by Init, it lasts as long as the
+// session.
+func (statement *Statement) NoScope(names ...string) *Statement {
+	if len(names) == 0 {
+		statement.scopesOff = true
+		return statement
+	}
+	noScopes := make(map[string]bool, len(statement.noScopes)+len(names))
+	for name := range statement.noScopes {
+		noScopes[name] = true
+	}
+	for _, name := range names {
+		noScopes[name] = true
+	}
+	statement.noScopes = noScopes
+	return statement
+}
+
+func (statement *Statement) resetScopes() {
+	statement.ctx = context.Background()
+	statement.scopesOff = false
+	statement.noScopes = nil
+}
+
+func (statement *Statement) activeScopes(table *core.Table) []*Scope {
+	if statement.scopesOff || table == nil {
+		return nil
+	}
+	var scopes []*Scope
+	for _, scope := range statement.Engine.registeredScopes() {
+		if !statement.noScopes[scope.Name] && scope.appliesTo(table) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+func (statement *Statement) context() context.Context {
+	if statement.ctx == nil {
+		return context.Background()
+	}
+	return statement.ctx
+}
+
+// scopeCond returns the conditions of the active scopes on table, whose
+// columns are prefixed by tableName unless it's empty
+func (statement *Statement) scopeCond(table *core.Table, tableName string) builder.Cond {
+	scopes := statement.activeScopes(table)
+	if len(scopes) == 0 {
+		return nil
+	}
+	colName := func(name string) string {
+		if len(tableName) > 0 {
+			return statement.Engine.Quote(tableName) + `.` + statement.Engine.Quote(name)
+		}
+		return statement.Engine.Quote(name)
+	}
+	cond := builder.NewCond()
+	for _, scope := range scopes {
+		if scope.Cond == nil {
+			continue
+		}
+		if c := scope.Cond(statement.context(), table, colName); c != nil {
+			cond = cond.And(c)
+		}
+	}
+	return cond
+}
+
+// refScopeCond returns the conditions of the active scopes on the table of
+// the statement
+func (statement *Statement) refScopeCond() builder.Cond {
+	var tableName string
+	if statement.needTableName() {
+		tableName = statement.TableAlias
+		if len(tableName) == 0 {
+			tableName = statement.TableName()
+		}
+	}
+	return statement.scopeCond(statement.RefTable, tableName)
+}
+
+// setScopeValues sets the values of the active scopes on the record v of
+// table before it's inserted
+func (session *Session) setScopeValues(table *core.Table, v reflect.Value) error {
+	v = reflect.Indirect(v)
+	for _, scope := range session.Statement.activeScopes(table) {
+		if scope.Values == nil {
+			continue
+		}
+		for name, value := range scope.Values(session.Statement.context(), table) {
+			col := table.GetColumn(name)
+			if col == nil {
+				continue
+			}
+			fieldValue, err := col.ValueOfV(&v)
+			if err != nil {
+				return err
+			}
+			if value == nil {
+				fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				continue
+			}
+			if err = setKeyValue(*fieldValue, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+type scopeTenantKey struct{}
+
+type ScopePost struct {
+	Id       int64
+	TenantId int64
+	UserId   int64
+	Title    string
+}
+
+type ScopeUser struct {
+	Id       int64
+	TenantId *int64
+	Name     string
+}
+
+type ScopeTag struct {
+	Id   int64
+	Name string
+}
+
+type ScopePostUser struct {
+	ScopePost `xorm:"extends"`
+	ScopeUser `xorm:"extends" rel:"LEFT:u.id=ScopePost.user_id" alias:"u"`
+}
+
+func newScopeEngine(t *testing.T) *Engine {
+	engine := newFakeEngine(t, core.MYSQL)
+	engine.AddScope(ColumnScope("tenant", "tenant_id", func(ctx context.Context) interface{} {
+		return ctx.Value(scopeTenantKey{})
+	}))
+	engine.AddScope(&Scope{
+		Name: "titled",
+		Cond: func(ctx context.Context, table *core.Table, colName func(string) string) builder.Cond {
+			return builder.Neq{colName("title"): ""}
+		},
+	}, new(ScopePost))
+	return engine
+}
+
+func TestScopeCond(t *testing.T) {
+	engine := newScopeEngine(t)
+	defer engine.Close()
+	tenant := context.WithValue(context.Background(), scopeTenantKey{}, int64(1))
+
+	var cases = []struct {
+		ctx       context.Context
+		bean      interface{}
+		tableName string
+		noScopes  []string
+		sql       string
+		args      []interface{}
+	}{
+		{tenant, new(ScopePost), "", nil, "`tenant_id`=? AND `title`<>?", []interface{}{int64(1), ""}},
+		{tenant, new(ScopePost), "p", nil, "`p`.`tenant_id`=? AND `p`.`title`<>?", []interface{}{int64(1), ""}},
+		{tenant, new(ScopeUser), "", nil, "`tenant_id`=?", []interface{}{int64(1)}},
+		{context.Background(), new(ScopeUser), "", nil, "`tenant_id` IS NULL", nil},
+		{tenant, new(ScopePost), "", []string{"tenant"}, "`title`<>?", []interface{}{""}},
+		{tenant, new(ScopeTag), "", nil, "", nil},
+	}
+
+	for i, c := range cases {
+		session := engine.NewSession()
+		session.Statement.ctx = c.ctx
+		if c.noScopes != nil {
+			session.NoScope(c.noScopes...)
+		}
+		table := engine.autoMapType(reflect.ValueOf(c.bean).Elem())
+		sql, args, err := session.Statement.condToSQL(session.Statement.scopeCond(table, c.tableName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%d: want %s %v, get %s %v", i, c.sql, c.args, sql, args)
+		}
+
+		// NoScope without name disables all the scopes
+		if cond := session.NoScope().Statement.scopeCond(table, ""); cond != nil {
+			t.Errorf("%d: want no condition, get %v", i, cond)
+		}
+		session.Close()
+	}
+
+	engine.RemoveScope("titled")
+	session := engine.NewSession()
+	defer session.Close()
+	session.Statement.ctx = tenant
+	table := engine.autoMapType(reflect.ValueOf(new(ScopePost)).Elem())
+	if sql, _, _ := session.Statement.condToSQL(session.Statement.scopeCond(table, "")); sql != "`tenant_id`=?" {
+		t.Error("the removed scope is applied:", sql)
+	}
+}
+
+func TestScopeJoinAlias(t *testing.T) {
+	engine := newScopeEngine(t)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+	tenant := context.WithValue(context.Background(), scopeTenantKey{}, int64(2))
+
+	takeFakeStmts(dsn)
+	if err := engine.Context(tenant).Find(&[]ScopePostUser{}); err != nil {
+		t.Fatal(err)
+	}
+	stmts := takeFakeStmts(dsn)
+	// the columns of the scopes are prefixed by the aliases of the tables
+	want := "FROM `scope_post` AS `ScopePost` LEFT JOIN `scope_user` AS `u` ON u.id=ScopePost.user_id AND (`u`.`tenant_id`=?)" +
+		" WHERE `ScopePost`.`tenant_id`=? AND `ScopePost`.`title`<>?"
+	if len(stmts) != 1 || !strings.HasSuffix(stmts[0], want) {
+		t.Fatal("want", want, "get", stmts)
+	}
+}
+
+func TestSetScopeValues(t *testing.T) {
+	engine := newScopeEngine(t)
+	defer engine.Close()
+	session := engine.NewSession()
+	defer session.Close()
+
+	tenant := int64(3)
+	post := &ScopePost{TenantId: 9, Title: "a"}
+	user := &ScopeUser{TenantId: &tenant, Name: "b"}
+	for _, bean := range []interface{}{post, user} {
+		table := engine.autoMapType(reflect.ValueOf(bean).Elem())
+		if err := session.Context(context.WithValue(context.Background(), scopeTenantKey{}, int64(4))).
+			setScopeValues(table, reflect.ValueOf(bean)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if post.TenantId != 4 || user.TenantId == nil || *user.TenantId != 4 {
+		t.Fatal("want the tenant 4, get", post.TenantId, user.TenantId)
+	}
+
+	// a nil value sets the zero value of the field
+	session.Context(context.Background())
+	for _, bean := range []interface{}{post, user} {
+		table := engine.autoMapType(reflect.ValueOf(bean).Elem())
+		if err := session.setScopeValues(table, reflect.ValueOf(bean)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if post.TenantId != 0 || user.TenantId != nil {
+		t.Fatal("want no tenant, get", post.TenantId, user.TenantId)
+	}
+}
+
+func TestScopeConcurrentRegistration(t *testing.T) {
+	engine := newScopeEngine(t)
+	defer engine.Close()
+	table := engine.autoMapType(reflect.ValueOf(new(ScopePost)).Elem())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				engine.AddScope(&Scope{Name: "tmp"})
+				engine.RemoveScope("tmp")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			session := engine.NewSession()
+			defer session.Close()
+			for j := 0; j < 100; j++ {
+				session.Statement.activeScopes(table)
+			}
+		}()
+	}
+	wg.Wait()
+	if scopes := engine.registeredScopes(); len(scopes) != 2 {
+		t.Fatal("want 2 scopes, get", len(scopes))
+	}
+}
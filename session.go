@@ -5,6 +5,7 @@
 package xorm
 
 import (
+	"context"
 	"hash/crc32"
 	"reflect"
 
@@ -33,17 +34,32 @@ type Session struct {
 	afterDeleteBeans map[interface{}]*[]func(interface{})
 	// --
 
+	// the savepoints of the nested transactions, the innermost last
+	savePoints []savePoint
+
 	beforeClosures []func(interface{})
 	afterClosures  []func(interface{})
 
-	prepareStmt bool
-	stmtCache   map[uint32]*core.Stmt //key: hash.Hash32 of (queryStr, len(queryStr))
-	cascadeDeep int
+	prepareStmt  bool
+	stmtCache    map[stmtKey]*core.Stmt
+	forcePrimary bool
+	cascadeDeep  int
+	page         *Page
 
 	// !evalphobia! stored the last executed query on this session
 	//beforeSQLExec func(string, ...interface{})
 	lastSQL     string
 	lastSQLArgs []interface{}
+
+	ctx   context.Context
+	hooks []Hook
+}
+
+// stmtKey is the key of prepared statements, the statements prepared on the
+// primary and the replicas of an EngineGroup must not be mixed up
+type stmtKey struct {
+	db  *core.DB
+	crc uint32 // hash.Hash32 of (queryStr, len(queryStr))
 }
 
 // Clone copy all the session's content and return a new session
@@ -61,6 +77,8 @@ func (session *Session) Init() {
 	session.IsAutoClose = false
 	session.AutoResetStatement = true
 	session.prepareStmt = false
+	session.forcePrimary = false
+	session.page = nil
 
 	// !nashtsai! is lazy init better?
 	session.afterInsertBeans = make(map[interface{}]*[]func(interface{}), 0)
@@ -70,6 +88,8 @@ func (session *Session) Init() {
 	session.afterClosures = make([]func(interface{}), 0)
 
 	session.lastSQLArgs = []interface{}{}
+	session.ctx = context.Background()
+	session.Statement.resetScopes()
 }
 
 // Close release the connection from pool
@@ -80,7 +100,8 @@ func (session *Session) Close() {
 
 	if session.db != nil {
 		// When Close be called, if session is a transaction and do not call
-		// Commit or Rollback, then call Rollback.
+		// Commit or Rollback, then call Rollback on the whole transaction.
+		session.savePoints = nil
 		if session.Tx != nil && !session.IsCommitedOrRollbacked {
 			session.Rollback()
 		}
@@ -94,6 +115,7 @@ func (session *Session) Close() {
 		session.IsAutoClose = false
 		session.AutoResetStatement = true
 		session.prepareStmt = false
+		session.forcePrimary = false
 
 		// processors
 		session.afterInsertBeans = nil
@@ -101,6 +123,9 @@ func (session *Session) Close() {
 		session.afterDeleteBeans = nil
 		session.beforeClosures = nil
 		session.afterClosures = nil
+		session.ctx = context.Background()
+		session.Statement.resetScopes()
+		session.hooks = nil
 	}
 }
 
@@ -110,6 +135,15 @@ func (session *Session) resetStatement() {
 	}
 }
 
+// Context sets the context on this session, it will be passed down to every
+// query, exec and transaction started by the session so that cancellation
+// and deadlines are honored by the driver.
+func (session *Session) Context(ctx context.Context) *Session {
+	session.ctx = ctx
+	session.Statement.ctx = ctx
+	return session
+}
+
 // Before Apply before Processor, affected bean is passed to closure arg
 func (session *Session) Before(closures func(interface{})) *Session {
 	if closures != nil {
@@ -138,7 +172,8 @@ func (session *Session) Alias(alias string) *Session {
 	return session
 }
 
-// Where provides custom query condition.
+// Where provides custom query condition, query is a string with its args, a
+// builder.Cond or a *builder.Builder written in the dialect of the engine.
 func (session *Session) Where(query interface{}, args ...interface{}) *Session {
 	session.Statement.Where(query, args...)
 	return session
@@ -198,6 +233,13 @@ func (session *Session) SetExpr(column string, expression string) *Session {
 	return session
 }
 
+// SetJSON provides a query string like "column = {column with value at
+// path}" which updates a part of a JSON column, see builder.JSONSet
+func (session *Session) SetJSON(column, path string, value interface{}) *Session {
+	session.Statement.SetJSON(column, path, value)
+	return session
+}
+
 // Select provides some columns to special
 func (session *Session) Select(str string) *Session {
 	session.Statement.Select(str)
@@ -283,7 +325,7 @@ func (session *Session) Having(conditions string) *Session {
 func (session *Session) DB() *core.DB {
 	if session.db == nil {
 		session.db = session.Engine.db
-		session.stmtCache = make(map[uint32]*core.Stmt, 0)
+		session.stmtCache = make(map[stmtKey]*core.Stmt, 0)
 	}
 	return session.db
 }
@@ -299,17 +341,17 @@ func (session *Session) canCache() bool {
 	return true
 }
 
-func (session *Session) doPrepare(sqlStr string) (stmt *core.Stmt, err error) {
-	crc := crc32.ChecksumIEEE([]byte(sqlStr))
+func (session *Session) doPrepare(db *core.DB, sqlStr string) (stmt *core.Stmt, err error) {
+	key := stmtKey{db: db, crc: crc32.ChecksumIEEE([]byte(sqlStr))}
 	// TODO try hash(sqlStr+len(sqlStr))
 	var has bool
-	stmt, has = session.stmtCache[crc]
+	stmt, has = session.stmtCache[key]
 	if !has {
-		stmt, err = session.DB().Prepare(sqlStr)
+		stmt, err = db.PrepareContext(session.ctx, sqlStr)
 		if err != nil {
 			return nil, err
 		}
-		session.stmtCache[crc] = stmt
+		session.stmtCache[key] = stmt
 	}
 	return
 }
@@ -321,7 +363,7 @@ func (session *Session) Ping() error {
 		defer session.Close()
 	}
 
-	return session.DB().Ping()
+	return session.DB().PingContext(session.ctx)
 }
 
 func (session *Session) getField(dataStruct *reflect.Value, key string, table *core.Table, idx int) *reflect.Value {
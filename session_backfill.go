@@ -378,7 +378,7 @@ func (session *Session) _row2Bean(rows *core.Rows, fields []string, fieldsCount
 					if table != nil {
 						hasAssigned = true
 						if len(table.PrimaryKeys) != 1 {
-							panic("unsupported non or composited primary key cascade")
+							return errors.New("unsupported non or composited primary key cascade, use Preload with a relation tag")
 						}
 						var pk = make(core.PK, len(table.PrimaryKeys))
 
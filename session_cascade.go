@@ -0,0 +1,517 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+// CascadeWrite selects the relations, given by paths like Preload, which are
+// written with the records. Insert inserts the related records after filling
+// their foreign keys with the keys of the record, which may be autoincrement.
+// Update inserts, updates and deletes the related records to match the ones
+// of the record found in the database. Delete deletes the related records,
+// or soft deletes them when the record is soft deleted. The records of a
+// belongs_to relation are only inserted when their keys are zero, the ones of
+// a many_to_many relation are inserted likewise, then linked or unlinked.
+// All the writes run in the session's transaction, or in a transaction of
+// their own, and call the processors of each record.
+func (session *Session) CascadeWrite(paths ...string) *Session {
+	for _, path := range paths {
+		session.Statement.cascadeWrites = append(session.Statement.cascadeWrites, preloadParam{path: path})
+	}
+	return session
+}
+
+// cascadeTx runs fn in a transaction, nested in the one of the session if
+// any, committed when fn succeeds
+func (session *Session) cascadeTx(fn func() error) error {
+	if err := session.Begin(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		session.Rollback()
+		return err
+	}
+	return session.Commit()
+}
+
+// cascadeInsert inserts the beans with their relations, it returns the
+// number of the inserted beans
+func (session *Session) cascadeInsert(beans ...interface{}) (int64, error) {
+	root := newPreloadTree(session.Statement.cascadeWrites)
+	session.Statement.cascadeWrites = nil
+	defer session.resetStatement()
+
+	var affected int64
+	err := session.cascadeTx(func() error {
+		for _, bean := range beans {
+			values, err := preloadValues(reflect.ValueOf(bean))
+			if err != nil {
+				return err
+			}
+			for _, v := range values {
+				ptr := v.Addr().Interface()
+				cnt, err := session.cascadeSave(v, root, true, func() (int64, error) {
+					return session.innerInsert(ptr)
+				})
+				if err != nil {
+					return err
+				}
+				affected += cnt
+			}
+		}
+		return nil
+	})
+	return affected, err
+}
+
+// cascadeUpdate updates the bean with its relations
+func (session *Session) cascadeUpdate(bean interface{}, condiBean ...interface{}) (int64, error) {
+	root := newPreloadTree(session.Statement.cascadeWrites)
+	session.Statement.cascadeWrites = nil
+
+	v := reflect.Indirect(reflect.ValueOf(bean))
+	if v.Kind() != reflect.Struct || !v.CanAddr() {
+		return 0, errors.New("CascadeWrite needs a pointer to a struct")
+	}
+
+	var affected int64
+	err := session.cascadeTx(func() error {
+		var err error
+		affected, err = session.cascadeSave(v, root, false, func() (int64, error) {
+			return session.update(bean, condiBean...)
+		})
+		return err
+	})
+	return affected, err
+}
+
+// cascadeDelete deletes the records matching the bean with their relations
+func (session *Session) cascadeDelete(bean interface{}) (int64, error) {
+	root := newPreloadTree(session.Statement.cascadeWrites)
+	session.Statement.cascadeWrites = nil
+
+	v := reflect.Indirect(reflect.ValueOf(bean))
+	if v.Kind() != reflect.Struct {
+		return 0, errors.New("CascadeWrite needs a pointer to a struct")
+	}
+
+	var affected int64
+	err := session.cascadeTx(func() error {
+		// the records matching the conditions of the statement
+		sess := session.Clone()
+		sess.IsAutoClose = false
+		sess.Statement.setRefValue(v)
+		sess.Statement.processIdParam()
+		records := reflect.New(reflect.SliceOf(reflect.PtrTo(v.Type())))
+		if err := sess.find(records.Interface(), bean); err != nil {
+			return err
+		}
+
+		unscoped := session.Statement.unscoped
+		soft := sess.Statement.RefTable.DeletedColumn() != nil && !unscoped
+		for i := 0; i < records.Elem().Len(); i++ {
+			if err := session.cascadeDeleteRelations(records.Elem().Index(i).Elem(), root, soft, unscoped); err != nil {
+				return err
+			}
+		}
+
+		var err error
+		affected, err = session.delete(bean)
+		return err
+	})
+	return affected, err
+}
+
+// cascadeSave writes the record v by save, then the relations of the node;
+// the belongs_to records are inserted first to fill the foreign keys of v
+func (session *Session) cascadeSave(v reflect.Value, node *preloadNode, insert bool, save func() (int64, error)) (int64, error) {
+	rels := make([]*relation, len(node.children))
+	for i, child := range node.children {
+		rel, err := session.relation(v, child.name)
+		if err != nil {
+			return 0, err
+		}
+		rels[i] = rel
+		if rel.assoc.Kind == core.BelongsTo {
+			if err = session.saveBelongsTo(v, rel, child); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	affected, err := save()
+	if err != nil {
+		return affected, err
+	}
+
+	for i, child := range node.children {
+		switch rels[i].assoc.Kind {
+		case core.HasOne, core.HasMany:
+			err = session.saveHas(v, rels[i], child, insert)
+		case core.ManyToMany:
+			err = session.saveManyToMany(v, rels[i], child, insert)
+		}
+		if err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// saveBelongsTo inserts the record the belongs_to relation refers to when
+// its keys are zero, then sets the foreign keys of v
+func (session *Session) saveBelongsTo(v reflect.Value, rel *relation, node *preloadNode) error {
+	records := relationRecords(v, rel)
+	if len(records) == 0 {
+		return nil
+	}
+	record := records[0]
+	if _, pk, err := preloadKey(record, rel.relTable, rel.relTable.PrimaryKeys); err != nil {
+		return err
+	} else if pk == nil || isPKZero(core.PK(pk)) {
+		if err = session.cascadeInsertRecord(record, node); err != nil {
+			return err
+		}
+	}
+
+	_, values, err := preloadKey(record, rel.relTable, rel.keys.relCols)
+	if err != nil {
+		return err
+	}
+	return setKeyValues(v, rel.table, rel.keys.cols, values)
+}
+
+// saveHas writes the has_one or has_many records of v, the records of the
+// database which are not in v any more are deleted when v is updated
+func (session *Session) saveHas(v reflect.Value, rel *relation, node *preloadNode, insert bool) error {
+	_, key, err := preloadKey(v, rel.table, rel.keys.cols)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("the keys of the relation %s are null", rel.assoc.FieldName)
+	}
+
+	pkCols := rel.relTable.PrimaryKeys
+	var olds []reflect.Value
+	oldPKs := make(map[string]reflect.Value)
+	if !insert {
+		if olds, err = session.preloadFind(rel.relType, nil, rel.keys.relCols, [][]interface{}{key}); err != nil {
+			return err
+		}
+		for _, old := range olds {
+			pkKey, _, err := preloadKey(old, rel.relTable, pkCols)
+			if err != nil {
+				return err
+			}
+			oldPKs[pkKey] = old
+		}
+	}
+
+	for _, record := range relationRecords(v, rel) {
+		if err = setKeyValues(record, rel.relTable, rel.keys.relCols, key); err != nil {
+			return err
+		}
+		pkKey, pk, err := preloadKey(record, rel.relTable, pkCols)
+		if err != nil {
+			return err
+		}
+		old, ok := oldPKs[pkKey]
+		switch {
+		case ok && pk != nil:
+			delete(oldPKs, pkKey)
+			err = session.cascadeUpdateRecord(record, old, pk, rel.relTable, node)
+		case insert || pk == nil || isPKZero(core.PK(pk)) || rel.relTable.AutoIncrement == "":
+			err = session.cascadeInsertRecord(record, node)
+		default:
+			// a record moved from another one
+			err = session.cascadeUpdateRecord(record, reflect.Value{}, pk, rel.relTable, node)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, old := range olds {
+		pkKey, _, _ := preloadKey(old, rel.relTable, pkCols)
+		if _, ok := oldPKs[pkKey]; ok {
+			if err = session.cascadeDeleteRecord(old, rel.relTable, node, false, false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// saveManyToMany inserts the many_to_many records of v whose keys are zero,
+// then links them to v and unlinks the ones which are not in v any more
+func (session *Session) saveManyToMany(v reflect.Value, rel *relation, node *preloadNode, insert bool) error {
+	_, key, err := preloadKey(v, rel.table, rel.keys.cols)
+	if err != nil {
+		return err
+	}
+	if key == nil {
+		return fmt.Errorf("the keys of the relation %s are null", rel.assoc.FieldName)
+	}
+
+	linked := make(map[string][]interface{})
+	if !insert {
+		_, relKeys, err := session.preloadJoinTable(rel.assoc.JoinTable, rel.keys, [][]interface{}{key})
+		if err != nil {
+			return err
+		}
+		for _, relKey := range relKeys {
+			linked[joinPreloadKey(relKey)] = relKey
+		}
+	}
+
+	joinTable := session.Engine.Quote(rel.assoc.JoinTable)
+	var cols []string
+	for _, col := range rel.keys.joinCols {
+		cols = append(cols, session.Engine.Quote(col))
+	}
+	for _, col := range rel.keys.joinRelCols {
+		cols = append(cols, session.Engine.Quote(col))
+	}
+	insertSQL := "INSERT INTO " + joinTable + " (" + strings.Join(cols, ", ") + ") VALUES (" +
+		strings.Repeat("?, ", len(cols)-1) + "?)"
+
+	for _, record := range relationRecords(v, rel) {
+		relKey, pk, err := preloadKey(record, rel.relTable, rel.keys.relCols)
+		if err != nil {
+			return err
+		}
+		if pk == nil || isPKZero(core.PK(pk)) {
+			if err = session.cascadeInsertRecord(record, node); err != nil {
+				return err
+			}
+			if relKey, pk, err = preloadKey(record, rel.relTable, rel.keys.relCols); err != nil {
+				return err
+			}
+		}
+		if _, ok := linked[relKey]; ok {
+			delete(linked, relKey)
+			continue
+		}
+		if _, err = session.cascadeSession().exec(insertSQL, append(append([]interface{}{}, key...), pk...)...); err != nil {
+			return err
+		}
+	}
+
+	for _, relKey := range linked {
+		cond := builder.Eq{}
+		for i, col := range rel.keys.joinCols {
+			cond[col] = key[i]
+		}
+		for i, col := range rel.keys.joinRelCols {
+			cond[col] = relKey[i]
+		}
+		if err = session.execJoinTableDelete(joinTable, cond); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (session *Session) execJoinTableDelete(joinTable string, cond builder.Cond) error {
+	condSQL, args, err := builder.ToSQL(cond, session.Engine.QuoteKey)
+	if err != nil {
+		return err
+	}
+	_, err = session.cascadeSession().exec("DELETE FROM "+joinTable+" WHERE "+condSQL, args...)
+	return err
+}
+
+// cascadeInsertRecord inserts a related record with its relations
+func (session *Session) cascadeInsertRecord(record reflect.Value, node *preloadNode) error {
+	_, err := session.cascadeSave(record, node, true, func() (int64, error) {
+		return session.cascadeSession().Insert(record.Addr().Interface())
+	})
+	return err
+}
+
+// cascadeUpdateRecord updates a related record with its relations, the
+// record itself is not updated when its columns equal the ones of old
+func (session *Session) cascadeUpdateRecord(record, old reflect.Value, pk []interface{}, table *core.Table, node *preloadNode) error {
+	_, err := session.cascadeSave(record, node, false, func() (int64, error) {
+		if old.IsValid() && sameColumns(record, old, table) {
+			return 0, nil
+		}
+		return session.cascadeSession().ID(core.PK(pk)).Update(record.Addr().Interface())
+	})
+	return err
+}
+
+// cascadeDeleteRelations deletes the related records of v; when v is soft
+// deleted, the records which cannot be soft deleted are kept
+func (session *Session) cascadeDeleteRelations(v reflect.Value, node *preloadNode, soft, unscoped bool) error {
+	for _, child := range node.children {
+		rel, err := session.relation(v, child.name)
+		if err != nil {
+			return err
+		}
+		_, key, err := preloadKey(v, rel.table, rel.keys.cols)
+		if err != nil {
+			return err
+		}
+		if key == nil {
+			continue
+		}
+
+		switch rel.assoc.Kind {
+		case core.HasOne, core.HasMany:
+			var fn func(*Session) *Session
+			if unscoped {
+				fn = func(sess *Session) *Session {
+					return sess.Unscoped()
+				}
+			}
+			records, err := session.preloadFind(rel.relType, fn, rel.keys.relCols, [][]interface{}{key})
+			if err != nil {
+				return err
+			}
+			for _, record := range records {
+				if err = session.cascadeDeleteRecord(record, rel.relTable, child, soft, unscoped); err != nil {
+					return err
+				}
+			}
+		case core.ManyToMany:
+			if soft {
+				continue
+			}
+			cond := builder.Eq{}
+			for i, col := range rel.keys.joinCols {
+				cond[col] = key[i]
+			}
+			if err = session.execJoinTableDelete(session.Engine.Quote(rel.assoc.JoinTable), cond); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// cascadeDeleteRecord deletes a related record after its relations
+func (session *Session) cascadeDeleteRecord(record reflect.Value, table *core.Table, node *preloadNode, parentSoft, unscoped bool) error {
+	soft := table.DeletedColumn() != nil && !unscoped
+	if parentSoft && !soft {
+		return nil
+	}
+	if err := session.cascadeDeleteRelations(record, node, soft, unscoped); err != nil {
+		return err
+	}
+
+	_, pk, err := preloadKey(record, table, table.PrimaryKeys)
+	if err != nil {
+		return err
+	}
+	sess := session.cascadeSession()
+	if unscoped {
+		sess.Unscoped()
+	}
+	_, err = sess.ID(core.PK(pk)).NoAutoCondition().Delete(record.Addr().Interface())
+	return err
+}
+
+// cascadeSession returns a session running in the transaction of the
+// session, it must not be closed
+func (session *Session) cascadeSession() *Session {
+	return session.preloadSession()
+}
+
+// relationRecords returns the addressable records of the relation field of v
+func relationRecords(v reflect.Value, rel *relation) []reflect.Value {
+	fieldValue := v.FieldByIndex(rel.field.Index)
+	var records []reflect.Value
+	if rel.many {
+		for i := 0; i < fieldValue.Len(); i++ {
+			if record := reflect.Indirect(fieldValue.Index(i)); record.IsValid() {
+				records = append(records, record)
+			}
+		}
+		return records
+	}
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return nil
+		}
+		return []reflect.Value{fieldValue.Elem()}
+	}
+	if reflect.DeepEqual(fieldValue.Interface(), reflect.Zero(fieldValue.Type()).Interface()) {
+		return nil
+	}
+	return []reflect.Value{fieldValue}
+}
+
+// sameColumns reports whether the columns of the records are equal
+func sameColumns(a, b reflect.Value, table *core.Table) bool {
+	for _, col := range table.Columns() {
+		if col.IsCreated || col.IsUpdated || col.IsVersion {
+			continue
+		}
+		va, err := col.ValueOfV(&a)
+		if err != nil {
+			return false
+		}
+		vb, err := col.ValueOfV(&b)
+		if err != nil {
+			return false
+		}
+		if !reflect.DeepEqual(va.Interface(), vb.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// setKeyValues sets the key columns of v to the values
+func setKeyValues(v reflect.Value, table *core.Table, cols []string, values []interface{}) error {
+	for i, name := range cols {
+		col := table.GetColumn(name)
+		if col == nil {
+			return fmt.Errorf("column %s not found in table %s", name, table.Name)
+		}
+		fieldValue, err := col.ValueOfV(&v)
+		if err != nil {
+			return err
+		}
+		if err = setKeyValue(*fieldValue, values[i]); err != nil {
+			return fmt.Errorf("column %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func setKeyValue(fieldValue reflect.Value, value interface{}) error {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+		}
+		fieldValue = fieldValue.Elem()
+	}
+	if scanner, ok := fieldValue.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	rv := reflect.ValueOf(value)
+	if fieldValue.Kind() == reflect.String && rv.Kind() != reflect.String {
+		fieldValue.SetString(fmt.Sprint(value))
+		return nil
+	}
+	if !rv.Type().ConvertibleTo(fieldValue.Type()) {
+		return fmt.Errorf("cannot set %v to %v", rv.Type(), fieldValue.Type())
+	}
+	fieldValue.Set(rv.Convert(fieldValue.Type()))
+	return nil
+}
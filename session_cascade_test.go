@@ -0,0 +1,212 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+type CascadeUser struct {
+	Id      int64
+	Name    string
+	Profile *CascadeProfile `xorm:"has_one"`
+	Pets    []CascadePet    `xorm:"has_many"`
+	Tags    []CascadeTag    `xorm:"many_to_many(cascade_user_tag)"`
+}
+
+type CascadeProfile struct {
+	Id            int64
+	CascadeUserId int64
+}
+
+type CascadePet struct {
+	Id            int64
+	CascadeUserId int64
+	Name          string
+	Updated       time.Time    `xorm:"updated"`
+	Owner         *CascadeUser `xorm:"belongs_to(cascade_user_id)"`
+	Keeper        CascadeUser  `xorm:"belongs_to"`
+}
+
+type CascadeTag struct {
+	Id   int64
+	Name string
+}
+
+type CascadeBad struct {
+	Id     int64
+	Pet    []CascadePet `xorm:"has_one"`
+	Pets   CascadePet   `xorm:"has_many"`
+	Tags   []CascadeTag `xorm:"many_to_many"`
+	Linked []CascadeTag `xorm:"many_to_many(cascade_bad_tag,bad_id)"`
+	Owner  *CascadeUser `xorm:"belongs_to(a,b)"`
+}
+
+func TestCascadeRelationKeys(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	session := engine.NewSession()
+	defer session.Close()
+
+	var cases = []struct {
+		bean interface{}
+		name string
+		keys *preloadKeys
+	}{
+		{&CascadeUser{}, "Profile", &preloadKeys{cols: []string{"id"}, relCols: []string{"cascade_user_id"}}},
+		{&CascadeUser{}, "Pets", &preloadKeys{cols: []string{"id"}, relCols: []string{"cascade_user_id"}}},
+		{&CascadeUser{}, "Tags", &preloadKeys{
+			cols: []string{"id"}, relCols: []string{"id"},
+			joinCols: []string{"cascade_user_id"}, joinRelCols: []string{"cascade_tag_id"},
+		}},
+		{&CascadePet{}, "Owner", &preloadKeys{cols: []string{"cascade_user_id"}, relCols: []string{"id"}}},
+		{&CascadePet{}, "Keeper", &preloadKeys{cols: []string{"keeper_id"}, relCols: []string{"id"}}},
+		{&CascadeBad{}, "Pet", nil},
+		{&CascadeBad{}, "Pets", nil},
+		{&CascadeBad{}, "Tags", nil},
+		{&CascadeBad{}, "Linked", nil},
+		{&CascadeBad{}, "Owner", nil},
+		{&CascadeBad{}, "Missing", nil},
+	}
+
+	for _, c := range cases {
+		rel, err := session.relation(reflect.ValueOf(c.bean).Elem(), c.name)
+		if c.keys == nil {
+			if err == nil {
+				t.Errorf("%T.%s: want an error", c.bean, c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rel.keys, c.keys) {
+			t.Errorf("%T.%s: want %+v, get %+v", c.bean, c.name, c.keys, rel.keys)
+		}
+	}
+}
+
+func TestSameColumns(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+
+	a := CascadePet{Id: 1, CascadeUserId: 2, Name: "a", Updated: time.Now()}
+	b := a
+	b.Updated = a.Updated.Add(time.Hour)
+	b.Owner = &CascadeUser{Id: 2}
+	va, vb := reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem()
+	table := engine.autoMapType(va)
+	if !sameColumns(va, vb, table) {
+		t.Error("the updated columns and the relations must not be compared")
+	}
+	b.Name = "b"
+	if sameColumns(va, vb, table) {
+		t.Error("the records have different names")
+	}
+}
+
+func TestCascadeInsertKeepsStatement(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	users := []*CascadeUser{
+		{Id: 1, Name: "a", Pets: []CascadePet{{Id: 1, Name: "x"}}},
+		{Id: 2, Name: "b", Pets: []CascadePet{{Id: 2, Name: "y"}}},
+	}
+	if _, err := engine.Omit("name").CascadeWrite("Pets").Insert(users[0], users[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	var inserts []string
+	for _, stmt := range takeFakeStmts(dsn) {
+		if strings.HasPrefix(stmt, "INSERT INTO `cascade_user`") {
+			inserts = append(inserts, stmt)
+		}
+	}
+	want := "INSERT INTO `cascade_user` (`id`) VALUES (?)"
+	if len(inserts) != 2 || inserts[0] != want || inserts[1] != want {
+		t.Fatal("want twice", want, "get", inserts)
+	}
+	for _, user := range users {
+		if user.Pets[0].CascadeUserId != user.Id {
+			t.Error("the foreign key of the pet is not set:", user.Pets[0].CascadeUserId)
+		}
+	}
+}
+
+func TestCascadeUpdateSaveHas(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	user := &CascadeUser{Id: 1, Name: "u", Pets: []CascadePet{
+		{Id: 1, Name: "a"},  // unchanged
+		{Id: 2, Name: "b2"}, // changed
+		{Name: "new"},       // inserted
+		{Id: 7, Name: "m"},  // moved from another user
+	}}
+	// the pets of the user in the database, the pet 3 is deleted
+	queueFakeResults(dsn, [][]driver.Value{
+		{"id", "cascade_user_id", "name"},
+		{int64(1), int64(1), "a"},
+		{int64(2), int64(1), "b"},
+		{int64(3), int64(1), "c"},
+	})
+	takeFakeStmts(dsn)
+	if _, err := engine.CascadeWrite("Pets").ID(1).Update(user); err != nil {
+		t.Fatal(err)
+	}
+
+	var writes []string
+	for _, stmt := range takeFakeStmts(dsn) {
+		if !strings.HasPrefix(stmt, "SELECT") {
+			writes = append(writes, stmt)
+		}
+	}
+	want := []string{
+		"UPDATE `cascade_user` SET `name` = ? WHERE `id`=?",
+		"UPDATE `cascade_pet` SET `cascade_user_id` = ?, `name` = ?, `updated` = ? WHERE `id`=?",
+		"INSERT INTO `cascade_pet` (`cascade_user_id`,`name`,`updated`) VALUES (?, ?, ?)",
+		"UPDATE `cascade_pet` SET `cascade_user_id` = ?, `name` = ?, `updated` = ? WHERE `id`=?",
+		"DELETE FROM `cascade_pet` WHERE `id`=?",
+	}
+	if !reflect.DeepEqual(writes, want) {
+		t.Fatalf("want\n%s\nget\n%s", strings.Join(want, "\n"), strings.Join(writes, "\n"))
+	}
+	for _, pet := range user.Pets {
+		if pet.CascadeUserId != 1 {
+			t.Error("the foreign key of the pet is not set:", pet)
+		}
+	}
+}
+
+func TestCascadeInsertSaveHas(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	// every record is inserted, whatever its key, and nothing is read
+	user := &CascadeUser{Id: 1, Name: "u", Pets: []CascadePet{{Id: 5, Name: "a"}, {Name: "b"}}}
+	takeFakeStmts(dsn)
+	if _, err := engine.CascadeWrite("Pets").Insert(user); err != nil {
+		t.Fatal(err)
+	}
+	stmts := takeFakeStmts(dsn)
+	if len(stmts) != 3 {
+		t.Fatal("want 3 inserts, get", stmts)
+	}
+	for _, stmt := range stmts {
+		if !strings.HasPrefix(stmt, "INSERT") {
+			t.Error("want an insert, get", stmt)
+		}
+	}
+}
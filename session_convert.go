@@ -113,6 +113,9 @@ func (session *Session) bytes2Value(col *core.Column, fieldValue *reflect.Value,
 		v = data
 		t := fieldType.Elem()
 		k := t.Kind()
+		if col.IsArray && k != reflect.Uint8 {
+			return session.array2Value(col, fieldValue, data)
+		}
 		if col.SQLType.IsText() {
 			x := reflect.New(fieldType)
 			if len(data) > 0 {
@@ -534,6 +537,42 @@ func (session *Session) bytes2Value(col *core.Column, fieldValue *reflect.Value,
 	return nil
 }
 
+// array2Value sets the elements of the PostgreSQL array literal data to the
+// slice or the array fieldValue
+func (session *Session) array2Value(col *core.Column, fieldValue *reflect.Value, data []byte) error {
+	elems, err := core.ParseArrayLiteral(string(data))
+	if err != nil {
+		return err
+	}
+
+	fieldType := fieldValue.Type()
+	x := *fieldValue
+	if fieldType.Kind() == reflect.Slice {
+		x = reflect.MakeSlice(fieldType, len(elems), len(elems))
+	} else if len(elems) > fieldType.Len() {
+		return fmt.Errorf("%d elements overflow %v", len(elems), fieldType)
+	} else {
+		fieldValue.Set(reflect.Zero(fieldType))
+	}
+
+	elemCol := *col
+	elemCol.IsArray = false
+	for i, elem := range elems {
+		// the NULL elements are left zero
+		if elem == nil {
+			continue
+		}
+		elemValue := x.Index(i)
+		if err = session.bytes2Value(&elemCol, &elemValue, []byte(*elem)); err != nil {
+			return err
+		}
+	}
+	if fieldType.Kind() == reflect.Slice {
+		fieldValue.Set(x)
+	}
+	return nil
+}
+
 // convert a field value of a struct to interface for put into db
 func (session *Session) value2Interface(col *core.Column, fieldValue reflect.Value) (interface{}, error) {
 	if fieldValue.CanAddr() {
@@ -629,6 +668,13 @@ func (session *Session) value2Interface(col *core.Column, fieldValue reflect.Val
 			return fieldValue.Interface(), nil
 		}
 
+		if col.IsArray {
+			if k == reflect.Slice && fieldValue.IsNil() {
+				return nil, nil
+			}
+			return core.ArrayLiteral(fieldValue)
+		}
+
 		if col.SQLType.IsText() {
 			bytes, err := json.Marshal(fieldValue.Interface())
 			if err != nil {
@@ -1,5 +1,9 @@
 package xorm
 
+import (
+	"github.com/coscms/xorm/core"
+)
+
 // Count counts the records. bean's non-empty fields
 // are conditions.
 func (session *Session) Count(bean interface{}) (int64, error) {
@@ -21,11 +25,9 @@ func (session *Session) Count(bean interface{}) (int64, error) {
 
 	var err error
 	var total int64
-	if session.IsAutoCommit {
-		err = session.DB().QueryRow(sqlStr, args...).Scan(&total)
-	} else {
-		err = session.Tx.QueryRow(sqlStr, args...).Scan(&total)
-	}
+	err = session.queryRowScan(sqlStr, args, func(row *core.Row) error {
+		return row.Scan(&total)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -53,11 +55,9 @@ func (session *Session) Sum(bean interface{}, columnName string) (float64, error
 
 	var err error
 	var res float64
-	if session.IsAutoCommit {
-		err = session.DB().QueryRow(sqlStr, args...).Scan(&res)
-	} else {
-		err = session.Tx.QueryRow(sqlStr, args...).Scan(&res)
-	}
+	err = session.queryRowScan(sqlStr, args, func(row *core.Row) error {
+		return row.Scan(&res)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -85,11 +85,9 @@ func (session *Session) Sums(bean interface{}, columnNames ...string) ([]float64
 
 	var err error
 	var res = make([]float64, len(columnNames), len(columnNames))
-	if session.IsAutoCommit {
-		err = session.DB().QueryRow(sqlStr, args...).ScanSlice(&res)
-	} else {
-		err = session.Tx.QueryRow(sqlStr, args...).ScanSlice(&res)
-	}
+	err = session.queryRowScan(sqlStr, args, func(row *core.Row) error {
+		return row.ScanSlice(&res)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -117,11 +115,9 @@ func (session *Session) SumsInt(bean interface{}, columnNames ...string) ([]int6
 
 	var err error
 	var res = make([]int64, 0, len(columnNames))
-	if session.IsAutoCommit {
-		err = session.DB().QueryRow(sqlStr, args...).ScanSlice(&res)
-	} else {
-		err = session.Tx.QueryRow(sqlStr, args...).ScanSlice(&res)
-	}
+	err = session.queryRowScan(sqlStr, args, func(row *core.Row) error {
+		return row.ScanSlice(&res)
+	})
 	if err != nil {
 		return nil, err
 	}
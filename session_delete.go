@@ -3,8 +3,12 @@ package xorm
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/coscms/xorm/builder"
 	"github.com/coscms/xorm/core"
 )
 
@@ -79,6 +83,13 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 		defer session.Close()
 	}
 
+	if len(session.Statement.cascadeWrites) > 0 {
+		return session.cascadeDelete(bean)
+	}
+	return session.delete(bean)
+}
+
+func (session *Session) delete(bean interface{}) (int64, error) {
 	session.Statement.setRefValue(rValue(bean))
 	var table = session.Statement.RefTable
 
@@ -93,6 +104,22 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 	}
 
 	// --
+	// the version of the bean, when it's set, must still be the one of the
+	// record, it's a condition of the bean unless NoAutoCondition is used
+	var verCondValue interface{}
+	if table.Version != "" && session.Statement.checkVersion {
+		verValue, err := table.VersionColumn().ValueOf(bean)
+		if err != nil {
+			return 0, err
+		}
+		if !isZero(verValue.Interface()) {
+			verCondValue = session.versionCondValue(table.VersionColumn(), *verValue)
+			if session.Statement.noAutoCondition {
+				session.Statement.cond = session.Statement.cond.And(builder.Eq{session.Engine.Quote(table.Version): verCondValue})
+			}
+		}
+	}
+
 	condSQL, condArgs, _ := session.Statement.genConds(bean)
 	if len(condSQL) == 0 && session.Statement.LimitN == 0 {
 		return 0, ErrNeedDeletedCond
@@ -202,6 +229,14 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 	if err != nil {
 		return 0, err
 	}
+	if verCondValue != nil {
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			if err = session.optimisticLockError(table, bean, verCondValue); err != nil {
+				cleanupProcessorsClosures(&session.afterClosures)
+				return 0, err
+			}
+		}
+	}
 
 	// handle after delete processors
 	if session.IsAutoCommit {
@@ -232,3 +267,124 @@ func (session *Session) Delete(bean interface{}) (int64, error) {
 
 	return res.RowsAffected()
 }
+
+// ForceDelete deletes the records for good, even when the table has a deleted
+// column; the soft deleted records are deleted too, or only them after
+// OnlyDeleted
+func (session *Session) ForceDelete(bean interface{}) (int64, error) {
+	session.Statement.Unscoped()
+	return session.Delete(bean)
+}
+
+// Restore clears the deleted column of the soft deleted records, bean's
+// non-empty fields are conditions. The updated column is set and the version
+// is moved on like by Update, the version of the bean must still be the one
+// of the record or an OptimisticLockError is returned.
+func (session *Session) Restore(bean interface{}) (int64, error) {
+	defer session.resetStatement()
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+
+	session.Statement.setRefValue(rValue(bean))
+	table := session.Statement.RefTable
+	deletedColumn := table.DeletedColumn()
+	if deletedColumn == nil {
+		return 0, ErrNoDeletedColumn
+	}
+
+	// the version of the bean, when it's set, must still be the one of the
+	// record, as for Delete
+	var verValue *reflect.Value
+	var verCondValue interface{}
+	if table.Version != "" && session.Statement.checkVersion {
+		var err error
+		verValue, err = table.VersionColumn().ValueOf(bean)
+		if err != nil {
+			return 0, err
+		}
+		if !isZero(verValue.Interface()) {
+			verCondValue = session.versionCondValue(table.VersionColumn(), *verValue)
+			if session.Statement.noAutoCondition {
+				session.Statement.cond = session.Statement.cond.And(builder.Eq{session.Engine.Quote(table.Version): verCondValue})
+			}
+		}
+	}
+
+	session.Statement.OnlyDeleted()
+	if session.Statement.noAutoCondition {
+		// the deleted records are restored only, even without the
+		// conditions of the bean
+		session.Statement.cond = session.Statement.cond.And(session.Statement.deletedCond(session.Statement.colName(deletedColumn, false)))
+	}
+	condSQL, condArgs, err := session.Statement.genConds(bean)
+	if err != nil {
+		return 0, err
+	}
+
+	var zeroTime interface{}
+	if !deletedColumn.Nullable {
+		zeroTime = "0001-01-01 00:00:00"
+	}
+	colNames := []string{session.Engine.Quote(deletedColumn.Name) + " = ?"}
+	args := []interface{}{zeroTime}
+
+	var afterClosures []func(interface{})
+	afterClosures = append(afterClosures, func(bean interface{}) {
+		setColumnTime(bean, deletedColumn, time.Time{})
+	})
+	if session.Statement.UseAutoTime && table.Updated != "" {
+		col := table.UpdatedColumn()
+		val, t := session.Engine.NowTime2(col.SQLType.Name)
+		colNames = append(colNames, session.Engine.Quote(col.Name)+" = ?")
+		args = append(args, val)
+		afterClosures = append(afterClosures, func(bean interface{}) {
+			setColumnTime(bean, col, t)
+		})
+	}
+	if verValue != nil {
+		col := table.VersionColumn()
+		if col.SQLType.IsTime() {
+			val, t := session.nextTimeVersion(col, *verValue)
+			colNames = append(colNames, session.Engine.Quote(col.Name)+" = ?")
+			args = append(args, val)
+			if !isZero(verValue.Interface()) {
+				afterClosures = append(afterClosures, func(bean interface{}) {
+					setColumnTime(bean, col, t)
+				})
+			}
+		} else {
+			colNames = append(colNames, session.Engine.Quote(col.Name)+" = "+session.Engine.Quote(col.Name)+" + 1")
+			if !isZero(verValue.Interface()) {
+				afterClosures = append(afterClosures, func(bean interface{}) {
+					setColumnInt(bean, col, verValue.Int()+1)
+				})
+			}
+		}
+	}
+
+	sqlStr := fmt.Sprintf("UPDATE %v SET %v WHERE %v",
+		session.Engine.Quote(session.Statement.TableName()),
+		strings.Join(colNames, ", "),
+		condSQL)
+	res, err := session.exec(sqlStr, append(args, condArgs...)...)
+	if err != nil {
+		return 0, err
+	}
+	if verCondValue != nil {
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			if err = session.optimisticLockError(table, bean, verCondValue); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if cacher := session.Engine.getCacher2(table); cacher != nil && session.Statement.UseCache {
+		cacher.ClearIds(session.Statement.TableName())
+		cacher.ClearBeans(session.Statement.TableName())
+	}
+	for _, closure := range afterClosures {
+		closure(bean)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,123 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+type SoftPost struct {
+	Id      int64
+	UserId  int64
+	Title   string
+	Deleted time.Time `xorm:"deleted"`
+}
+
+type SoftUser struct {
+	Id      int64
+	Name    string
+	Updated time.Time `xorm:"updated"`
+	Deleted time.Time `xorm:"deleted"`
+	Version int       `xorm:"version"`
+}
+
+type SoftPostUser struct {
+	SoftPost `xorm:"extends"`
+	SoftUser `xorm:"extends" rel:"LEFT:u.id=SoftPost.user_id" alias:"u"`
+}
+
+func TestSoftDeleteSQL(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	var cases = []struct {
+		name string
+		run  func() error
+		sql  string
+	}{
+		{"find", func() error {
+			var posts []SoftPostUser
+			return engine.Find(&posts)
+		}, "SELECT `SoftPost`.`id`, `SoftPost`.`user_id`, `SoftPost`.`title`, `SoftPost`.`deleted`, `u`.`id`, `u`.`name`, `u`.`updated`, `u`.`deleted`, `u`.`version` " +
+			"FROM `soft_post` AS `SoftPost` LEFT JOIN `soft_user` AS `u` ON u.id=SoftPost.user_id AND (`u`.`deleted` IS NULL OR `u`.`deleted` = '0001-01-01 00:00:00') " +
+			"WHERE (`SoftPost`.`deleted` IS NULL OR `SoftPost`.`deleted`=?)"},
+		// the joined users are still the ones which are not deleted
+		{"only deleted", func() error {
+			var posts []SoftPostUser
+			return engine.OnlyDeleted().Find(&posts)
+		}, "SELECT `SoftPost`.`id`, `SoftPost`.`user_id`, `SoftPost`.`title`, `SoftPost`.`deleted`, `u`.`id`, `u`.`name`, `u`.`updated`, `u`.`deleted`, `u`.`version` " +
+			"FROM `soft_post` AS `SoftPost` LEFT JOIN `soft_user` AS `u` ON u.id=SoftPost.user_id AND (`u`.`deleted` IS NULL OR `u`.`deleted` = '0001-01-01 00:00:00') " +
+			"WHERE `SoftPost`.`deleted` IS NOT NULL AND `SoftPost`.`deleted`<>?"},
+		{"unscoped", func() error {
+			var posts []SoftPostUser
+			return engine.Unscoped().Find(&posts)
+		}, "SELECT `SoftPost`.`id`, `SoftPost`.`user_id`, `SoftPost`.`title`, `SoftPost`.`deleted`, `u`.`id`, `u`.`name`, `u`.`updated`, `u`.`deleted`, `u`.`version` " +
+			"FROM `soft_post` AS `SoftPost` LEFT JOIN `soft_user` AS `u` ON u.id=SoftPost.user_id"},
+		{"force delete", func() error {
+			_, err := engine.ForceDelete(&SoftPost{Id: 1})
+			return err
+		}, "DELETE FROM `soft_post` WHERE `id`=?"},
+		{"force delete of the deleted", func() error {
+			_, err := engine.OnlyDeleted().ForceDelete(&SoftPost{UserId: 1})
+			return err
+		}, "DELETE FROM `soft_post` WHERE `user_id`=? AND `deleted` IS NOT NULL AND `deleted`<>?"},
+		{"restore", func() error {
+			_, err := engine.Restore(&SoftPost{Id: 1})
+			return err
+		}, "UPDATE `soft_post` SET `deleted` = ? WHERE `id`=? AND `deleted` IS NOT NULL AND `deleted`<>?"},
+	}
+
+	for _, c := range cases {
+		if err := c.run(); err != nil {
+			t.Fatal(c.name, err)
+		}
+		if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != c.sql {
+			t.Errorf("%s: want\n%s\nget\n%v", c.name, c.sql, stmts)
+		}
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	user := &SoftUser{Id: 1, Version: 3}
+	queueFakeAffected(dsn, 1)
+	if _, err := engine.Restore(user); err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE `soft_user` SET `deleted` = ?, `updated` = ?, `version` = `version` + 1 " +
+		"WHERE `id`=? AND `deleted` IS NOT NULL AND `deleted`<>? AND `version`=?"
+	if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != want {
+		t.Errorf("want\n%s\nget\n%v", want, stmts)
+	}
+	if user.Version != 4 || user.Updated.IsZero() {
+		t.Errorf("get %+v", user)
+	}
+
+	// the record was changed since its version was read
+	user = &SoftUser{Id: 1, Version: 3}
+	queueFakeAffected(dsn, 0)
+	_, err := engine.NoAutoCondition().ID(1).Restore(user)
+	if lockErr, ok := err.(*OptimisticLockError); !ok || lockErr.Version != 3 || user.Version != 3 {
+		t.Errorf("want an OptimisticLockError, get %v %+v", err, user)
+	}
+	want = "UPDATE `soft_user` SET `deleted` = ?, `updated` = ?, `version` = `version` + 1 " +
+		"WHERE `version`=? AND `deleted` IS NOT NULL AND `deleted`<>? AND `id`=?"
+	if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != want {
+		t.Errorf("want\n%s\nget\n%v", want, stmts)
+	}
+
+	// no record may be deleted without a version to check
+	queueFakeAffected(dsn, 0)
+	if n, err := engine.Restore(&SoftUser{Name: "a"}); err != nil || n != 0 {
+		t.Errorf("get %d %v", n, err)
+	}
+}
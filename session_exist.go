@@ -0,0 +1,128 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"github.com/coscms/xorm/core"
+)
+
+// Exist reports whether any record matches the conditions without loading
+// it. The bean may be a struct, whose non-empty fields are conditions too, or
+// a table name; without it the table of Table or the query of SQL is used.
+func (session *Session) Exist(bean ...interface{}) (bool, error) {
+	defer session.resetStatement()
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+
+	var sqlStr string
+	var args []interface{}
+	var err error
+	if session.Statement.RawSQL == "" {
+		sqlStr, args, err = session.Statement.genExistSQL(bean...)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		sqlStr = session.Statement.RawSQL
+		args = session.Statement.RawParams
+	}
+
+	session.queryPreprocess(&sqlStr, args...)
+
+	var rawRows *core.Rows
+	if session.IsAutoCommit {
+		_, rawRows, err = session.innerQuery(session.queryDB(), sqlStr, args...)
+	} else {
+		rawRows, err = session.txQueryRows(session.Tx, sqlStr, args...)
+	}
+	if err != nil {
+		return false, err
+	}
+	defer rawRows.Close()
+
+	if rawRows.Next() {
+		return true, nil
+	}
+	return false, rawRows.Err()
+}
+
+// genExistSQL generates the query of one constant row among the matching
+// records: SELECT 1 ... LIMIT 1, TOP 1 on MSSQL and ROWNUM on Oracle
+func (statement *Statement) genExistSQL(bean ...interface{}) (string, []interface{}, error) {
+	var condSQL string
+	var condArgs []interface{}
+	var err error
+	var isStruct bool
+	if len(bean) > 0 {
+		if tableName, ok := bean[0].(string); ok {
+			statement.AltTableName = tableName
+		} else {
+			isStruct = true
+			statement.setRefValue(rValue(bean[0]))
+		}
+	}
+	if len(statement.TableName()) == 0 {
+		return "", nil, ErrTableNotFound
+	}
+	if isStruct {
+		condSQL, condArgs, err = statement.genConds(bean[0])
+	} else {
+		// the table given by Table(bean) still honors its deleted column
+		if statement.RefTable != nil {
+			if col := statement.RefTable.DeletedColumn(); col != nil {
+				statement.cond = statement.cond.And(statement.deletedCond(statement.colName(col, len(statement.JoinStr()) > 0)))
+			}
+		}
+		statement.cond = statement.cond.And(statement.refScopeCond())
+		statement.processIdParam()
+		condSQL, condArgs, err = statement.condToSQL(statement.cond)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	dialect := statement.Engine.Dialect()
+	quote := statement.Engine.Quote
+	fromStr := " FROM " + quote(statement.TableName())
+	if statement.TableAlias != "" {
+		if dialect.DBType() == core.ORACLE {
+			fromStr += " " + quote(statement.TableAlias)
+		} else {
+			fromStr += " AS " + quote(statement.TableAlias)
+		}
+	}
+	if statement.JoinStr() != "" {
+		fromStr += " " + statement.JoinStr()
+	}
+
+	var whereStr string
+	if dialect.DBType() == core.ORACLE {
+		if condSQL != "" {
+			whereStr = " WHERE (" + condSQL + ") AND ROWNUM = 1"
+		} else {
+			whereStr = " WHERE ROWNUM = 1"
+		}
+	} else if condSQL != "" {
+		whereStr = " WHERE " + condSQL
+	}
+
+	var sqlStr string
+	if dialect.DBType() == core.MSSQL {
+		sqlStr = "SELECT TOP 1 1" + fromStr + whereStr
+	} else {
+		sqlStr = "SELECT 1" + fromStr + whereStr
+	}
+	if statement.GroupByStr != "" {
+		sqlStr += " GROUP BY " + statement.GroupByStr
+	}
+	if statement.HavingStr != "" {
+		sqlStr += " " + statement.HavingStr
+	}
+	if dialect.DBType() != core.MSSQL && dialect.DBType() != core.ORACLE {
+		sqlStr += " LIMIT 1"
+	}
+	return sqlStr, append(statement.joinArgs, condArgs...), nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+type ExistUser struct {
+	Id      int64
+	Name    string
+	Deleted time.Time `xorm:"deleted"`
+}
+
+func TestExistSQL(t *testing.T) {
+	var cases = []struct {
+		dbType core.DbType
+		exist  func(*Session) (bool, error)
+		sql    string
+	}{
+		{core.MYSQL, func(s *Session) (bool, error) { return s.Exist(&ExistUser{Name: "a"}) },
+			"SELECT 1 FROM `exist_user` WHERE `name`=? AND (`deleted` IS NULL OR `deleted`=?) LIMIT 1"},
+		{core.POSTGRES, func(s *Session) (bool, error) { return s.Where("id > ?", 1).Exist("exist_user") },
+			`SELECT 1 FROM "exist_user" WHERE id > $1 LIMIT 1`},
+		// the deleted column of the table given by Table is honored
+		{core.SQLITE, func(s *Session) (bool, error) { return s.Table(new(ExistUser)).Exist() },
+			"SELECT 1 FROM `exist_user` WHERE (`deleted` IS NULL OR `deleted`=?) LIMIT 1"},
+		{core.MSSQL, func(s *Session) (bool, error) { return s.Exist(&ExistUser{Name: "a"}) },
+			`SELECT TOP 1 1 FROM "exist_user" WHERE "name"=? AND ("deleted" IS NULL OR "deleted"=?)`},
+		{core.ORACLE, func(s *Session) (bool, error) { return s.Exist(&ExistUser{Name: "a"}) },
+			`SELECT 1 FROM "exist_user" WHERE ("name"=:1 AND ("deleted" IS NULL OR "deleted"=:2)) AND ROWNUM = 1`},
+		{core.ORACLE, func(s *Session) (bool, error) { return s.Table("exist_user").Exist() },
+			`SELECT 1 FROM "exist_user" WHERE ROWNUM = 1`},
+		// a raw query is run as is
+		{core.MYSQL, func(s *Session) (bool, error) {
+			return s.SQL("SELECT id FROM exist_user WHERE name = ?", "a").Exist()
+		}, "SELECT id FROM exist_user WHERE name = ?"},
+	}
+
+	for i, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		dsn := string(c.dbType) + "/" + t.Name()
+		takeFakeStmts(dsn)
+		session := engine.NewSession()
+		if has, err := c.exist(session); err != nil || !has {
+			t.Fatal(i, has, err)
+		}
+		if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != c.sql {
+			t.Errorf("%d %s: want\n%s\nget\n%v", i, c.dbType, c.sql, stmts)
+		}
+		session.Close()
+		engine.Close()
+	}
+}
+
+func TestExistNoRows(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+
+	queueFakeResults("mysql/"+t.Name(), [][]driver.Value{{"1"}})
+	if has, err := engine.Exist(&ExistUser{Name: "a"}); err != nil || has {
+		t.Errorf("want false, get %v %v", has, err)
+	}
+}
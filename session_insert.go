@@ -19,6 +19,10 @@ func (session *Session) Insert(beans ...interface{}) (int64, error) {
 		defer session.Close()
 	}
 
+	if len(session.Statement.cascadeWrites) > 0 {
+		return session.cascadeInsert(beans...)
+	}
+
 	for _, bean := range beans {
 		sliceValue := reflect.Indirect(reflect.ValueOf(bean))
 		if sliceValue.Kind() == reflect.Slice {
@@ -95,6 +99,9 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 			processor.BeforeInsert()
 		}
 		// --
+		if err := session.setScopeValues(table, vv); err != nil {
+			return 0, err
+		}
 
 		if i == 0 {
 			for _, col := range table.Columns() {
@@ -124,7 +131,7 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 						continue
 					}
 				}
-				if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime {
+				if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime || isTimeVersion(col, session) {
 					val, t := session.Engine.NowTime2(col.SQLType.Name)
 					args = append(args, val)
 
@@ -181,7 +188,7 @@ func (session *Session) innerInsertMulti(rowsSlicePtr interface{}) (int64, error
 						continue
 					}
 				}
-				if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime {
+				if (col.IsCreated || col.IsUpdated) && session.Statement.UseAutoTime || isTimeVersion(col, session) {
 					val, t := session.Engine.NowTime2(col.SQLType.Name)
 					args = append(args, val)
 
@@ -300,6 +307,9 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 		processor.BeforeInsert()
 	}
 	// --
+	if err := session.setScopeValues(table, rValue(bean)); err != nil {
+		return 0, err
+	}
 	colNames, args, err := genCols(session.Statement.RefTable, session, bean, false, false)
 	if err != nil {
 		return 0, err
@@ -335,33 +345,7 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 		session.Engine.QuoteStr(),
 		colPlaces)
 
-	handleAfterInsertProcessorFunc := func(bean interface{}) {
-		if session.IsAutoCommit {
-			for _, closure := range session.afterClosures {
-				closure(bean)
-			}
-			if processor, ok := interface{}(bean).(AfterInsertProcessor); ok {
-				processor.AfterInsert()
-			}
-		} else {
-			lenAfterClosures := len(session.afterClosures)
-			if lenAfterClosures > 0 {
-				if value, has := session.afterInsertBeans[bean]; has && value != nil {
-					*value = append(*value, session.afterClosures...)
-				} else {
-					afterClosures := make([]func(interface{}), lenAfterClosures)
-					copy(afterClosures, session.afterClosures)
-					session.afterInsertBeans[bean] = &afterClosures
-				}
-
-			} else {
-				if _, ok := interface{}(bean).(AfterInsertProcessor); ok {
-					session.afterInsertBeans[bean] = nil
-				}
-			}
-		}
-		cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
-	}
+	handleAfterInsertProcessorFunc := session.handleAfterInsertProcessor
 
 	// for postgres, many of them didn't implement lastInsertId, so we should
 	// implemented it ourself.
@@ -382,7 +366,7 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 			verValue, err := table.VersionColumn().ValueOf(bean)
 			if err != nil {
 				session.Engine.logger.Error(err)
-			} else if verValue.IsValid() && verValue.CanSet() {
+			} else if verValue.IsValid() && verValue.CanSet() && !isTimeVersion(table.VersionColumn(), session) {
 				verValue.SetInt(1)
 			}
 		}
@@ -427,7 +411,7 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 			verValue, err := table.VersionColumn().ValueOf(bean)
 			if err != nil {
 				session.Engine.logger.Error(err)
-			} else if verValue.IsValid() && verValue.CanSet() {
+			} else if verValue.IsValid() && verValue.CanSet() && !isTimeVersion(table.VersionColumn(), session) {
 				verValue.SetInt(1)
 			}
 		}
@@ -470,7 +454,7 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 			verValue, err := table.VersionColumn().ValueOf(bean)
 			if err != nil {
 				session.Engine.logger.Error(err)
-			} else if verValue.IsValid() && verValue.CanSet() {
+			} else if verValue.IsValid() && verValue.CanSet() && !isTimeVersion(table.VersionColumn(), session) {
 				verValue.SetInt(1)
 			}
 		}
@@ -500,6 +484,34 @@ func (session *Session) innerInsert(bean interface{}) (int64, error) {
 	}
 }
 
+func (session *Session) handleAfterInsertProcessor(bean interface{}) {
+	if session.IsAutoCommit {
+		for _, closure := range session.afterClosures {
+			closure(bean)
+		}
+		if processor, ok := interface{}(bean).(AfterInsertProcessor); ok {
+			processor.AfterInsert()
+		}
+	} else {
+		lenAfterClosures := len(session.afterClosures)
+		if lenAfterClosures > 0 {
+			if value, has := session.afterInsertBeans[bean]; has && value != nil {
+				*value = append(*value, session.afterClosures...)
+			} else {
+				afterClosures := make([]func(interface{}), lenAfterClosures)
+				copy(afterClosures, session.afterClosures)
+				session.afterInsertBeans[bean] = &afterClosures
+			}
+
+		} else {
+			if _, ok := interface{}(bean).(AfterInsertProcessor); ok {
+				session.afterInsertBeans[bean] = nil
+			}
+		}
+	}
+	cleanupProcessorsClosures(&session.afterClosures) // cleanup after used
+}
+
 // InsertOne insert only one struct into database as a record.
 // The in parameter bean must a struct or a point to struct. The return
 // parameter is inserted and error
@@ -0,0 +1,402 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+// Page holds the cursors of the pages around the one fetched by Find with
+// Paginate, a cursor is empty when there is no such page
+type Page struct {
+	Next string
+	Prev string
+}
+
+type paginateParam struct {
+	cursor   string
+	pageSize int
+}
+
+// pageOrder is one of the ordering columns of a paginated query
+type pageOrder struct {
+	key  string // column name, qualified by a table name or alias if needed
+	desc bool
+	col  *core.Column // column of the fetched struct holding the value
+}
+
+// pageCursor is the content of the opaque cursors
+type pageCursor struct {
+	Prev   bool          `json:"p,omitempty"`
+	Values []interface{} `json:"v"`
+}
+
+// pageState is the pagination of one query
+type pageState struct {
+	orders   []*pageOrder
+	cursor   *pageCursor // nil for the first page
+	pageSize int
+}
+
+// Paginate fetches with Find or Rows the pageSize records following the
+// cursor, the first page when the cursor is empty. The records are sorted by
+// the OrderBy, Asc and Desc columns then by the primary key, and the page is
+// sought by comparing these columns with the values in the cursor, so they
+// should not be nullable: Find fails with ErrPaginateNull when the first or
+// the last record of the page has a NULL value to put in a cursor. After
+// Find, Page returns the cursors of the next and previous pages.
+func (session *Session) Paginate(cursor string, pageSize int) *Session {
+	session.Statement.paginate = &paginateParam{cursor: cursor, pageSize: pageSize}
+	session.page = nil
+	return session
+}
+
+// Page returns the cursors around the page fetched by the last paginated Find
+func (session *Session) Page() *Page {
+	if session.page == nil {
+		return &Page{}
+	}
+	return session.page
+}
+
+// findPage runs Find for the page and sets the cursors around it
+func (session *Session) findPage(sliceValue reflect.Value, rowsSlicePtr interface{}, condiBean ...interface{}) error {
+	if sliceValue.Kind() != reflect.Slice {
+		return errors.New("Paginate needs a pointer to a slice")
+	}
+	elemType := sliceValue.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return errors.New("slice type")
+	}
+
+	page, err := session.preparePage(elemType)
+	if err != nil {
+		return err
+	}
+	if err = session.find(rowsSlicePtr, condiBean...); err != nil {
+		return err
+	}
+
+	n := sliceValue.Len()
+	hasMore := n > page.pageSize
+	if hasMore {
+		n = page.pageSize
+		sliceValue.Set(sliceValue.Slice(0, n))
+	}
+	backward := page.cursor != nil && page.cursor.Prev
+	if backward {
+		swap := reflect.Swapper(sliceValue.Interface())
+		for i, j := 0, n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	result := &Page{}
+	if n > 0 {
+		if (backward && hasMore) || (!backward && page.cursor != nil) {
+			values, err := session.cursorValues(page.orders, sliceValue.Index(0))
+			if err != nil {
+				return err
+			}
+			if result.Prev, err = encodeCursor(true, values); err != nil {
+				return err
+			}
+		}
+		if backward || hasMore {
+			values, err := session.cursorValues(page.orders, sliceValue.Index(n-1))
+			if err != nil {
+				return err
+			}
+			if result.Next, err = encodeCursor(false, values); err != nil {
+				return err
+			}
+		}
+	}
+	session.page = result
+	return nil
+}
+
+// preparePage adds the seek condition, the order and the limit of the page to
+// the statement. One more record than the page size is fetched to know
+// whether another page follows.
+func (session *Session) preparePage(elemType reflect.Type) (*pageState, error) {
+	statement := &session.Statement
+	param := statement.paginate
+	statement.paginate = nil
+	if param.pageSize < 1 {
+		return nil, ErrParamsType
+	}
+
+	elem := reflect.New(elemType).Elem()
+	if statement.RefTable == nil {
+		statement.setRefValue(elem)
+	}
+	orders, err := statement.pageOrders(session.Engine.autoMapType(elem))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pageState{orders: orders, pageSize: param.pageSize}
+	if param.cursor != "" {
+		if page.cursor, err = decodeCursor(param.cursor); err != nil {
+			return nil, err
+		}
+		if len(page.cursor.Values) != len(orders) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	backward := page.cursor != nil && page.cursor.Prev
+	orderStrs := make([]string, len(orders))
+	for i, order := range orders {
+		if order.desc != backward {
+			orderStrs[i] = session.Engine.Quote(order.key) + " DESC"
+		} else {
+			orderStrs[i] = session.Engine.Quote(order.key) + " ASC"
+		}
+	}
+	statement.OrderStr = strings.Join(orderStrs, ", ")
+	if page.cursor != nil {
+		statement.cond = statement.cond.And(session.seekCond(orders, page.cursor))
+	}
+	statement.LimitN = param.pageSize + 1
+	statement.Start = 0
+	return page, nil
+}
+
+// seekCond returns the condition of the records after the cursor in the
+// order, before it for a previous page cursor:
+// (c1 > v1) OR (c1 = v1 AND c2 > v2) OR ...
+func (session *Session) seekCond(orders []*pageOrder, cursor *pageCursor) builder.Cond {
+	values := make([]interface{}, len(orders))
+	for i, v := range cursor.Values {
+		if t, ok := v.(time.Time); ok {
+			values[i] = session.Engine.FormatTime(orders[i].col.SQLType.Name, t)
+		} else {
+			values[i] = v
+		}
+	}
+
+	var seek builder.Cond = builder.NewCond()
+	for i, order := range orders {
+		cond := builder.NewCond()
+		for j := 0; j < i; j++ {
+			cond = cond.And(builder.Eq{orders[j].key: values[j]})
+		}
+		if order.desc != cursor.Prev {
+			cond = cond.And(builder.Lt{order.key: values[i]})
+		} else {
+			cond = cond.And(builder.Gt{order.key: values[i]})
+		}
+		seek = seek.Or(cond)
+	}
+	return seek
+}
+
+// pageOrders parses the ordering columns and appends the primary keys to
+// them, which makes the order total
+func (statement *Statement) pageOrders(table *core.Table) ([]*pageOrder, error) {
+	var orders []*pageOrder
+	if len(strings.TrimSpace(statement.OrderStr)) > 0 {
+		for _, item := range strings.Split(statement.OrderStr, ",") {
+			fields := strings.Fields(item)
+			if len(fields) == 0 || len(fields) > 2 {
+				return nil, ErrPaginateOrder
+			}
+			order := &pageOrder{key: fields[0]}
+			if len(fields) == 2 {
+				switch strings.ToUpper(fields[1]) {
+				case "ASC":
+				case "DESC":
+					order.desc = true
+				default:
+					return nil, ErrPaginateOrder
+				}
+			}
+			order.key = strings.Replace(order.key, "`", "", -1)
+			order.key = strings.Replace(order.key, statement.Engine.QuoteStr(), "", -1)
+			if order.col = statement.pageColumn(table, order.key); order.col == nil {
+				return nil, ErrPaginateOrder
+			}
+			orders = append(orders, order)
+		}
+	}
+
+	needTableName := len(statement.JoinStr()) > 0
+	for _, pk := range statement.RefTable.PKColumns() {
+		key := pk.Name
+		if needTableName {
+			if statement.TableAlias != "" {
+				key = statement.TableAlias + "." + key
+			} else {
+				key = statement.TableName() + "." + key
+			}
+		}
+		col := statement.pageColumn(table, key)
+		if col == nil {
+			return nil, ErrPaginateOrder
+		}
+		var ordered bool
+		for _, order := range orders {
+			if order.col == col {
+				ordered = true
+				break
+			}
+		}
+		if !ordered {
+			orders = append(orders, &pageOrder{key: key, col: col})
+		}
+	}
+	if len(orders) == 0 {
+		return nil, ErrPaginateOrder
+	}
+	return orders, nil
+}
+
+// pageColumn finds the column of the fetched struct named by an ordering
+// column, which may be qualified by a table name or alias
+func (statement *Statement) pageColumn(table *core.Table, name string) *core.Column {
+	var qualifier string
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		qualifier, name = name[:i], name[i+1:]
+	}
+	var found *core.Column
+	for _, col := range table.Columns() {
+		if !strings.EqualFold(col.Name, name) || col.MapType == core.ONLYTODB {
+			continue
+		}
+		tableName := columnTableName(table, col)
+		isMain := tableName == statement.RefTable.Name
+		if qualifier == "" {
+			if isMain {
+				return col
+			}
+			if found == nil {
+				found = col
+			}
+			continue
+		}
+		if qualifier == tableName || (isMain && qualifier == statement.TableAlias) ||
+			(table.Relation != nil && table.Relation.ExAlias[tableName] == qualifier) {
+			return col
+		}
+	}
+	return found
+}
+
+// columnTableName returns the name of the table of a column, which belongs to
+// an extends table when its field is in an extends struct
+func columnTableName(table *core.Table, col *core.Column) string {
+	path := strings.Split(col.FieldName, ".")
+	if len(path) > 1 && table.Relation != nil {
+		if name := table.Relation.GetTableNameByStructField(path[len(path)-2]); name != "" {
+			return name
+		}
+	}
+	return table.Name
+}
+
+// cursorValues returns the values of the ordering columns of a fetched record
+func (session *Session) cursorValues(orders []*pageOrder, elem reflect.Value) ([]interface{}, error) {
+	elem = reflect.Indirect(elem)
+	values := make([]interface{}, len(orders))
+	for i, order := range orders {
+		fieldValue, err := order.col.ValueOfV(&elem)
+		if err != nil {
+			return nil, err
+		}
+		if values[i], err = session.value2Interface(order.col, *fieldValue); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// encodeCursor encodes the values of a record as an url safe string, time
+// values are tagged to be decoded as time.Time. NULL values are rejected, no
+// record compares greater or less than them.
+func encodeCursor(prev bool, values []interface{}) (string, error) {
+	cursor := pageCursor{Prev: prev, Values: make([]interface{}, len(values))}
+	for i, v := range values {
+		if isNullValue(v) {
+			return "", ErrPaginateNull
+		}
+		if t, ok := v.(time.Time); ok {
+			cursor.Values[i] = map[string]string{"t": t.Format(time.RFC3339Nano)}
+		} else {
+			cursor.Values[i] = v
+		}
+	}
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// isNullValue reports whether v, a valuer or a pointer to it, is NULL
+func isNullValue(v interface{}) bool {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return true
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return true
+		}
+		rv = rv.Elem()
+	}
+	return !rv.IsValid()
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var cursor pageCursor
+	if err = decoder.Decode(&cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	for i, v := range cursor.Values {
+		switch value := v.(type) {
+		case nil:
+			return nil, ErrInvalidCursor
+		case json.Number:
+			if n, err := value.Int64(); err == nil {
+				cursor.Values[i] = n
+			} else if f, err := value.Float64(); err == nil {
+				cursor.Values[i] = f
+			} else {
+				return nil, ErrInvalidCursor
+			}
+		case map[string]interface{}:
+			s, _ := value["t"].(string)
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			cursor.Values[i] = t
+		}
+	}
+	return &cursor, nil
+}
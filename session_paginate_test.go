@@ -0,0 +1,124 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+type PageUser struct {
+	Id      int64
+	Name    *string
+	Created time.Time
+}
+
+func TestCursor(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	values := []interface{}{int64(1), 1.5, "a", created, true}
+	s, err := encodeCursor(true, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor, err := decodeCursor(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cursor.Prev || len(cursor.Values) != len(values) || !cursor.Values[3].(time.Time).Equal(created) {
+		t.Fatalf("get %+v", cursor)
+	}
+	cursor.Values[3] = created
+	if !reflect.DeepEqual(cursor.Values, values) {
+		t.Fatal("want", values, "get", cursor.Values)
+	}
+
+	var nilName *string
+	for _, v := range []interface{}{nil, nilName, sql.NullString{}} {
+		if _, err = encodeCursor(false, []interface{}{int64(1), v}); err != ErrPaginateNull {
+			t.Error("want", ErrPaginateNull, "get", err)
+		}
+	}
+
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	for _, s := range []string{"!", encode("x"), encode(`{"v":[1,null]}`), encode(`{"v":[{"t":"x"}]}`)} {
+		if _, err = decodeCursor(s); err != ErrInvalidCursor {
+			t.Error(s, "want", ErrInvalidCursor, "get", err)
+		}
+	}
+}
+
+func TestSeekCond(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	session := engine.NewSession()
+	defer session.Close()
+	table := engine.autoMapType(reflect.ValueOf(PageUser{}))
+
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	orders := []*pageOrder{
+		{key: "created", desc: true, col: table.GetColumn("created")},
+		{key: "id", col: table.GetColumn("id")},
+	}
+
+	var cases = []struct {
+		cursor *pageCursor
+		sql    string
+	}{
+		{&pageCursor{Values: []interface{}{created, int64(5)}}, "(created<?) OR (created=? AND id>?)"},
+		// a previous page is sought in the reverse order
+		{&pageCursor{Prev: true, Values: []interface{}{created, int64(5)}}, "(created>?) OR (created=? AND id<?)"},
+	}
+	for _, c := range cases {
+		sql, args, err := builder.ToSQL(session.seekCond(orders, c.cursor))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []interface{}{engine.FormatTime(core.DateTime, created), engine.FormatTime(core.DateTime, created), int64(5)}
+		if sql != c.sql || !reflect.DeepEqual(args, want) {
+			t.Errorf("want %s %v, get %s %v", c.sql, want, sql, args)
+		}
+	}
+}
+
+func TestPaginateNull(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	// the next page cannot be sought from the NULL name of the last record
+	queueFakeResults(dsn, [][]driver.Value{
+		{"id", "name", "created"},
+		{int64(1), nil, "2020-01-02 03:04:05"},
+		{int64(2), "b", "2020-01-02 03:04:05"},
+	})
+	var users []PageUser
+	if err := engine.OrderBy("name").Paginate("", 1).Find(&users); err != ErrPaginateNull {
+		t.Fatal("want", ErrPaginateNull, "get", err)
+	}
+
+	queueFakeResults(dsn, [][]driver.Value{
+		{"id", "name", "created"},
+		{int64(1), "a", "2020-01-02 03:04:05"},
+		{int64(2), nil, "2020-01-02 03:04:05"},
+	})
+	session := engine.NewSession()
+	defer session.Close()
+	users = nil
+	if err := session.OrderBy("name").Paginate("", 1).Find(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || session.Page().Next == "" {
+		t.Fatalf("get %+v %+v", users, session.Page())
+	}
+}
@@ -0,0 +1,518 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+// preloadBatchArgs is the maximum number of key values of a preload query,
+// the related records of more keys are loaded by several queries
+const preloadBatchArgs = 500
+
+var associationKinds = map[string]int{
+	"BELONGS_TO":   core.BelongsTo,
+	"HAS_ONE":      core.HasOne,
+	"HAS_MANY":     core.HasMany,
+	"MANY_TO_MANY": core.ManyToMany,
+}
+
+type preloadParam struct {
+	path string
+	fn   func(*Session) *Session
+}
+
+// preloadNode is a relation to load, with the relations of its records
+type preloadNode struct {
+	name     string
+	fn       func(*Session) *Session
+	children []*preloadNode
+}
+
+func (node *preloadNode) child(name string) *preloadNode {
+	for _, child := range node.children {
+		if child.name == name {
+			return child
+		}
+	}
+	child := &preloadNode{name: name}
+	node.children = append(node.children, child)
+	return child
+}
+
+// relation is an association resolved between the mapped tables
+type relation struct {
+	assoc    *core.Association
+	field    reflect.StructField
+	many     bool
+	relType  reflect.Type
+	table    *core.Table
+	relTable *core.Table
+	keys     *preloadKeys
+}
+
+// preloadKeys are the columns matching the records of a relation
+type preloadKeys struct {
+	cols    []string // columns of the table
+	relCols []string // columns of the related table
+	// columns of the join table referencing cols and relCols
+	joinCols    []string
+	joinRelCols []string
+}
+
+// parseAssociation returns the association declared by the first tag of a
+// field, or nil:
+//
+//	belongs_to(fk,...)        the columns of the table referencing the related primary keys
+//	has_one(fk,...)           the columns of the related table referencing the primary keys
+//	has_many(fk,...)
+//	many_to_many(join,fk,...) the columns of the join table referencing both primary keys
+func parseAssociation(tag string, fieldName string) *core.Association {
+	name, args := tag, ""
+	if i := strings.Index(tag, "("); i > 0 && strings.HasSuffix(tag, ")") {
+		name, args = tag[:i], tag[i+1:len(tag)-1]
+	}
+	kind, ok := associationKinds[strings.ToUpper(name)]
+	if !ok {
+		return nil
+	}
+
+	assoc := &core.Association{Kind: kind, FieldName: fieldName}
+	for _, col := range strings.Split(args, ",") {
+		if col = strings.TrimSpace(col); col != "" {
+			assoc.ForeignKeys = append(assoc.ForeignKeys, col)
+		}
+	}
+	if kind == core.ManyToMany && len(assoc.ForeignKeys) > 0 {
+		assoc.JoinTable = assoc.ForeignKeys[0]
+		assoc.ForeignKeys = assoc.ForeignKeys[1:]
+	}
+	return assoc
+}
+
+// Preload loads the relations of the paths, such as "Orders" and
+// "Orders.Items", into the records found by Find or Get. The relations are
+// the fields tagged belongs_to, has_one, has_many or many_to_many; the
+// related records of all the found records are loaded together by IN queries.
+func (session *Session) Preload(paths ...string) *Session {
+	for _, path := range paths {
+		session.Statement.preloads = append(session.Statement.preloads, preloadParam{path: path})
+	}
+	return session
+}
+
+// PreloadWith loads the relation of the path like Preload, the related
+// records are queried by the session returned by fn, which may add
+// conditions, an order or the columns to the session it's given
+func (session *Session) PreloadWith(path string, fn func(*Session) *Session) *Session {
+	session.Statement.preloads = append(session.Statement.preloads, preloadParam{path: path, fn: fn})
+	return session
+}
+
+// preload loads the relations of the statement into the found records
+func (session *Session) preload(beans interface{}) error {
+	if len(session.Statement.preloads) == 0 {
+		return nil
+	}
+
+	root := newPreloadTree(session.Statement.preloads)
+	values, err := preloadValues(reflect.ValueOf(beans))
+	if err != nil {
+		return err
+	}
+	for _, node := range root.children {
+		if err = session.preloadNode(values, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newPreloadTree returns the tree of the relations of the paths
+func newPreloadTree(params []preloadParam) *preloadNode {
+	root := &preloadNode{}
+	for _, param := range params {
+		node := root
+		for _, name := range strings.Split(param.path, ".") {
+			node = node.child(name)
+		}
+		if param.fn != nil {
+			node.fn = param.fn
+		}
+	}
+	return root
+}
+
+// preloadValues returns the addressable structs of a pointer to a struct or
+// to a slice or a map of structs
+func preloadValues(v reflect.Value) ([]reflect.Value, error) {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.CanAddr() {
+			return []reflect.Value{v}, nil
+		}
+	case reflect.Slice:
+		values := make([]reflect.Value, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, reflect.Indirect(v.Index(i)))
+		}
+		return values, nil
+	case reflect.Map:
+		if v.Type().Elem().Kind() == reflect.Ptr {
+			values := make([]reflect.Value, 0, v.Len())
+			for _, key := range v.MapKeys() {
+				values = append(values, v.MapIndex(key).Elem())
+			}
+			return values, nil
+		}
+	}
+	return nil, errors.New("Preload needs a pointer to a struct, a slice or a map of pointers")
+}
+
+// preloadNode loads the relation of the node into the beans, then the
+// relations of the children of the node into the related records
+func (session *Session) preloadNode(beans []reflect.Value, node *preloadNode) error {
+	if len(beans) == 0 {
+		return nil
+	}
+	rel, err := session.relation(beans[0], node.name)
+	if err != nil {
+		return err
+	}
+	table, relTable, keys, field, many := rel.table, rel.relTable, rel.keys, rel.field, rel.many
+
+	// the beans by the values of their key columns
+	owners := make(map[string][]int)
+	var ownerKeys [][]interface{}
+	for i, bean := range beans {
+		key, values, err := preloadKey(bean, table, keys.cols)
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			continue
+		}
+		if _, ok := owners[key]; !ok {
+			ownerKeys = append(ownerKeys, values)
+		}
+		owners[key] = append(owners[key], i)
+	}
+
+	relKeys := ownerKeys
+	var links map[string][]string
+	if rel.assoc.Kind == core.ManyToMany {
+		if links, relKeys, err = session.preloadJoinTable(rel.assoc.JoinTable, keys, ownerKeys); err != nil {
+			return err
+		}
+	}
+
+	rels, err := session.preloadFind(rel.relType, node.fn, keys.relCols, relKeys)
+	if err != nil {
+		return err
+	}
+
+	// the relations of the related records are loaded before they are copied
+	// to the beans
+	for _, child := range node.children {
+		if err = session.preloadNode(rels, child); err != nil {
+			return err
+		}
+	}
+
+	if many {
+		for _, bean := range beans {
+			bean.FieldByIndex(field.Index).Set(reflect.MakeSlice(field.Type, 0, 0))
+		}
+	}
+	assigned := make([]bool, len(beans))
+	for _, rel := range rels {
+		key, _, err := preloadKey(rel, relTable, keys.relCols)
+		if err != nil {
+			return err
+		}
+		ownerKeys := []string{key}
+		if links != nil {
+			ownerKeys = links[key]
+		}
+		for _, ownerKey := range ownerKeys {
+			for _, i := range owners[ownerKey] {
+				fieldValue := beans[i].FieldByIndex(field.Index)
+				if many {
+					fieldValue.Set(reflect.Append(fieldValue, preloadElem(rel, field.Type.Elem())))
+				} else if !assigned[i] {
+					fieldValue.Set(preloadElem(rel, field.Type))
+					assigned[i] = true
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// relation resolves the association of the struct field name of the bean
+func (session *Session) relation(bean reflect.Value, name string) (*relation, error) {
+	table := session.Engine.autoMapType(bean)
+	assoc := table.GetAssociation(name)
+	if assoc == nil {
+		return nil, fmt.Errorf("%v has no relation %s", bean.Type(), name)
+	}
+
+	field, _ := bean.Type().FieldByName(assoc.FieldName)
+	rel := &relation{
+		assoc:   assoc,
+		field:   field,
+		many:    field.Type.Kind() == reflect.Slice,
+		relType: field.Type,
+		table:   table,
+	}
+	if rel.many {
+		rel.relType = rel.relType.Elem()
+	}
+	if rel.relType.Kind() == reflect.Ptr {
+		rel.relType = rel.relType.Elem()
+	}
+	if rel.relType.Kind() != reflect.Struct || rel.many != (assoc.Kind == core.HasMany || assoc.Kind == core.ManyToMany) {
+		return nil, fmt.Errorf("the type %v of the relation %s does not match its tag", field.Type, name)
+	}
+	rel.relTable = session.Engine.autoMapType(reflect.New(rel.relType).Elem())
+
+	var err error
+	rel.keys, err = session.preloadKeys(assoc, table, rel.relTable)
+	return rel, err
+}
+
+// preloadElem returns the related record, or its pointer, as a value of t
+func preloadElem(rel reflect.Value, t reflect.Type) reflect.Value {
+	if t.Kind() == reflect.Ptr {
+		return rel.Addr()
+	}
+	return rel
+}
+
+// preloadKeys returns the columns matching the records of the association,
+// the foreign keys not given by the tag are named after the structs
+func (session *Session) preloadKeys(assoc *core.Association, table, relTable *core.Table) (*preloadKeys, error) {
+	mapper := session.Engine.ColumnMapper
+	defaultKeys := func(t *core.Table, prefix string) []string {
+		cols := make([]string, len(t.PrimaryKeys))
+		for i, pk := range t.PrimaryKeys {
+			cols[i] = mapper.Obj2Table(prefix) + "_" + pk
+		}
+		return cols
+	}
+
+	keys := &preloadKeys{}
+	switch assoc.Kind {
+	case core.BelongsTo:
+		keys.cols = assoc.ForeignKeys
+		if len(keys.cols) == 0 {
+			keys.cols = defaultKeys(relTable, assoc.FieldName)
+		}
+		keys.relCols = relTable.PrimaryKeys
+	case core.HasOne, core.HasMany:
+		keys.cols = table.PrimaryKeys
+		keys.relCols = assoc.ForeignKeys
+		if len(keys.relCols) == 0 {
+			keys.relCols = defaultKeys(table, table.Type.Name())
+		}
+	case core.ManyToMany:
+		if assoc.JoinTable == "" {
+			return nil, fmt.Errorf("the relation %s has no join table", assoc.FieldName)
+		}
+		keys.cols = table.PrimaryKeys
+		keys.relCols = relTable.PrimaryKeys
+		if len(assoc.ForeignKeys) == 0 {
+			keys.joinCols = defaultKeys(table, table.Type.Name())
+			keys.joinRelCols = defaultKeys(relTable, relTable.Type.Name())
+		} else if len(assoc.ForeignKeys) == len(keys.cols)+len(keys.relCols) {
+			keys.joinCols = assoc.ForeignKeys[:len(keys.cols)]
+			keys.joinRelCols = assoc.ForeignKeys[len(keys.cols):]
+		} else {
+			return nil, fmt.Errorf("the relation %s needs %d join table columns", assoc.FieldName, len(keys.cols)+len(keys.relCols))
+		}
+	}
+	if len(keys.cols) == 0 || len(keys.cols) != len(keys.relCols) {
+		return nil, fmt.Errorf("the keys of the relation %s do not match the primary keys", assoc.FieldName)
+	}
+	return keys, nil
+}
+
+// preloadSession returns a session running on the connection or the
+// transaction of the session, it must not be closed
+func (session *Session) preloadSession() *Session {
+	sess := session.Clone()
+	sess.Statement.Init()
+	sess.IsAutoClose = false
+	sess.AutoResetStatement = true
+	sess.page = nil
+	return sess
+}
+
+// preloadFind finds the records of relType whose columns match the keys
+func (session *Session) preloadFind(relType reflect.Type, fn func(*Session) *Session, cols []string, keys [][]interface{}) ([]reflect.Value, error) {
+	var rels []reflect.Value
+	size := preloadBatchArgs / len(cols)
+	if size < 1 {
+		size = 1
+	}
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		sess := session.preloadSession()
+		if fn != nil {
+			sess = fn(sess)
+		}
+		slicePtr := reflect.New(reflect.SliceOf(reflect.PtrTo(relType)))
+		if err := sess.And(preloadCond(cols, keys[start:end])).Find(slicePtr.Interface()); err != nil {
+			return nil, err
+		}
+		slice := slicePtr.Elem()
+		for i := 0; i < slice.Len(); i++ {
+			rels = append(rels, slice.Index(i).Elem())
+		}
+	}
+	return rels, nil
+}
+
+// preloadJoinTable reads the rows of the join table referencing the keys, it
+// returns the keys of the records linked to each related record and the keys
+// of the related records
+func (session *Session) preloadJoinTable(joinTable string, keys *preloadKeys, ownerKeys [][]interface{}) (map[string][]string, [][]interface{}, error) {
+	links := make(map[string][]string)
+	var relKeys [][]interface{}
+
+	var cols []string
+	for _, col := range keys.joinCols {
+		cols = append(cols, session.Engine.Quote(col))
+	}
+	for _, col := range keys.joinRelCols {
+		cols = append(cols, session.Engine.Quote(col))
+	}
+	size := preloadBatchArgs / len(keys.joinCols)
+	if size < 1 {
+		size = 1
+	}
+	for start := 0; start < len(ownerKeys); start += size {
+		end := start + size
+		if end > len(ownerKeys) {
+			end = len(ownerKeys)
+		}
+		condSQL, args, err := builder.ToSQL(preloadCond(keys.joinCols, ownerKeys[start:end]), session.Engine.QuoteKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		sqlStr := "SELECT " + strings.Join(cols, ", ") + " FROM " + session.Engine.Quote(joinTable) + " WHERE " + condSQL
+		rows, err := session.preloadSession().queryInterface(sqlStr, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			ownerKey, _ := preloadRowKey(row, keys.joinCols)
+			relKey, relValues := preloadRowKey(row, keys.joinRelCols)
+			if relValues == nil {
+				continue
+			}
+			if _, ok := links[relKey]; !ok {
+				relKeys = append(relKeys, relValues)
+			}
+			links[relKey] = append(links[relKey], ownerKey)
+		}
+	}
+	return links, relKeys, nil
+}
+
+// preloadCond returns the condition matching the keys of the columns
+func preloadCond(cols []string, keys [][]interface{}) builder.Cond {
+	if len(cols) == 1 {
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = key[0]
+		}
+		return builder.In(cols[0], values...)
+	}
+	cond := builder.NewCond()
+	for _, key := range keys {
+		eq := builder.Eq{}
+		for i, col := range cols {
+			eq[col] = key[i]
+		}
+		cond = cond.Or(eq)
+	}
+	return cond
+}
+
+// preloadKey returns the key of the values of the columns of a bean, the
+// values are nil when one of them is null
+func preloadKey(bean reflect.Value, table *core.Table, cols []string) (string, []interface{}, error) {
+	values := make([]interface{}, len(cols))
+	for i, name := range cols {
+		col := table.GetColumn(name)
+		if col == nil {
+			return "", nil, fmt.Errorf("column %s not found in table %s", name, table.Name)
+		}
+		fieldValue, err := col.ValueOfV(&bean)
+		if err != nil {
+			return "", nil, err
+		}
+		if values[i] = preloadValue(fieldValue.Interface()); values[i] == nil {
+			return "", nil, nil
+		}
+	}
+	return joinPreloadKey(values), values, nil
+}
+
+// preloadRowKey returns the key of the values of the columns of a row
+func preloadRowKey(row map[string]interface{}, cols []string) (string, []interface{}) {
+	values := make([]interface{}, len(cols))
+	for i, col := range cols {
+		if values[i] = preloadValue(row[col]); values[i] == nil {
+			return "", nil
+		}
+	}
+	return joinPreloadKey(values), values
+}
+
+func joinPreloadKey(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// preloadValue returns the value of a key column, which compares equal
+// whatever the Go type of the field or the driver, or nil when it's null
+func preloadValue(v interface{}) interface{} {
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil
+		}
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b)
+	}
+	return rv.Interface()
+}
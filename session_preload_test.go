@@ -0,0 +1,229 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+type PreloadOrder struct {
+	ShopId int64         `xorm:"pk"`
+	Number int64         `xorm:"pk"`
+	Items  []PreloadItem `xorm:"has_many"`
+}
+
+type PreloadItem struct {
+	Id                 int64
+	PreloadOrderShopId int64
+	PreloadOrderNumber int64
+	Order              *PreloadOrder `xorm:"belongs_to(preload_order_shop_id,preload_order_number)"`
+	Shop               *PreloadOrder `xorm:"belongs_to"`
+}
+
+func TestParseAssociation(t *testing.T) {
+	var cases = []struct {
+		tag   string
+		assoc *core.Association
+	}{
+		{"belongs_to", &core.Association{Kind: core.BelongsTo, FieldName: "F"}},
+		{"HAS_ONE(a)", &core.Association{Kind: core.HasOne, FieldName: "F", ForeignKeys: []string{"a"}}},
+		{"has_many( a , b )", &core.Association{Kind: core.HasMany, FieldName: "F", ForeignKeys: []string{"a", "b"}}},
+		{"many_to_many(j)", &core.Association{Kind: core.ManyToMany, FieldName: "F", JoinTable: "j", ForeignKeys: []string{}}},
+		{"many_to_many(j,a,b)", &core.Association{Kind: core.ManyToMany, FieldName: "F", JoinTable: "j", ForeignKeys: []string{"a", "b"}}},
+		{"many_to_many", &core.Association{Kind: core.ManyToMany, FieldName: "F"}},
+		{"varchar(20)", nil},
+		{"pk", nil},
+	}
+
+	for _, c := range cases {
+		if assoc := parseAssociation(c.tag, "F"); !reflect.DeepEqual(assoc, c.assoc) {
+			t.Errorf("%s: want %+v, get %+v", c.tag, c.assoc, assoc)
+		}
+	}
+}
+
+func TestPreloadCompositeKeys(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	session := engine.NewSession()
+	defer session.Close()
+
+	var cases = []struct {
+		bean interface{}
+		name string
+		keys *preloadKeys
+	}{
+		{&PreloadOrder{}, "Items", &preloadKeys{
+			cols:    []string{"shop_id", "number"},
+			relCols: []string{"preload_order_shop_id", "preload_order_number"},
+		}},
+		{&PreloadItem{}, "Order", &preloadKeys{
+			cols:    []string{"preload_order_shop_id", "preload_order_number"},
+			relCols: []string{"shop_id", "number"},
+		}},
+		{&PreloadItem{}, "Shop", &preloadKeys{
+			cols:    []string{"shop_shop_id", "shop_number"},
+			relCols: []string{"shop_id", "number"},
+		}},
+	}
+	for _, c := range cases {
+		rel, err := session.relation(reflect.ValueOf(c.bean).Elem(), c.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(rel.keys, c.keys) {
+			t.Errorf("%T.%s: want %+v, get %+v", c.bean, c.name, c.keys, rel.keys)
+		}
+	}
+}
+
+func TestPreloadCond(t *testing.T) {
+	var cases = []struct {
+		cols []string
+		keys [][]interface{}
+		sql  string
+		args []interface{}
+	}{
+		{[]string{"a"}, [][]interface{}{{1}, {2}}, "a IN (?,?)", []interface{}{1, 2}},
+		{[]string{"a", "b"}, [][]interface{}{{1, "x"}, {2, "y"}}, "(a=? AND b=?) OR (a=? AND b=?)", []interface{}{1, "x", 2, "y"}},
+	}
+
+	for _, c := range cases {
+		sql, args, err := builder.ToSQL(preloadCond(c.cols, c.keys))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != c.sql || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("want %s %v, get %s %v", c.sql, c.args, sql, args)
+		}
+	}
+}
+
+func TestPreloadValue(t *testing.T) {
+	n := 3
+	var nilInt *int
+	var cases = []struct {
+		v     interface{}
+		value interface{}
+	}{
+		{int64(1), int64(1)},
+		{&n, 3},
+		{nilInt, nil},
+		{nil, nil},
+		{[]byte("ab"), "ab"},
+		{sql.NullInt64{Int64: 2, Valid: true}, int64(2)},
+		{sql.NullInt64{}, nil},
+		{sql.NullString{String: "s", Valid: true}, "s"},
+	}
+
+	for _, c := range cases {
+		if value := preloadValue(c.v); !reflect.DeepEqual(value, c.value) {
+			t.Errorf("%#v: want %#v, get %#v", c.v, c.value, value)
+		}
+	}
+
+	// the keys are equal whatever the Go type of the values
+	a := joinPreloadKey([]interface{}{preloadValue(int64(3)), preloadValue("a")})
+	b := joinPreloadKey([]interface{}{preloadValue(&n), preloadValue([]byte("a"))})
+	if a != "3\x00a" || a != b {
+		t.Error("want equal keys, get", a, b)
+	}
+}
+
+func TestPreloadBatch(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	users := [][]driver.Value{{"id", "name"}, {int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}}
+	pets := [][]driver.Value{
+		{"id", "cascade_user_id", "name"},
+		{int64(10), int64(1), "x"},
+		{int64(11), int64(3), "y"},
+		{int64(12), int64(1), "z"},
+	}
+	queueFakeResults(dsn, users, pets)
+	takeFakeStmts(dsn)
+
+	var found []CascadeUser
+	if err := engine.Preload("Pets").Find(&found); err != nil {
+		t.Fatal(err)
+	}
+	stmts := takeFakeStmts(dsn)
+	if len(stmts) != 2 || !strings.HasSuffix(stmts[1], "WHERE `cascade_user_id` IN (?,?,?)") {
+		t.Fatal("want the pets loaded by one query, get", stmts)
+	}
+	names := func(pets []CascadePet) (s []string) {
+		for _, pet := range pets {
+			s = append(s, pet.Name)
+		}
+		return
+	}
+	if len(found) != 3 ||
+		!reflect.DeepEqual(names(found[0].Pets), []string{"x", "z"}) ||
+		found[1].Pets == nil || len(found[1].Pets) != 0 ||
+		!reflect.DeepEqual(names(found[2].Pets), []string{"y"}) {
+		t.Fatalf("get %+v", found)
+	}
+
+	// the keys beyond preloadBatchArgs are loaded by another query
+	many := [][]driver.Value{{"id", "name"}}
+	for i := 1; i <= preloadBatchArgs+1; i++ {
+		many = append(many, []driver.Value{int64(i), "u"})
+	}
+	queueFakeResults(dsn, many, pets, pets)
+	found = nil
+	if err := engine.Preload("Pets").Find(&found); err != nil {
+		t.Fatal(err)
+	}
+	if stmts = takeFakeStmts(dsn); len(stmts) != 3 {
+		t.Fatal("want 3 queries, get", len(stmts))
+	}
+	if len(found[0].Pets) != 4 {
+		t.Error("want the pets of both queries, get", found[0].Pets)
+	}
+}
+
+func TestPreloadCompositeBatch(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	orders := [][]driver.Value{
+		{"shop_id", "number"},
+		{int64(1), int64(1)},
+		{int64(1), int64(2)},
+		{int64(2), int64(1)},
+	}
+	items := [][]driver.Value{
+		{"id", "preload_order_shop_id", "preload_order_number"},
+		{int64(10), int64(1), int64(2)},
+		{int64(11), int64(2), int64(1)},
+		{int64(12), int64(2), int64(1)},
+	}
+	queueFakeResults(dsn, orders, items)
+	takeFakeStmts(dsn)
+
+	var found []*PreloadOrder
+	if err := engine.Preload("Items").Find(&found); err != nil {
+		t.Fatal(err)
+	}
+	stmts := takeFakeStmts(dsn)
+	want := "WHERE ((`preload_order_number`=? AND `preload_order_shop_id`=?) OR (`preload_order_number`=? AND `preload_order_shop_id`=?) OR (`preload_order_number`=? AND `preload_order_shop_id`=?))"
+	if len(stmts) != 2 || !strings.HasSuffix(stmts[1], want) {
+		t.Fatal("want the items loaded by one query, get", stmts)
+	}
+	if len(found) != 3 || len(found[0].Items) != 0 || len(found[1].Items) != 1 || len(found[2].Items) != 2 ||
+		found[1].Items[0].Id != 10 || found[2].Items[1].Id != 12 {
+		t.Fatalf("get %+v %+v %+v", found[0], found[1], found[2])
+	}
+}
@@ -21,19 +21,19 @@ func (session *Session) SQL(query interface{}, args ...interface{}) *Session {
 // Execute sql
 func (session *Session) innerExec(sqlStr string, args ...interface{}) (sql.Result, error) {
 	if session.prepareStmt {
-		stmt, err := session.doPrepare(sqlStr)
+		stmt, err := session.doPrepare(session.DB(), sqlStr)
 		if err != nil {
 			return nil, err
 		}
 
-		res, err := stmt.Exec(args...)
+		res, err := stmt.ExecContext(session.ctx, args...)
 		if err != nil {
 			return nil, err
 		}
 		return res, nil
 	}
 
-	return session.DB().Exec(sqlStr, args...)
+	return session.DB().ExecContext(session.ctx, sqlStr, args...)
 }
 
 func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, error) {
@@ -44,18 +44,18 @@ func (session *Session) exec(sqlStr string, args ...interface{}) (sql.Result, er
 
 	session.saveLastSQL(sqlStr, args...)
 
-	return session.Engine.logSQLExecutionTime(sqlStr, args, func() (sql.Result, error) {
+	return session.runExec(sqlStr, args, func() (sql.Result, error) {
 		if session.IsAutoCommit {
 			// FIXME: oci8 can not auto commit (github.com/mattn/go-oci8)
 			if session.Engine.dialect.DBType() == core.ORACLE {
 				session.Begin()
-				r, err := session.Tx.Exec(sqlStr, args...)
+				r, err := session.Tx.ExecContext(session.ctx, sqlStr, args...)
 				session.Commit()
 				return r, err
 			}
 			return session.innerExec(sqlStr, args...)
 		}
-		return session.Tx.Exec(sqlStr, args...)
+		return session.Tx.ExecContext(session.ctx, sqlStr, args...)
 	})
 }
 
@@ -77,18 +77,23 @@ func (session *Session) queryPreprocess(sqlStr *string, paramStr ...interface{})
 	session.saveLastSQL(*sqlStr, paramStr...)
 }
 
+// query sends sqlStr to the primary, e.g. for INSERT ... RETURNING
 func (session *Session) query(sqlStr string, paramStr ...interface{}) (resultsSlice []map[string][]byte, err error) {
+	return session.queryFrom(session.DB(), sqlStr, paramStr...)
+}
 
+// queryFrom sends sqlStr to db, or to the transaction once it has begun
+func (session *Session) queryFrom(db *core.DB, sqlStr string, paramStr ...interface{}) (resultsSlice []map[string][]byte, err error) {
 	session.queryPreprocess(&sqlStr, paramStr...)
 
 	if session.IsAutoCommit {
-		return session.innerQuery2(sqlStr, paramStr...)
+		return session.innerQuery2(db, sqlStr, paramStr...)
 	}
 	return session.txQuery(session.Tx, sqlStr, paramStr...)
 }
 
 func (session *Session) txQuery(tx *core.Tx, sqlStr string, params ...interface{}) (resultsSlice []map[string][]byte, err error) {
-	rows, err := tx.Query(sqlStr, params...)
+	rows, err := session.txQueryRows(tx, sqlStr, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -97,15 +102,15 @@ func (session *Session) txQuery(tx *core.Tx, sqlStr string, params ...interface{
 	return rows2maps(rows)
 }
 
-func (session *Session) innerQuery(sqlStr string, params ...interface{}) (*core.Stmt, *core.Rows, error) {
+func (session *Session) innerQuery(db *core.DB, sqlStr string, params ...interface{}) (*core.Stmt, *core.Rows, error) {
 	var callback func() (*core.Stmt, *core.Rows, error)
 	if session.prepareStmt {
 		callback = func() (*core.Stmt, *core.Rows, error) {
-			stmt, err := session.doPrepare(sqlStr)
+			stmt, err := session.doPrepare(db, sqlStr)
 			if err != nil {
 				return nil, nil, err
 			}
-			rows, err := stmt.Query(params...)
+			rows, err := stmt.QueryContext(session.ctx, params...)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -113,22 +118,22 @@ func (session *Session) innerQuery(sqlStr string, params ...interface{}) (*core.
 		}
 	} else {
 		callback = func() (*core.Stmt, *core.Rows, error) {
-			rows, err := session.DB().Query(sqlStr, params...)
+			rows, err := db.QueryContext(session.ctx, sqlStr, params...)
 			if err != nil {
 				return nil, nil, err
 			}
 			return nil, rows, err
 		}
 	}
-	stmt, rows, err := session.Engine.logSQLQueryTime(sqlStr, params, callback)
+	stmt, rows, err := session.runQuery(sqlStr, params, callback)
 	if err != nil {
 		return nil, nil, err
 	}
 	return stmt, rows, nil
 }
 
-func (session *Session) innerQuery2(sqlStr string, params ...interface{}) ([]map[string][]byte, error) {
-	_, rows, err := session.innerQuery(sqlStr, params...)
+func (session *Session) innerQuery2(db *core.DB, sqlStr string, params ...interface{}) ([]map[string][]byte, error) {
+	_, rows, err := session.innerQuery(db, sqlStr, params...)
 	if rows != nil {
 		defer rows.Close()
 	}
@@ -145,7 +150,7 @@ func (session *Session) Query(sqlStr string, paramStr ...interface{}) (resultsSl
 		defer session.Close()
 	}
 
-	return session.query(sqlStr, paramStr...)
+	return session.queryFrom(session.rawQueryDB(sqlStr), sqlStr, paramStr...)
 }
 
 // =============================
@@ -173,7 +173,9 @@ func (session *Session) isTableEmpty(tableName string) (bool, error) {
 
 	var total int64
 	sql := fmt.Sprintf("select count(*) from %s", session.Engine.Quote(tableName))
-	err := session.DB().QueryRow(sql).Scan(&total)
+	err := session.processHooks(sql, nil, func() (int64, error) {
+		return -1, session.DB().QueryRowContext(session.ctx, sql).Scan(&total)
+	})
 	session.saveLastSQL(sql)
 	if err != nil {
 		return true, err
@@ -205,7 +207,7 @@ func (session *Session) isIndexExist2(tableName string, cols []string, unique bo
 		defer session.Close()
 	}
 
-	indexes, err := session.Engine.dialect.GetIndexes(tableName)
+	indexes, err := session.Engine.dialect.GetIndexes(session.ctx, tableName)
 	if err != nil {
 		return false, err
 	}
@@ -279,7 +281,7 @@ func (session *Session) dropAll() error {
 func (session *Session) Sync2(beans ...interface{}) error {
 	engine := session.Engine
 
-	tables, err := engine.DBMetas()
+	tables, err := engine.dbMetas(session.ctx)
 	if err != nil {
 		return err
 	}
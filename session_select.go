@@ -17,6 +17,7 @@ func (session *Session) cacheCopySessionSettings(newSession *Session) {
 	newSession.Statement.ColumnStr = session.Statement.ColumnStr
 	newSession.Statement.OmitStr = session.Statement.OmitStr
 	newSession.Statement.columnMap = session.Statement.columnMap
+	newSession.forcePrimary = session.forcePrimary
 }
 
 func (session *Session) cacheGet(bean interface{}, sqlStr string, args ...interface{}) (has bool, err error) {
@@ -40,7 +41,7 @@ func (session *Session) cacheGet(bean interface{}, sqlStr string, args ...interf
 	table := session.Statement.RefTable
 	if err != nil {
 		var res = make([]string, len(table.PrimaryKeys))
-		rows, err := session.DB().Query(newsql, args...)
+		rows, err := session.dbQuery(session.queryDB(), newsql, args...)
 		if err != nil {
 			return false, err
 		}
@@ -92,6 +93,7 @@ func (session *Session) cacheGet(bean interface{}, sqlStr string, args ...interf
 		if cacheBean == nil {
 			newSession := session.Engine.NewSession()
 			defer newSession.Close()
+			newSession.Context(session.ctx)
 
 			session.cacheCopySessionSettings(newSession)
 
@@ -144,7 +146,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 	ids, err := core.GetCacheSql(cacher, tableName, newsql, args)
 
 	if err != nil {
-		rows, err := session.DB().Query(newsql, args...)
+		rows, err := session.dbQuery(session.queryDB(), newsql, args...)
 		if err != nil {
 			return err
 		}
@@ -226,6 +228,7 @@ func (session *Session) cacheFind(t reflect.Type, sqlStr string, rowsSlicePtr in
 	if len(ides) > 0 {
 		newSession := session.Engine.NewSession()
 		defer newSession.Close()
+		newSession.Context(session.ctx)
 
 		session.cacheCopySessionSettings(newSession)
 
@@ -321,6 +324,14 @@ func (session *Session) Get(bean interface{}) (bool, error) {
 		defer session.Close()
 	}
 
+	has, err := session.get(bean)
+	if err != nil || !has {
+		return has, err
+	}
+	return true, session.preload(bean)
+}
+
+func (session *Session) get(bean interface{}) (bool, error) {
 	session.Statement.setRefValue(rValue(bean))
 
 	var sqlStr string
@@ -352,9 +363,9 @@ func (session *Session) Get(bean interface{}) (bool, error) {
 	var err error
 	session.queryPreprocess(&sqlStr, args...)
 	if session.IsAutoCommit {
-		_, rawRows, err = session.innerQuery(sqlStr, args...)
+		_, rawRows, err = session.innerQuery(session.queryDB(), sqlStr, args...)
 	} else {
-		rawRows, err = session.Tx.Query(sqlStr, args...)
+		rawRows, err = session.txQueryRows(session.Tx, sqlStr, args...)
 	}
 	if err != nil {
 		return false, err
@@ -380,10 +391,20 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 		defer session.Close()
 	}
 
+	if err := session.find(rowsSlicePtr, condiBean...); err != nil {
+		return err
+	}
+	return session.preload(rowsSlicePtr)
+}
+
+func (session *Session) find(rowsSlicePtr interface{}, condiBean ...interface{}) error {
 	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
 	if sliceValue.Kind() != reflect.Slice && sliceValue.Kind() != reflect.Map {
 		return errors.New("needs a pointer to a slice or a map")
 	}
+	if session.Statement.paginate != nil {
+		return session.findPage(sliceValue, rowsSlicePtr, condiBean...)
+	}
 
 	sliceElementType := sliceValue.Type().Elem()
 
@@ -416,9 +437,8 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 	} else {
 		// !oinume! Add "<col> IS NULL" to WHERE whatever condiBean is given.
 		// See https://github.com/coscms/xorm/issues/179
-		if col := table.DeletedColumn(); col != nil && !session.Statement.unscoped { // tag "deleted" is enabled
-			colName := session.Statement.colName(col, addedTableName)
-			autoCond = builder.IsNull{colName}.Or(builder.Eq{colName: "0001-01-01 00:00:00"})
+		if col := table.DeletedColumn(); col != nil { // tag "deleted" is enabled
+			autoCond = session.Statement.deletedCond(session.Statement.colName(col, addedTableName))
 		}
 	}
 
@@ -431,7 +451,7 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 
 		columnStr := session.Statement.genColumnStr()
 
-		condSQL, condArgs, _ := builder.ToSQL(session.Statement.cond.And(autoCond), session.Engine.QuoteKey)
+		condSQL, condArgs, _ := session.Statement.condToSQL(session.Statement.cond.And(autoCond, session.Statement.refScopeCond()))
 
 		args = append(session.Statement.joinArgs, condArgs...)
 		sqlStr = session.Statement.genSelectSQL(columnStr, condSQL)
@@ -465,9 +485,9 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 
 		session.queryPreprocess(&sqlStr, args...)
 		if session.IsAutoCommit {
-			_, rawRows, err = session.innerQuery(sqlStr, args...)
+			_, rawRows, err = session.innerQuery(session.queryDB(), sqlStr, args...)
 		} else {
-			rawRows, err = session.Tx.Query(sqlStr, args...)
+			rawRows, err = session.txQueryRows(session.Tx, sqlStr, args...)
 		}
 		if err != nil {
 			return err
@@ -513,7 +533,7 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 		return session.rows2Beans(rawRows, fields, len(fields), session.Engine.autoMapType(dataStruct), newElemFunc, sliceValueSetFunc)
 	}
 
-	resultsSlice, err := session.query(sqlStr, args...)
+	resultsSlice, err := session.queryFrom(session.queryDB(), sqlStr, args...)
 	if err != nil {
 		return err
 	}
@@ -563,3 +583,68 @@ func (session *Session) Find(rowsSlicePtr interface{}, condiBean ...interface{})
 
 	return nil
 }
+
+// FindAndCount retrieves the records like Find and counts all the records
+// matching the same conditions, joins and relations without the order and
+// the limit. The grouped or distinct records are counted by a subquery, as
+// are the records of a raw SQL query, which should not be limited.
+func (session *Session) FindAndCount(rowsSlicePtr interface{}, condiBean ...interface{}) (int64, error) {
+	defer session.resetStatement()
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+
+	sliceValue := reflect.Indirect(reflect.ValueOf(rowsSlicePtr))
+	if sliceValue.Kind() != reflect.Slice && sliceValue.Kind() != reflect.Map {
+		return 0, errors.New("needs a pointer to a slice or a map")
+	}
+	elemType := sliceValue.Type().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	// Find must neither reset nor close the session, and the count ignores
+	// the seek condition of a paginated Find
+	autoClose, autoReset := session.IsAutoClose, session.AutoResetStatement
+	session.IsAutoClose, session.AutoResetStatement = false, false
+	statement := session.Statement
+	err := session.Find(rowsSlicePtr, condiBean...)
+	session.IsAutoClose, session.AutoResetStatement = autoClose, autoReset
+	if err != nil {
+		return 0, err
+	}
+	statement.OrderStr = ""
+	statement.LimitN = 0
+	statement.Start = 0
+	statement.paginate = nil
+	session.Statement = statement
+
+	var sqlStr string
+	var args []interface{}
+	if session.Statement.RawSQL == "" {
+		var bean interface{}
+		if len(condiBean) > 0 {
+			bean = condiBean[0]
+		} else if elemType.Kind() == reflect.Struct {
+			bean = reflect.New(elemType).Interface()
+		} else {
+			return 0, errors.New("slice type")
+		}
+		sqlStr, args = session.Statement.genFindCountSQL(bean)
+	} else {
+		sqlStr = fmt.Sprintf("SELECT count(*) FROM (%v) %v", session.Statement.RawSQL,
+			session.Engine.Quote("xorm_count"))
+		args = session.Statement.RawParams
+	}
+
+	session.queryPreprocess(&sqlStr, args...)
+
+	var total int64
+	err = session.queryRowScan(sqlStr, args, func(row *core.Row) error {
+		return row.Scan(&total)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
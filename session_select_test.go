@@ -0,0 +1,66 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+func TestFindAndCount(t *testing.T) {
+	engine := newScopeEngine(t)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+	tenant := context.WithValue(context.Background(), scopeTenantKey{}, int64(1))
+	posts := [][]driver.Value{{"id", "tenant_id", "user_id", "title"}, {int64(1), int64(1), int64(2), "a"}}
+	count := [][]driver.Value{{"count"}, {int64(12)}}
+
+	var cases = []struct {
+		session func(*Session) *Session
+		sqls    []string
+	}{
+		{func(s *Session) *Session {
+			return s.Where("`user_id` > ?", 1).Join("LEFT", "scope_user", "scope_user.id = scope_post.user_id").
+				Desc("id").Limit(1, 10)
+		}, []string{
+			"SELECT `scope_post`.`id`, `scope_post`.`tenant_id`, `scope_post`.`user_id`, `scope_post`.`title` FROM `scope_post` " +
+				"LEFT JOIN `scope_user` ON scope_user.id = scope_post.user_id " +
+				"WHERE `user_id` > ? AND `scope_post`.`tenant_id`=? AND `scope_post`.`title`<>? ORDER BY `id` DESC LIMIT 1 OFFSET 10",
+			// the count keeps the conditions, joins and scopes only once
+			"SELECT count(*) FROM `scope_post` LEFT JOIN `scope_user` ON scope_user.id = scope_post.user_id " +
+				"WHERE `user_id` > ? AND `scope_post`.`tenant_id`=? AND `scope_post`.`title`<>?",
+		}},
+		// a raw query is counted through a subquery
+		{func(s *Session) *Session {
+			return s.SQL("SELECT * FROM scope_post WHERE user_id > ?", 1)
+		}, []string{
+			"SELECT * FROM scope_post WHERE user_id > ?",
+			"SELECT count(*) FROM (SELECT * FROM scope_post WHERE user_id > ?) `xorm_count`",
+		}},
+	}
+
+	for i, c := range cases {
+		queueFakeResults(dsn, posts, count)
+		session := engine.NewSession()
+		var found []ScopePost
+		total, err := c.session(session.Context(tenant)).FindAndCount(&found)
+		if err != nil {
+			t.Fatal(i, err)
+		}
+		if total != 12 || len(found) != 1 || found[0].Title != "a" {
+			t.Errorf("%d: get %d %+v", i, total, found)
+		}
+		if stmts := takeFakeStmts(dsn); !reflect.DeepEqual(stmts, c.sqls) {
+			t.Errorf("%d: want\n%v\nget\n%v", i, c.sqls, stmts)
+		}
+		// the statement is reset after the count
+		if session.Statement.RawSQL != "" || session.Statement.JoinStr() != "" || session.Statement.LimitN != 0 {
+			t.Errorf("%d: the statement is not reset", i)
+		}
+		session.Close()
+	}
+}
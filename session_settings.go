@@ -54,6 +54,12 @@ func (session *Session) NoAutoCondition(no ...bool) *Session {
 	return session
 }
 
+// NoVersionCheck disables the optimistic locking of the version column
+func (session *Session) NoVersionCheck(no ...bool) *Session {
+	session.Statement.NoVersionCheck(no...)
+	return session
+}
+
 // StoreEngine is only avialble mysql dialect currently
 func (session *Session) StoreEngine(storeEngine string) *Session {
 	session.Statement.StoreEngine = storeEngine
@@ -79,3 +85,11 @@ func (session *Session) Unscoped() *Session {
 	session.Statement.Unscoped()
 	return session
 }
+
+// OnlyDeleted selects the soft deleted records only, by the struct tag
+// "deleted", instead of the other ones. The records joined by a relation
+// are still the ones which are not deleted.
+func (session *Session) OnlyDeleted() *Session {
+	session.Statement.OnlyDeleted()
+	return session
+}
@@ -0,0 +1,212 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coscms/xorm/core"
+)
+
+// SyncOptions are the opt-in changes of SyncWithOptions. Missing tables, columns
+// and indexes are always created and renamed indexes renamed; columns and
+// tables which are no longer mapped are never dropped.
+type SyncOptions struct {
+	// AlterColumns changes the type, length, nullability and default of
+	// the columns, except narrowing them, see ColumnDiff.IsNarrowing
+	AlterColumns bool
+	// AllowNarrowing lets AlterColumns narrow the columns too, which may
+	// truncate or reject their values
+	AllowNarrowing bool
+	// DropIndexes drops the indexes which are no longer declared
+	DropIndexes bool
+	// DryRun returns the planned statements without executing them
+	DryRun bool
+}
+
+// SyncWithOptions synchronizes the database with the structs like Sync2 does,
+// and applies the changes Sync2 only warns about when the options ask for it.
+// The executed, or planned when DryRun, statements are returned.
+func (session *Session) SyncWithOptions(opts SyncOptions, beans ...interface{}) ([]string, error) {
+	defer session.resetStatement()
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+
+	diff, err := session.Engine.Diff(beans...)
+	if err != nil {
+		return nil, err
+	}
+	sqls := session.Engine.syncPlan(diff, opts)
+	if opts.DryRun || len(sqls) == 0 {
+		return sqls, nil
+	}
+
+	// DDL of MySQL and Oracle commits implicitly
+	useTx := session.IsAutoCommit &&
+		session.Engine.dialect.DBType() != core.MYSQL &&
+		session.Engine.dialect.DBType() != core.ORACLE
+	if useTx {
+		if err = session.Begin(); err != nil {
+			return nil, err
+		}
+	}
+	for i, sqlStr := range sqls {
+		if strings.HasPrefix(sqlStr, "--") {
+			continue
+		}
+		if _, err = session.exec(sqlStr); err != nil {
+			if useTx {
+				session.Rollback()
+			}
+			return sqls[:i], err
+		}
+	}
+	if useTx {
+		if err = session.Commit(); err != nil {
+			return nil, err
+		}
+	}
+	return sqls, nil
+}
+
+// syncPlan returns the statements SyncWithOptions runs for the diff
+func (engine *Engine) syncPlan(diff *SchemaDiff, opts SyncOptions) []string {
+	dialect := engine.dialect
+	var sqls []string
+
+	for _, table := range diff.MissingTables {
+		sqls = append(sqls, dialect.CreateTableSql(table, table.Name, "", ""))
+		for _, name := range sortedIndexNames(table.Indexes) {
+			sqls = append(sqls, dialect.CreateIndexSql(table.Name, table.Indexes[name]))
+		}
+	}
+
+	for _, tableDiff := range diff.Tables {
+		var changedColumns []*ColumnDiff
+		if opts.AlterColumns {
+			for _, colDiff := range tableDiff.ChangedColumns {
+				if colDiff.IsNarrowing() && !opts.AllowNarrowing {
+					engine.logger.Warnf("Table %s column %s will not be narrowed: %s", tableDiff.Name, colDiff.Name, colDiff)
+					continue
+				}
+				changedColumns = append(changedColumns, colDiff)
+			}
+		} else {
+			for _, colDiff := range tableDiff.ChangedColumns {
+				engine.logger.Warnf("Table %s column %s is changed: %s", tableDiff.Name, colDiff.Name, colDiff)
+			}
+		}
+		if tableDiff.PrimaryKeyChanged {
+			engine.logger.Warnf("Table %s primary key is %v, struct primary key is %v",
+				tableDiff.Name, tableDiff.Actual.PrimaryKeys, tableDiff.Expected.PrimaryKeys)
+		}
+
+		if dialect.DBType() == core.SQLITE && len(changedColumns) > 0 {
+			sqls = append(sqls, engine.rebuildTableSQL(tableDiff, changedColumns, opts)...)
+			continue
+		}
+
+		if opts.DropIndexes {
+			for _, index := range tableDiff.ExtraIndexes {
+				sqls = append(sqls, dialect.DropIndexSql(tableDiff.Name, index))
+			}
+		}
+		for _, rename := range tableDiff.RenamedIndexes {
+			sqls = append(sqls, renameIndexSQL(dialect, tableDiff.Name, rename)...)
+		}
+		for _, c := range tableDiff.MissingColumns {
+			col := *c
+			sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ADD %v", dialect.Quote(tableDiff.Name),
+				strings.TrimSpace(col.StringNoPk(dialect))))
+		}
+		for _, colDiff := range changedColumns {
+			sqls = append(sqls, alterColumnSQL(dialect, tableDiff.Name, colDiff)...)
+		}
+		for _, index := range tableDiff.MissingIndexes {
+			sqls = append(sqls, dialect.CreateIndexSql(tableDiff.Name, index))
+		}
+	}
+	return sqls
+}
+
+// rebuildTableSQL returns the statements rebuilding a SQLite table, which
+// cannot alter its columns: the data is copied to a new table having the
+// expected columns plus the extra ones of the database, then the new table
+// replaces the old one. The changed columns which are not in changedColumns
+// keep their definition of the database.
+func (engine *Engine) rebuildTableSQL(diff *TableDiff, changedColumns []*ColumnDiff, opts SyncOptions) []string {
+	dialect := engine.dialect
+	tmpName := "_xorm_rebuild_" + diff.Name
+
+	kept := make(map[string]bool)
+	for _, colDiff := range diff.ChangedColumns {
+		kept[strings.ToLower(colDiff.Name)] = true
+	}
+	for _, colDiff := range changedColumns {
+		delete(kept, strings.ToLower(colDiff.Name))
+	}
+
+	table := core.NewEmptyTable()
+	table.Name = diff.Name
+	for _, c := range diff.Expected.Columns() {
+		col := *c
+		if kept[strings.ToLower(c.Name)] {
+			col = *diff.Actual.GetColumn(c.Name)
+		}
+		table.AddColumn(&col)
+	}
+	for _, c := range diff.ExtraColumns {
+		col := *c
+		col.IsPrimaryKey = false
+		col.IsAutoIncrement = false
+		table.AddColumn(&col)
+	}
+
+	var copyCols []string
+	for _, colName := range table.ColumnsSeq() {
+		if diff.Actual.GetColumn(colName) != nil {
+			copyCols = append(copyCols, dialect.Quote(colName))
+		}
+	}
+
+	sqls := []string{
+		dialect.CreateTableSql(table, tmpName, "", ""),
+		fmt.Sprintf("INSERT INTO %v (%v) SELECT %v FROM %v", dialect.Quote(tmpName),
+			strings.Join(copyCols, ", "), strings.Join(copyCols, ", "), dialect.Quote(diff.Name)),
+		fmt.Sprintf("DROP TABLE %v", dialect.Quote(diff.Name)),
+		fmt.Sprintf("ALTER TABLE %v RENAME TO %v", dialect.Quote(tmpName), dialect.Quote(diff.Name)),
+	}
+
+	// the indexes are dropped with the old table
+	indexes := diff.Expected.Indexes
+	for _, name := range sortedIndexNames(indexes) {
+		sqls = append(sqls, dialect.CreateIndexSql(diff.Name, indexes[name]))
+	}
+	if !opts.DropIndexes {
+		for _, index := range diff.ExtraIndexes {
+			sqls = append(sqls, fmt.Sprintf("CREATE%s INDEX %v ON %v (%v)", uniqueStr(index),
+				dialect.Quote(dbIndexName(dialect, diff.Name, index)), dialect.Quote(diff.Name),
+				dialect.Quote(strings.Join(index.Cols, dialect.Quote(",")))))
+		}
+	}
+	return sqls
+}
+
+func uniqueStr(index *core.Index) string {
+	if index.Type == core.UniqueType {
+		return " UNIQUE"
+	}
+	return ""
+}
+
+// SyncWithOptions synchronizes the database with the structs, see
+// Session.SyncWithOptions
+func (engine *Engine) SyncWithOptions(opts SyncOptions, beans ...interface{}) ([]string, error) {
+	session := engine.NewSession()
+	defer session.Close()
+	return session.SyncWithOptions(opts, beans...)
+}
@@ -0,0 +1,215 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+func testColumn(name, sqlType string, length int) *core.Column {
+	return core.NewColumn(name, name, core.SQLType{Name: sqlType}, length, 0, true)
+}
+
+func testTable(name string, cols ...*core.Column) *core.Table {
+	table := core.NewEmptyTable()
+	table.Name = name
+	for _, col := range cols {
+		table.AddColumn(col)
+	}
+	return table
+}
+
+func testIndex(name string, indexType int, cols ...string) *core.Index {
+	index := core.NewIndex(name, indexType)
+	index.AddColumn(cols...)
+	return index
+}
+
+func TestColumnDiffIsNarrowing(t *testing.T) {
+	decimal := func(sqlType string, precision, scale int) *core.Column {
+		col := testColumn("a", sqlType, precision)
+		col.Length2 = scale
+		return col
+	}
+
+	var cases = []struct {
+		from, to  *core.Column
+		narrowing bool
+	}{
+		{testColumn("a", core.BigInt, 0), testColumn("a", core.Int, 0), true},
+		{testColumn("a", core.Int, 0), testColumn("a", core.BigInt, 0), false},
+		{testColumn("a", core.Integer, 0), testColumn("a", core.Int, 0), false},
+		{testColumn("a", core.Text, 0), testColumn("a", core.Varchar, 255), true},
+		{testColumn("a", core.Varchar, 255), testColumn("a", core.Text, 0), false},
+		{testColumn("a", core.Varchar, 50), testColumn("a", core.Varchar, 20), true},
+		{testColumn("a", core.Varchar, 20), testColumn("a", core.Varchar, 50), false},
+		{testColumn("a", core.Char, 20), testColumn("a", core.Varchar, 10), true},
+		{testColumn("a", core.DateTime, 0), testColumn("a", core.Date, 0), true},
+		{testColumn("a", core.Date, 0), testColumn("a", core.DateTime, 0), false},
+		{testColumn("a", core.Double, 0), testColumn("a", core.Float, 0), true},
+		{testColumn("a", core.Int, 0), testColumn("a", core.Text, 0), false},
+		{testColumn("a", core.Varchar, 20), testColumn("a", core.Int, 0), true},
+		{testColumn("a", core.Json, 0), testColumn("a", core.Varchar, 255), true},
+		// a DECIMAL is not an integer type
+		{testColumn("a", core.Int, 0), decimal(core.Decimal, 5, 0), true},
+		{testColumn("a", core.Int, 0), decimal(core.Decimal, 10, 0), false},
+		{testColumn("a", core.Int, 0), decimal(core.Decimal, 10, 2), true},
+		{testColumn("a", core.BigInt, 0), decimal(core.Numeric, 20, 0), false},
+		{decimal(core.Decimal, 10, 0), testColumn("a", core.BigInt, 0), true},
+		{decimal(core.Decimal, 10, 2), decimal(core.Decimal, 12, 2), false},
+		{decimal(core.Decimal, 10, 2), decimal(core.Decimal, 12, 4), false},
+		{decimal(core.Decimal, 10, 2), decimal(core.Decimal, 10, 4), true},
+		{decimal(core.Decimal, 10, 2), decimal(core.Decimal, 12, 1), true},
+		{decimal(core.Decimal, 10, 2), decimal(core.Numeric, 8, 2), true},
+	}
+
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	for _, c := range cases {
+		diff := diffColumn(engine.dialect, c.to, c.from)
+		if diff == nil {
+			t.Fatal("no change", columnTypeString(c.from), columnTypeString(c.to))
+		}
+		if narrowing := diff.IsNarrowing(); narrowing != c.narrowing {
+			t.Errorf("%s: want narrowing %v, get %v", diff, c.narrowing, narrowing)
+		}
+	}
+}
+
+func TestSyncPlan(t *testing.T) {
+	userTable := func(nameType string, nameLength int, indexes ...*core.Index) *core.Table {
+		id := testColumn("id", core.BigInt, 0)
+		id.IsPrimaryKey = true
+		id.Nullable = false
+		table := testTable("user", id, testColumn("name", nameType, nameLength), testColumn("age", core.Int, 0))
+		for _, index := range indexes {
+			table.AddIndex(index)
+		}
+		return table
+	}
+
+	var cases = []struct {
+		name     string
+		dbType   core.DbType
+		opts     SyncOptions
+		expected *core.Table
+		actual   *core.Table
+		sqls     []string
+	}{
+		{
+			"changes are only warned about",
+			core.POSTGRES, SyncOptions{},
+			userTable(core.Varchar, 50), userTable(core.Varchar, 20),
+			nil,
+		},
+		{
+			"widening",
+			core.POSTGRES, SyncOptions{AlterColumns: true},
+			userTable(core.Varchar, 50), userTable(core.Varchar, 20),
+			[]string{`ALTER TABLE "user" ALTER COLUMN "name" TYPE VARCHAR(50)`},
+		},
+		{
+			"narrowing length is skipped",
+			core.POSTGRES, SyncOptions{AlterColumns: true},
+			userTable(core.Varchar, 20), userTable(core.Varchar, 50),
+			nil,
+		},
+		{
+			"narrowing type is skipped",
+			core.POSTGRES, SyncOptions{AlterColumns: true},
+			userTable(core.Varchar, 50), userTable(core.Text, 0),
+			nil,
+		},
+		{
+			"widening to a decimal",
+			core.POSTGRES, SyncOptions{AlterColumns: true},
+			userTable(core.Decimal, 10), userTable(core.Int, 0),
+			[]string{`ALTER TABLE "user" ALTER COLUMN "name" TYPE DECIMAL(10)`},
+		},
+		{
+			"narrowing to a decimal is skipped",
+			core.POSTGRES, SyncOptions{AlterColumns: true},
+			userTable(core.Decimal, 5), userTable(core.Int, 0),
+			nil,
+		},
+		{
+			"narrowing is allowed",
+			core.MYSQL, SyncOptions{AlterColumns: true, AllowNarrowing: true},
+			userTable(core.Varchar, 50), userTable(core.Text, 0),
+			[]string{"ALTER TABLE `user` MODIFY COLUMN `name` VARCHAR(50) NULL"},
+		},
+		{
+			"renamed index",
+			core.POSTGRES, SyncOptions{},
+			userTable(core.Varchar, 50, testIndex("name", core.IndexType, "name")),
+			userTable(core.Varchar, 50, testIndex("old_name", core.IndexType, "name")),
+			[]string{`ALTER INDEX "IDX_user_old_name" RENAME TO "IDX_user_name"`},
+		},
+		{
+			"extra index is kept",
+			core.MYSQL, SyncOptions{},
+			userTable(core.Varchar, 50),
+			userTable(core.Varchar, 50, testIndex("age", core.IndexType, "age")),
+			nil,
+		},
+		{
+			"extra index is dropped",
+			core.MYSQL, SyncOptions{DropIndexes: true},
+			userTable(core.Varchar, 50, testIndex("name", core.UniqueType, "name")),
+			userTable(core.Varchar, 50, testIndex("age", core.IndexType, "age")),
+			[]string{
+				"DROP INDEX `IDX_user_age` ON `user`",
+				"CREATE UNIQUE INDEX `UQE_user_name` ON `user` (`name`)",
+			},
+		},
+		{
+			"sqlite rebuild",
+			core.SQLITE, SyncOptions{AlterColumns: true},
+			userTable(core.Text, 0, testIndex("name", core.IndexType, "name")),
+			userTable(core.Int, 0, testIndex("age", core.IndexType, "age")),
+			[]string{
+				"CREATE TABLE IF NOT EXISTS `_xorm_rebuild_user` (`id` INTEGER PRIMARY KEY NOT NULL, `name` TEXT NULL, `age` INTEGER NULL)",
+				"INSERT INTO `_xorm_rebuild_user` (`id`, `name`, `age`) SELECT `id`, `name`, `age` FROM `user`",
+				"DROP TABLE `user`",
+				"ALTER TABLE `_xorm_rebuild_user` RENAME TO `user`",
+				"CREATE INDEX `IDX_user_name` ON `user` (`name`)",
+				"CREATE INDEX `IDX_user_age` ON `user` (`age`)",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		diff := DiffTables(engine.dialect, []*core.Table{c.expected}, []*core.Table{c.actual})
+		sqls := engine.syncPlan(diff, c.opts)
+		for i := range sqls {
+			sqls[i] = strings.TrimSpace(sqls[i])
+		}
+		if !(len(sqls) == 0 && len(c.sqls) == 0) && !reflect.DeepEqual(sqls, c.sqls) {
+			t.Errorf("%s: want\n%s\nget\n%s", c.name, strings.Join(c.sqls, "\n"), strings.Join(sqls, "\n"))
+		}
+		engine.Close()
+	}
+}
+
+func TestSyncPlanSQLiteRebuildKeepsNarrowedColumns(t *testing.T) {
+	engine := newFakeEngine(t, core.SQLITE)
+	defer engine.Close()
+
+	// name is widened, age is narrowed
+	expected := testTable("user", testColumn("name", core.Text, 0), testColumn("age", core.Int, 0))
+	actual := testTable("user", testColumn("name", core.Int, 0), testColumn("age", core.Double, 0))
+	diff := DiffTables(engine.dialect, []*core.Table{expected}, []*core.Table{actual})
+
+	sqls := engine.syncPlan(diff, SyncOptions{AlterColumns: true, DryRun: true})
+	want := "CREATE TABLE IF NOT EXISTS `_xorm_rebuild_user` (`name` TEXT NULL, `age` REAL NULL)"
+	if len(sqls) == 0 || strings.TrimSpace(sqls[0]) != want {
+		t.Fatal("want", want, "get", sqls)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+type ContextUser struct {
+	Id   int64
+	Name string
+}
+
+func TestSessionContext(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var cases = []struct {
+		name string
+		fn   func(*Session) error
+	}{
+		{"Find", func(s *Session) error {
+			var users []ContextUser
+			return s.Find(&users)
+		}},
+		{"Exec", func(s *Session) error {
+			_, err := s.Exec("UPDATE context_user SET name = ?", "a")
+			return err
+		}},
+		{"Begin", (*Session).Begin},
+		// the metadata of the database is read with the context too
+		{"Sync2", func(s *Session) error {
+			return s.Sync2(new(ContextUser))
+		}},
+		{"Dump", func(s *Session) error {
+			return s.DumpWithOptions(ioutil.Discard, DumpOptions{})
+		}},
+	}
+	for _, c := range cases {
+		session := engine.NewSession()
+		if err := c.fn(session.Context(ctx)); err != context.Canceled {
+			t.Errorf("%s: want %v, get %v", c.name, context.Canceled, err)
+		}
+		session.Close()
+		if stmts := takeFakeStmts(dsn); len(stmts) > 0 {
+			t.Errorf("%s: get the statements %v", c.name, stmts)
+		}
+	}
+
+	var users []ContextUser
+	if err := engine.Context(context.Background()).Find(&users); err != nil {
+		t.Fatal(err)
+	}
+	if stmts := takeFakeStmts(dsn); len(stmts) != 1 {
+		t.Errorf("want a query, get %v", stmts)
+	}
+}
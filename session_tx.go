@@ -1,37 +1,121 @@
 package xorm
 
-// Begin a transaction
+import (
+	"database/sql"
+	"fmt"
+)
+
+// savePoint is a nested transaction with the processors to call after the
+// commit when it began
+type savePoint struct {
+	name             string
+	afterInsertBeans map[interface{}]*[]func(interface{})
+	afterUpdateBeans map[interface{}]*[]func(interface{})
+	afterDeleteBeans map[interface{}]*[]func(interface{})
+}
+
+// Begin a transaction, or a nested one when a transaction is open: a
+// savepoint is created, which Rollback rolls back to and Commit releases
 func (session *Session) Begin() error {
+	return session.BeginTx(nil)
+}
+
+// BeginTx begins a transaction with the isolation level and the read-only
+// mode of the options, which are ignored by a nested transaction
+func (session *Session) BeginTx(opts *sql.TxOptions) error {
 	if session.IsAutoCommit {
-		tx, err := session.DB().Begin()
+		tx, err := session.DB().BeginTx(session.ctx, opts)
 		if err != nil {
 			return err
 		}
 		session.IsAutoCommit = false
 		session.IsCommitedOrRollbacked = false
 		session.Tx = tx
+		session.savePoints = nil
 		session.saveLastSQL("BEGIN TRANSACTION")
+		return nil
 	}
+
+	sp := savePoint{
+		name:             fmt.Sprintf("xorm_sp_%d", len(session.savePoints)+1),
+		afterInsertBeans: copyProcessorBeans(session.afterInsertBeans),
+		afterUpdateBeans: copyProcessorBeans(session.afterUpdateBeans),
+		afterDeleteBeans: copyProcessorBeans(session.afterDeleteBeans),
+	}
+	if err := session.execSavePoint(session.Engine.dialect.SavePointSql(sp.name)); err != nil {
+		return err
+	}
+	session.savePoints = append(session.savePoints, sp)
 	return nil
 }
 
-// Rollback When using transaction, you can rollback if any error
+// execSavePoint runs a statement of a savepoint in the transaction
+func (session *Session) execSavePoint(sqlStr string) error {
+	if sqlStr == "" {
+		return nil
+	}
+	session.saveLastSQL(sqlStr)
+	_, err := session.Tx.ExecContext(session.ctx, sqlStr)
+	return err
+}
+
+// copyProcessorBeans returns a copy of the beans waiting for the commit
+func copyProcessorBeans(beans map[interface{}]*[]func(interface{})) map[interface{}]*[]func(interface{}) {
+	c := make(map[interface{}]*[]func(interface{}), len(beans))
+	for bean, closuresPtr := range beans {
+		if closuresPtr != nil {
+			closures := append([]func(interface{}){}, *closuresPtr...)
+			closuresPtr = &closures
+		}
+		c[bean] = closuresPtr
+	}
+	return c
+}
+
+// Rollback When using transaction, you can rollback if any error. In a nested
+// transaction, the changes since its Begin are rolled back only.
 func (session *Session) Rollback() error {
+	if n := len(session.savePoints); n > 0 && !session.IsAutoCommit && !session.IsCommitedOrRollbacked {
+		sp := session.savePoints[n-1]
+		session.savePoints = session.savePoints[:n-1]
+		// the processors of the rolled back beans must not be called
+		session.afterInsertBeans = sp.afterInsertBeans
+		session.afterUpdateBeans = sp.afterUpdateBeans
+		session.afterDeleteBeans = sp.afterDeleteBeans
+		return session.execSavePoint(session.Engine.dialect.RollbackToSavePointSql(sp.name))
+	}
 	if !session.IsAutoCommit && !session.IsCommitedOrRollbacked {
 		session.saveLastSQL(session.Engine.dialect.RollBackStr())
-		session.IsCommitedOrRollbacked = true
+		defer session.endTx()
 		return session.Tx.Rollback()
 	}
 	return nil
 }
 
-// Commit When using transaction, Commit will commit all operations.
+// endTx returns the session to auto commit once its transaction is committed
+// or rolled back, so that the next Begin starts a new one
+func (session *Session) endTx() {
+	session.IsAutoCommit = true
+	session.IsCommitedOrRollbacked = false
+	session.Tx = nil
+	session.savePoints = nil
+}
+
+// Commit When using transaction, Commit will commit all operations. In a
+// nested transaction, its savepoint is released and the operations are
+// committed with the enclosing transaction.
 func (session *Session) Commit() error {
+	if n := len(session.savePoints); n > 0 && !session.IsAutoCommit && !session.IsCommitedOrRollbacked {
+		sp := session.savePoints[n-1]
+		session.savePoints = session.savePoints[:n-1]
+		return session.execSavePoint(session.Engine.dialect.ReleaseSavePointSql(sp.name))
+	}
 	if !session.IsAutoCommit && !session.IsCommitedOrRollbacked {
 		session.saveLastSQL("COMMIT")
-		session.IsCommitedOrRollbacked = true
 		var err error
-		if err = session.Tx.Commit(); err == nil {
+		err = session.Tx.Commit()
+		session.endTx()
+		if err == nil {
 			// handle processors after tx committed
 
 			closureCallFunc := func(closuresPtr *[]func(interface{}), bean interface{}) {
@@ -0,0 +1,54 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+func TestSessionTxReuse(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+	session := engine.NewSession()
+	defer session.Close()
+
+	// a transaction can begin again after the previous one ended
+	for _, end := range []func() error{session.Commit, session.Rollback, session.Commit} {
+		if err := session.Begin(); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Begin(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.Exec("UPDATE user SET name = ?", "a"); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if err := end(); err != nil {
+			t.Fatal(err)
+		}
+		if !session.IsAutoCommit || session.Tx != nil || len(session.savePoints) > 0 {
+			t.Fatalf("the session is left in a transaction: %v %v", session.IsAutoCommit, session.Tx)
+		}
+		want := []string{"SAVEPOINT xorm_sp_1", "UPDATE user SET name = ?", "RELEASE SAVEPOINT xorm_sp_1"}
+		if stmts := takeFakeStmts(dsn); !reflect.DeepEqual(stmts, want) {
+			t.Errorf("want %v, get %v", want, stmts)
+		}
+	}
+
+	// ending a transaction twice does nothing
+	if err := session.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.Exec("UPDATE user SET name = ?", "b"); err != nil {
+		t.Fatal(err)
+	}
+}
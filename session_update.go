@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/coscms/xorm/builder"
 	"github.com/coscms/xorm/core"
@@ -41,7 +42,7 @@ func (session *Session) cacheUpdate(sqlStr string, args ...interface{}) error {
 	session.Engine.TLogger.Cache.Debug("[Update] get cache sql", newsql, args[nStart:])
 	ids, err := core.GetCacheSql(cacher, tableName, newsql, args[nStart:])
 	if err != nil {
-		rows, err := session.DB().Query(newsql, args[nStart:]...)
+		rows, err := session.dbQuery(session.DB(), newsql, args[nStart:]...)
 		if err != nil {
 			return err
 		}
@@ -145,6 +146,13 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 		defer session.Close()
 	}
 
+	if len(session.Statement.cascadeWrites) > 0 {
+		return session.cascadeUpdate(bean, condiBean...)
+	}
+	return session.update(bean, condiBean...)
+}
+
+func (session *Session) update(bean interface{}, condiBean ...interface{}) (int64, error) {
 	v := rValue(bean)
 	t := v.Type()
 
@@ -172,7 +180,7 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 		}
 
 		if session.Statement.ColumnStr == "" {
-			colNames, args = buildUpdates(session.Engine, session.Statement.RefTable, bean, false, false,
+			colNames, args = buildUpdates(session.Engine, session.Statement.RefTable, bean, !session.Statement.checkVersion, false,
 				false, false, session.Statement.allUseBool, session.Statement.useAllCols,
 				session.Statement.mustColumnMap, session.Statement.nullableMap,
 				session.Statement.columnMap, true, session.Statement.unscoped)
@@ -229,6 +237,15 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	for _, v := range exprColumns {
 		colNames = append(colNames, session.Engine.Quote(v.colName)+" = "+v.expr)
 	}
+	//for update action to like "column = JSON_SET(column, path, ?)"
+	for _, v := range session.Statement.jsonColumns {
+		exprSQL, exprArgs, err := session.Statement.condToSQL(builder.JSONSet(v.colName, v.values))
+		if err != nil {
+			return 0, err
+		}
+		colNames = append(colNames, session.Engine.Quote(v.colName)+" = "+exprSQL)
+		args = append(args, exprArgs...)
+	}
 
 	session.Statement.processIdParam()
 
@@ -247,18 +264,30 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	var sqlStr string
 	var condArgs []interface{}
 	var condSQL string
-	cond := session.Statement.cond.And(autoCond)
+	cond := session.Statement.cond.And(autoCond, session.Statement.refScopeCond())
 
 	doIncVer := false
 	var verValue *reflect.Value
+	var verCondValue interface{}
+	var verTime time.Time
 	if table != nil && table.Version != "" && session.Statement.checkVersion {
 		verValue, err = table.VersionColumn().ValueOf(bean)
 		if err != nil {
 			return 0, err
 		}
 
-		cond = cond.And(builder.Eq{session.Engine.Quote(table.Version): verValue.Interface()})
-		condSQL, condArgs, _ = builder.ToSQL(cond)
+		verCol := table.VersionColumn()
+		verCondValue = session.versionCondValue(verCol, *verValue)
+		verSet := session.Engine.Quote(table.Version) + " = " + session.Engine.Quote(table.Version) + " + 1"
+		if verCol.SQLType.IsTime() {
+			var val interface{}
+			val, verTime = session.nextTimeVersion(verCol, *verValue)
+			verSet = session.Engine.Quote(table.Version) + " = ?"
+			args = append(args, val)
+		}
+
+		cond = cond.And(builder.Eq{session.Engine.Quote(table.Version): verCondValue})
+		condSQL, condArgs, _ = session.Statement.dialectCondToSQL(cond, nil)
 
 		if len(condSQL) > 0 {
 			condSQL = "WHERE " + condSQL
@@ -271,12 +300,12 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 		sqlStr = fmt.Sprintf("UPDATE %v SET %v, %v %v",
 			session.Engine.Quote(session.Statement.TableName()),
 			strings.Join(colNames, ", "),
-			session.Engine.Quote(table.Version)+" = "+session.Engine.Quote(table.Version)+" + 1",
+			verSet,
 			condSQL)
 
 		doIncVer = true
 	} else {
-		condSQL, condArgs, _ = builder.ToSQL(cond)
+		condSQL, condArgs, _ = session.Statement.dialectCondToSQL(cond, nil)
 		if len(condSQL) > 0 {
 			condSQL = "WHERE " + condSQL
 		}
@@ -295,8 +324,18 @@ func (session *Session) Update(bean interface{}, condiBean ...interface{}) (int6
 	if err != nil {
 		return 0, err
 	} else if doIncVer {
+		if affected, err := res.RowsAffected(); err == nil && affected == 0 {
+			if err = session.optimisticLockError(table, bean, verCondValue); err != nil {
+				cleanupProcessorsClosures(&session.afterClosures)
+				return 0, err
+			}
+		}
 		if verValue != nil && verValue.IsValid() && verValue.CanSet() {
-			verValue.SetInt(verValue.Int() + 1)
+			if table.VersionColumn().SQLType.IsTime() {
+				setColumnTime(bean, table.VersionColumn(), verTime)
+			} else {
+				verValue.SetInt(verValue.Int() + 1)
+			}
 		}
 	}
 
@@ -0,0 +1,50 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"testing"
+
+	"github.com/coscms/xorm/builder"
+	"github.com/coscms/xorm/core"
+)
+
+type UpdateUser struct {
+	Id      int64
+	Name    string
+	Version int `xorm:"version"`
+}
+
+func TestUpdateCondKeys(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	// the keys of the conditions of Update are written as they are given
+	var cases = []struct {
+		run func() (int64, error)
+		sql string
+	}{
+		{func() (int64, error) {
+			return engine.Cols("name").Where(builder.Eq{"LOWER(name)": "a"}).Update(&UpdateUser{Name: "b"})
+		}, "UPDATE `update_user` SET `name` = ?, `version` = `version` + 1 WHERE LOWER(name)=? AND `version`=?"},
+		{func() (int64, error) {
+			return engine.Cols("name").Where(builder.Eq{"name": "a"}).Update(&UpdateUser{Name: "b", Version: 2})
+		}, "UPDATE `update_user` SET `name` = ?, `version` = `version` + 1 WHERE name=? AND `version`=?"},
+		{func() (int64, error) {
+			return engine.Table("update_user").Where(builder.Eq{"LOWER(name)": "a"}).Update(map[string]interface{}{"name": "b"})
+		}, "UPDATE `update_user` SET `name` = ? WHERE LOWER(name)=?"},
+	}
+
+	for _, c := range cases {
+		takeFakeStmts(dsn)
+		if _, err := c.run(); err != nil {
+			t.Fatal(err)
+		}
+		if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != c.sql {
+			t.Errorf("want %s, get %v", c.sql, stmts)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package xorm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/xorm/core"
+)
+
+// OnConflictUpdate only updates the given columns when Upsert hits an existing
+// record, by default all the inserted columns but the conflict columns and
+// the created columns are updated
+func (session *Session) OnConflictUpdate(columns ...string) *Session {
+	session.Statement.upsertCols = append(session.Statement.upsertCols, columns...)
+	return session
+}
+
+// OnConflictDoNothing keeps the existing record untouched when Upsert hits it
+func (session *Session) OnConflictDoNothing() *Session {
+	session.Statement.upsertDoNothing = true
+	return session
+}
+
+// Upsert inserts the bean, or updates the existing record when the insert
+// conflicts on conflictCols. The primary keys are used when no conflict
+// columns are given; MySQL always resolves the conflict on any unique key.
+func (session *Session) Upsert(bean interface{}, conflictCols ...string) (int64, error) {
+	defer session.resetStatement()
+	if session.IsAutoClose {
+		defer session.Close()
+	}
+
+	return session.innerUpsert(bean, conflictCols)
+}
+
+func (session *Session) innerUpsert(bean interface{}, conflictCols []string) (int64, error) {
+	session.Statement.setRefValue(rValue(bean))
+	tableName := session.Statement.TableName()
+	if len(tableName) <= 0 {
+		return 0, ErrTableNotFound
+	}
+	table := session.Statement.RefTable
+	if len(conflictCols) == 0 {
+		conflictCols = table.PrimaryKeys
+	}
+	if len(conflictCols) == 0 {
+		return 0, errors.New("Upsert needs conflict columns or primary keys")
+	}
+
+	for _, closure := range session.beforeClosures {
+		closure(bean)
+	}
+	cleanupProcessorsClosures(&session.beforeClosures)
+
+	if processor, ok := interface{}(bean).(BeforeInsertProcessor); ok {
+		processor.BeforeInsert()
+	}
+	if err := session.setScopeValues(table, rValue(bean)); err != nil {
+		return 0, err
+	}
+
+	colNames, args, err := genCols(table, session, bean, false, false)
+	if err != nil {
+		return 0, err
+	}
+	// values of the columns, ? or an expression
+	colValues := make([]string, len(colNames))
+	for i := range colValues {
+		colValues[i] = "?"
+	}
+	for _, v := range session.Statement.getExpr() {
+		for i, colName := range colNames {
+			if colName == v.colName {
+				colNames = append(colNames[:i], colNames[i+1:]...)
+				colValues = append(colValues[:i], colValues[i+1:]...)
+				args = append(args[:i], args[i+1:]...)
+				break
+			}
+		}
+		colNames = append(colNames, v.colName)
+		colValues = append(colValues, v.expr)
+	}
+	if len(colNames) == 0 {
+		return 0, errors.New("No column to upsert")
+	}
+
+	updateCols := session.upsertUpdateCols(table, colNames, conflictCols)
+
+	var sqlStr string
+	switch session.Engine.dialect.DBType() {
+	case core.MYSQL:
+		sqlStr = session.genMysqlUpsertSQL(table, colNames, colValues, conflictCols, updateCols)
+	case core.POSTGRES, core.SQLITE:
+		sqlStr = session.genOnConflictSQL(table, colNames, colValues, conflictCols, updateCols)
+	case core.MSSQL, core.ORACLE:
+		sqlStr, err = session.genMergeSQL(table, colNames, colValues, conflictCols, updateCols)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, ErrNotImplemented
+	}
+
+	var affected int64
+	if session.Engine.dialect.DBType() == core.POSTGRES && len(table.AutoIncrement) > 0 {
+		res, err := session.query(sqlStr+" RETURNING "+session.Engine.Quote(table.AutoIncrement), args...)
+		if err != nil {
+			return 0, err
+		}
+		affected = int64(len(res))
+		if affected > 0 {
+			id, err := strconv.ParseInt(string(res[0][table.AutoIncrement]), 10, 64)
+			if err == nil && id > 0 {
+				session.setUpsertAutoIncr(table, bean, id)
+			}
+		}
+	} else {
+		res, err := session.exec(sqlStr, args...)
+		if err != nil {
+			return 0, err
+		}
+		affected, err = res.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		// MySQL reports 1 for an inserted record and 2 for an updated one
+		if session.Engine.dialect.DBType() == core.MYSQL && affected == 1 && len(table.AutoIncrement) > 0 {
+			if id, err := res.LastInsertId(); err == nil && id > 0 {
+				session.setUpsertAutoIncr(table, bean, id)
+			}
+		}
+	}
+
+	if cacher := session.Engine.getCacher2(table); cacher != nil && session.Statement.UseCache {
+		session.Engine.TLogger.Cache.Debug("[cache] clear upsert:", tableName)
+		cacher.ClearIds(tableName)
+		cacher.ClearBeans(tableName)
+	}
+
+	session.handleAfterInsertProcessor(bean)
+
+	return affected, nil
+}
+
+// upsertUpdateCols returns the columns to be updated on conflict
+func (session *Session) upsertUpdateCols(table *core.Table, colNames, conflictCols []string) []string {
+	if session.Statement.upsertDoNothing {
+		return nil
+	}
+
+	isConflict := make(map[string]bool, len(conflictCols))
+	for _, col := range conflictCols {
+		isConflict[strings.ToLower(col)] = true
+	}
+
+	var wanted map[string]bool
+	if len(session.Statement.upsertCols) > 0 {
+		wanted = make(map[string]bool, len(session.Statement.upsertCols))
+		for _, col := range session.Statement.upsertCols {
+			wanted[strings.ToLower(col)] = true
+		}
+	}
+
+	updateCols := make([]string, 0, len(colNames))
+	for _, colName := range colNames {
+		lColName := strings.ToLower(colName)
+		if isConflict[lColName] {
+			continue
+		}
+		col := table.GetColumn(colName)
+		if col != nil {
+			if col.IsCreated || col.IsAutoIncrement || (col.IsVersion && session.Statement.checkVersion) {
+				continue
+			}
+			if wanted != nil && !wanted[lColName] && !(col.IsUpdated && session.Statement.UseAutoTime) {
+				continue
+			}
+		} else if wanted != nil && !wanted[lColName] {
+			continue
+		}
+		updateCols = append(updateCols, colName)
+	}
+	if len(updateCols) > 0 && table.Version != "" && session.Statement.checkVersion {
+		updateCols = append(updateCols, table.Version)
+	}
+	return updateCols
+}
+
+func (session *Session) isUpsertVersion(table *core.Table, colName string) bool {
+	return table.Version != "" && session.Statement.checkVersion && colName == table.Version &&
+		!isTimeVersion(table.VersionColumn(), session)
+}
+
+func (session *Session) genInsertHead(colNames []string) string {
+	return fmt.Sprintf("INSERT INTO %s (%v%v%v)",
+		session.Engine.Quote(session.Statement.TableName()),
+		session.Engine.QuoteStr(),
+		strings.Join(colNames, session.Engine.Quote(", ")),
+		session.Engine.QuoteStr())
+}
+
+func (session *Session) genMysqlUpsertSQL(table *core.Table, colNames, colValues, conflictCols, updateCols []string) string {
+	sets := make([]string, 0, len(updateCols))
+	for _, colName := range updateCols {
+		quoted := session.Engine.Quote(colName)
+		if session.isUpsertVersion(table, colName) {
+			sets = append(sets, quoted+" = "+quoted+" + 1")
+		} else {
+			sets = append(sets, quoted+" = VALUES("+quoted+")")
+		}
+	}
+	if len(sets) == 0 {
+		quoted := session.Engine.Quote(conflictCols[0])
+		sets = append(sets, quoted+" = "+quoted)
+	}
+	return session.genInsertHead(colNames) + " VALUES (" + strings.Join(colValues, ", ") +
+		") ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", ")
+}
+
+func (session *Session) genOnConflictSQL(table *core.Table, colNames, colValues, conflictCols, updateCols []string) string {
+	quotedConflicts := make([]string, len(conflictCols))
+	for i, colName := range conflictCols {
+		quotedConflicts[i] = session.Engine.Quote(colName)
+	}
+	sqlStr := session.genInsertHead(colNames) + " VALUES (" + strings.Join(colValues, ", ") +
+		") ON CONFLICT (" + strings.Join(quotedConflicts, ", ") + ")"
+	if len(updateCols) == 0 {
+		return sqlStr + " DO NOTHING"
+	}
+
+	quotedTable := session.Engine.Quote(session.Statement.TableName())
+	sets := make([]string, 0, len(updateCols))
+	for _, colName := range updateCols {
+		quoted := session.Engine.Quote(colName)
+		if session.isUpsertVersion(table, colName) {
+			sets = append(sets, quoted+" = "+quotedTable+"."+quoted+" + 1")
+		} else {
+			sets = append(sets, quoted+" = excluded."+quoted)
+		}
+	}
+	return sqlStr + " DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+func (session *Session) genMergeSQL(table *core.Table, colNames, colValues, conflictCols, updateCols []string) (string, error) {
+	isOracle := session.Engine.dialect.DBType() == core.ORACLE
+
+	sources := make([]string, len(colNames))
+	inserts := make([]string, len(colNames))
+	hasCol := make(map[string]bool, len(colNames))
+	for i, colName := range colNames {
+		quoted := session.Engine.Quote(colName)
+		if isOracle {
+			sources[i] = colValues[i] + " " + quoted
+		} else {
+			sources[i] = colValues[i] + " AS " + quoted
+		}
+		inserts[i] = "S." + quoted
+		hasCol[strings.ToLower(colName)] = true
+	}
+
+	ons := make([]string, len(conflictCols))
+	for i, colName := range conflictCols {
+		if !hasCol[strings.ToLower(colName)] {
+			return "", fmt.Errorf("Upsert conflict column %s has no value", colName)
+		}
+		quoted := session.Engine.Quote(colName)
+		ons[i] = "T." + quoted + " = S." + quoted
+	}
+
+	quotedTable := session.Engine.Quote(session.Statement.TableName())
+	var sqlStr string
+	if isOracle {
+		sqlStr = "MERGE INTO " + quotedTable + " T USING (SELECT " + strings.Join(sources, ", ") + " FROM DUAL) S"
+	} else {
+		sqlStr = "MERGE INTO " + quotedTable + " WITH (HOLDLOCK) AS T USING (SELECT " + strings.Join(sources, ", ") + ") AS S"
+	}
+	sqlStr += " ON (" + strings.Join(ons, " AND ") + ")"
+
+	if len(updateCols) > 0 {
+		sets := make([]string, 0, len(updateCols))
+		for _, colName := range updateCols {
+			quoted := session.Engine.Quote(colName)
+			if session.isUpsertVersion(table, colName) {
+				sets = append(sets, "T."+quoted+" = T."+quoted+" + 1")
+			} else {
+				sets = append(sets, "T."+quoted+" = S."+quoted)
+			}
+		}
+		sqlStr += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+	}
+
+	sqlStr += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%v%v%v) VALUES (%v)",
+		session.Engine.QuoteStr(),
+		strings.Join(colNames, session.Engine.Quote(", ")),
+		session.Engine.QuoteStr(),
+		strings.Join(inserts, ", "))
+	if !isOracle {
+		// MSSQL requires MERGE to be terminated by a semicolon
+		sqlStr += ";"
+	}
+	return sqlStr, nil
+}
+
+func (session *Session) setUpsertAutoIncr(table *core.Table, bean interface{}, id int64) {
+	aiValue, err := table.AutoIncrColumn().ValueOf(bean)
+	if err != nil {
+		session.Engine.logger.Error(err)
+		return
+	}
+	if aiValue == nil || !aiValue.IsValid() || !aiValue.CanSet() {
+		return
+	}
+	aiValue.Set(int64ToIntValue(id, aiValue.Type()))
+}
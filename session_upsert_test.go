@@ -0,0 +1,119 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+type UpsertUser struct {
+	Id      int64
+	Email   string `xorm:"unique"`
+	Name    string
+	Created time.Time `xorm:"created"`
+	Updated time.Time `xorm:"updated"`
+	Version int       `xorm:"version"`
+}
+
+func TestUpsertUpdateCols(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	table := engine.autoMapType(reflect.ValueOf(UpsertUser{}))
+	colNames := []string{"id", "email", "name", "created", "updated", "version", "extra"}
+
+	var cases = []struct {
+		session func(*Session) *Session
+		cols    []string
+	}{
+		{func(s *Session) *Session { return s }, []string{"name", "updated", "extra", "version"}},
+		// the updated columns are always updated, the version is incremented
+		{func(s *Session) *Session { return s.OnConflictUpdate("Name") }, []string{"name", "updated", "version"}},
+		{func(s *Session) *Session { return s.OnConflictUpdate("extra").NoAutoTime() }, []string{"extra", "version"}},
+		{func(s *Session) *Session { return s.OnConflictUpdate("name").NoVersionCheck() }, []string{"name", "updated"}},
+		{func(s *Session) *Session { return s.OnConflictDoNothing() }, nil},
+		// the conflict columns are never updated
+		{func(s *Session) *Session { return s.OnConflictUpdate("email") }, []string{"updated", "version"}},
+	}
+
+	for i, c := range cases {
+		session := c.session(engine.NewSession())
+		cols := session.upsertUpdateCols(table, colNames, []string{"email"})
+		if !(len(cols) == 0 && len(c.cols) == 0) && !reflect.DeepEqual(cols, c.cols) {
+			t.Errorf("%d: want %v, get %v", i, c.cols, cols)
+		}
+		session.Close()
+	}
+}
+
+func TestUpsertSQL(t *testing.T) {
+	var cases = []struct {
+		dbType  core.DbType
+		session func(*Session) *Session
+		sql     string
+	}{
+		{core.MYSQL, nil,
+			"INSERT INTO `upsert_user` (`email`,`name`,`created`,`updated`,`version`) VALUES (?, ?, ?, ?, ?)" +
+				" ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `updated` = VALUES(`updated`), `version` = `version` + 1"},
+		{core.MYSQL, (*Session).OnConflictDoNothing,
+			"INSERT INTO `upsert_user` (`email`,`name`,`created`,`updated`,`version`) VALUES (?, ?, ?, ?, ?)" +
+				" ON DUPLICATE KEY UPDATE `email` = `email`"},
+		{core.POSTGRES, nil,
+			`INSERT INTO "upsert_user" ("email","name","created","updated","version") VALUES ($1, $2, $3, $4, $5)` +
+				` ON CONFLICT ("email") DO UPDATE SET "name" = excluded."name", "updated" = excluded."updated",` +
+				` "version" = "upsert_user"."version" + 1 RETURNING "id"`},
+		{core.POSTGRES, (*Session).OnConflictDoNothing,
+			`INSERT INTO "upsert_user" ("email","name","created","updated","version") VALUES ($1, $2, $3, $4, $5)` +
+				` ON CONFLICT ("email") DO NOTHING RETURNING "id"`},
+		{core.SQLITE, func(s *Session) *Session { return s.OnConflictUpdate("name").NoVersionCheck() },
+			"INSERT INTO `upsert_user` (`email`,`name`,`created`,`updated`,`version`) VALUES (?, ?, ?, ?, ?)" +
+				" ON CONFLICT (`email`) DO UPDATE SET `name` = excluded.`name`, `updated` = excluded.`updated`"},
+		{core.MSSQL, nil,
+			`MERGE INTO "upsert_user" WITH (HOLDLOCK) AS T USING (SELECT ? AS "email", ? AS "name", ? AS "created", ? AS "updated", ? AS "version") AS S` +
+				` ON (T."email" = S."email")` +
+				` WHEN MATCHED THEN UPDATE SET T."name" = S."name", T."updated" = S."updated", T."version" = T."version" + 1` +
+				` WHEN NOT MATCHED THEN INSERT ("email","name","created","updated","version") VALUES (S."email", S."name", S."created", S."updated", S."version");`},
+		{core.MSSQL, (*Session).OnConflictDoNothing,
+			`MERGE INTO "upsert_user" WITH (HOLDLOCK) AS T USING (SELECT ? AS "email", ? AS "name", ? AS "created", ? AS "updated", ? AS "version") AS S` +
+				` ON (T."email" = S."email")` +
+				` WHEN NOT MATCHED THEN INSERT ("email","name","created","updated","version") VALUES (S."email", S."name", S."created", S."updated", S."version");`},
+		{core.ORACLE, nil,
+			`MERGE INTO "upsert_user" T USING (SELECT :1 "email", :2 "name", :3 "created", :4 "updated", :5 "version" FROM DUAL) S` +
+				` ON (T."email" = S."email")` +
+				` WHEN MATCHED THEN UPDATE SET T."name" = S."name", T."updated" = S."updated", T."version" = T."version" + 1` +
+				` WHEN NOT MATCHED THEN INSERT ("email","name","created","updated","version") VALUES (S."email", S."name", S."created", S."updated", S."version")`},
+	}
+
+	for i, c := range cases {
+		engine := newFakeEngine(t, c.dbType)
+		dsn := string(c.dbType) + "/" + t.Name()
+		takeFakeStmts(dsn)
+		session := engine.NewSession()
+		if c.session != nil {
+			session = c.session(session)
+		}
+		if _, err := session.Upsert(&UpsertUser{Email: "a", Name: "b"}, "email"); err != nil {
+			t.Fatal(err)
+		}
+		if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != c.sql {
+			t.Errorf("%d %s: want\n%s\nget\n%v", i, c.dbType, c.sql, stmts)
+		}
+		session.Close()
+		engine.Close()
+	}
+}
+
+func TestUpsertMergeConflictColumn(t *testing.T) {
+	engine := newFakeEngine(t, core.MSSQL)
+	defer engine.Close()
+
+	// the autoincrement primary key has no value to match on
+	if _, err := engine.Upsert(&UpsertUser{Email: "a"}); err == nil {
+		t.Fatal("want an error")
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+// isTimeVersion reports whether the version column checked by the session
+// holds the time of the last write instead of a counter
+func isTimeVersion(col *core.Column, session *Session) bool {
+	return col.IsVersion && session.Statement.checkVersion && col.SQLType.IsTime()
+}
+
+// versionCondValue returns the value of the version of the bean as written
+// in a condition
+func (session *Session) versionCondValue(col *core.Column, verValue reflect.Value) interface{} {
+	if col.SQLType.IsTime() && verValue.Type().ConvertibleTo(core.TimeType) {
+		return session.Engine.FormatTime(col.SQLType.Name, verValue.Convert(core.TimeType).Interface().(time.Time))
+	}
+	return verValue.Interface()
+}
+
+// nextTimeVersion returns the time of the next version of a time version
+// column, which must differ from the previous one once stored in seconds
+func (session *Session) nextTimeVersion(col *core.Column, verValue reflect.Value) (interface{}, time.Time) {
+	val, t := session.Engine.NowTime2(col.SQLType.Name)
+	if verValue.Type().ConvertibleTo(core.TimeType) {
+		prev := verValue.Convert(core.TimeType).Interface().(time.Time).Truncate(time.Second)
+		if !t.Truncate(time.Second).After(prev) {
+			t = prev.Add(time.Second)
+			val = session.Engine.FormatTime(col.SQLType.Name, t)
+		}
+	}
+	return val, t
+}
+
+// optimisticLockError returns the error of an Update or a Delete of the bean
+// which changed no record, or nil when the statement does not target one
+// record by ID or by the primary key of the bean
+func (session *Session) optimisticLockError(table *core.Table, bean interface{}, version interface{}) error {
+	var pk core.PK
+	if session.Statement.IdParam != nil && len(*session.Statement.IdParam) > 0 {
+		pk = *session.Statement.IdParam
+	} else {
+		v := rValue(bean)
+		if v.Kind() != reflect.Struct || len(table.PrimaryKeys) == 0 {
+			return nil
+		}
+		for _, col := range table.PKColumns() {
+			fieldValue, err := col.ValueOfV(&v)
+			if err != nil {
+				return nil
+			}
+			pk = append(pk, fieldValue.Interface())
+		}
+		if isPKZero(pk) {
+			return nil
+		}
+	}
+	return &OptimisticLockError{
+		Table:   session.Statement.TableName(),
+		PK:      pk,
+		Version: version,
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coscms/xorm/core"
+)
+
+type VersionUser struct {
+	Id      int64
+	Name    string
+	Age     int
+	Version int `xorm:"version"`
+}
+
+type TimeVersionUser struct {
+	Id      int64
+	Name    string
+	Version time.Time `xorm:"version"`
+}
+
+func TestOptimisticLock(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	var cases = []struct {
+		name    string
+		run     func(user *VersionUser) (int64, error)
+		sql     string
+		version int // version of the bean after a write
+		locked  bool
+	}{
+		{"update", func(user *VersionUser) (int64, error) {
+			return engine.ID(user.Id).Update(user)
+		}, "UPDATE `version_user` SET `name` = ?, `age` = ?, `version` = `version` + 1 WHERE `id`=? AND `version`=?", 4, true},
+		{"update by the primary key of the bean", func(user *VersionUser) (int64, error) {
+			return engine.Update(user, &VersionUser{Id: user.Id})
+		}, "UPDATE `version_user` SET `name` = ?, `age` = ?, `version` = `version` + 1 WHERE `id`=? AND `version`=?", 4, true},
+		{"partial update", func(user *VersionUser) (int64, error) {
+			return engine.ID(user.Id).Cols("name").Update(user)
+		}, "UPDATE `version_user` SET `name` = ?, `version` = `version` + 1 WHERE `id`=? AND `version`=?", 4, true},
+		{"partial update of a zero value", func(user *VersionUser) (int64, error) {
+			user.Age = 0
+			return engine.ID(user.Id).MustCols("age").Update(user)
+		}, "UPDATE `version_user` SET `name` = ?, `age` = ?, `version` = `version` + 1 WHERE `id`=? AND `version`=?", 4, true},
+		// a bulk update changing no record is not an optimistic lock failure
+		{"bulk update", func(user *VersionUser) (int64, error) {
+			return engine.Where("age > ?", 1).Update(&VersionUser{Name: "b"})
+		}, "UPDATE `version_user` SET `name` = ?, `version` = `version` + 1 WHERE age > ? AND `version`=?", 3, false},
+		// the version is written like the other columns
+		{"update without version check", func(user *VersionUser) (int64, error) {
+			return engine.NoVersionCheck().ID(user.Id).Update(user)
+		}, "UPDATE `version_user` SET `name` = ?, `age` = ?, `version` = ? WHERE `id`=?", 3, false},
+		{"delete", func(user *VersionUser) (int64, error) {
+			return engine.Delete(user)
+		}, "DELETE FROM `version_user` WHERE `id`=? AND `name`=? AND `age`=? AND `version`=?", 3, true},
+		// the version is still checked without the conditions of the bean
+		{"delete without auto condition", func(user *VersionUser) (int64, error) {
+			return engine.NoAutoCondition().ID(user.Id).Delete(user)
+		}, "DELETE FROM `version_user` WHERE `version`=? AND `id`=?", 3, true},
+		{"delete without version check", func(user *VersionUser) (int64, error) {
+			return engine.NoAutoCondition().NoVersionCheck().ID(user.Id).Delete(user)
+		}, "DELETE FROM `version_user` WHERE `id`=?", 3, false},
+	}
+
+	for _, c := range cases {
+		for _, affected := range []int64{1, 0} {
+			user := &VersionUser{Id: 1, Name: "a", Age: 2, Version: 3}
+			queueFakeAffected(dsn, affected)
+			n, err := c.run(user)
+			if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != c.sql {
+				t.Errorf("%s: want\n%s\nget\n%v", c.name, c.sql, stmts)
+			}
+
+			if affected == 1 || !c.locked {
+				if err != nil || n != affected {
+					t.Errorf("%s %d: get %d %v", c.name, affected, n, err)
+				}
+				if affected == 1 && user.Version != c.version {
+					t.Errorf("%s: want the version %d, get %d", c.name, c.version, user.Version)
+				}
+				continue
+			}
+
+			// the record was changed or deleted since its version was read
+			lockErr, ok := err.(*OptimisticLockError)
+			if !ok || !errors.Is(err, ErrOptimisticLock) {
+				t.Errorf("%s: want an OptimisticLockError, get %v", c.name, err)
+				continue
+			}
+			want := &OptimisticLockError{Table: "version_user", PK: core.PK{int64(1)}, Version: 3}
+			if !reflect.DeepEqual(lockErr, want) || user.Version != 3 {
+				t.Errorf("%s: want %+v, get %+v %d", c.name, want, lockErr, user.Version)
+			}
+		}
+	}
+}
+
+func TestTimeVersion(t *testing.T) {
+	engine := newFakeEngine(t, core.MYSQL)
+	defer engine.Close()
+	dsn := "mysql/" + t.Name()
+
+	// the next version differs from the previous one once stored in seconds
+	version := time.Now().Add(time.Hour).Truncate(time.Second)
+	user := &TimeVersionUser{Id: 1, Name: "a", Version: version}
+	if _, err := engine.ID(1).Update(user); err != nil {
+		t.Fatal(err)
+	}
+	want := "UPDATE `time_version_user` SET `name` = ?, `version` = ? WHERE `id`=? AND `version`=?"
+	if stmts := takeFakeStmts(dsn); len(stmts) != 1 || stmts[0] != want {
+		t.Errorf("want %s, get %v", want, stmts)
+	}
+	if !user.Version.Equal(version.Add(time.Second)) {
+		t.Errorf("want the version %v, get %v", version.Add(time.Second), user.Version)
+	}
+
+	queueFakeAffected(dsn, 0)
+	_, err := engine.ID(1).Update(user)
+	if lockErr, ok := err.(*OptimisticLockError); !ok || lockErr.Version != engine.FormatTime(core.DateTime, version.Add(time.Second)) {
+		t.Errorf("want an OptimisticLockError, get %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+// Copyright 2015 The Xorm Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xorm
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/coscms/xorm/core"
+)
+
+type scanState int
+
+const (
+	scanNormal      scanState = iota
+	scanQuote                 // '...', "..." or `...`
+	scanBracket               // [...] of MSSQL
+	scanComment               // /* ... */
+	scanKeptComment           // /*! ... */ of MySQL
+	scanDollar                // $tag$ ... $tag$ of PostgreSQL
+)
+
+var (
+	delimiterRegexp = regexp.MustCompile(`(?i)^\s*DELIMITER\s+(\S+)\s*$`)
+	goRegexp        = regexp.MustCompile(`(?i)^\s*GO(\s+\d+)?\s*$`)
+	dollarTagRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z_0-9]*)?\$`)
+	plsqlRegexp     = regexp.MustCompile(`(?i)^(BEGIN|DECLARE|CREATE\s+(OR\s+REPLACE\s+)?(PROCEDURE|FUNCTION|TRIGGER|PACKAGE|TYPE)\b)`)
+)
+
+// SQLScanner reads the statements of a SQL script one by one. It knows the
+// string literals, quoted identifiers and comments of the dialect, the dollar
+// quoted bodies of PostgreSQL, the DELIMITER command of MySQL, the GO batch
+// separator of MSSQL, which replaces the semicolon and whose count repeats
+// the batch, and the PL/SQL blocks of
+// Oracle ended by a slash line. Comments are removed from the statements,
+// except the MySQL executable comments.
+type SQLScanner struct {
+	r         *bufio.Reader
+	dbType    core.DbType
+	delimiter string
+
+	pending string // rest of the current line
+	repeat  int    // times the last statement is scanned again
+	eof     bool
+	err     error
+
+	state     scanState
+	quote     byte
+	escapes   bool // backslashes escape in the current quote
+	depth     int  // nesting of block comments
+	dollarTag string
+
+	buf       strings.Builder
+	started   bool
+	stmt      string
+	line      int
+	stmtLine  int
+	startLine int
+	bytesRead int64
+}
+
+// NewSQLScanner returns a scanner of the SQL script of r written for the
+// database type
+func NewSQLScanner(r io.Reader, dbType core.DbType) *SQLScanner {
+	return &SQLScanner{
+		r:         bufio.NewReader(r),
+		dbType:    dbType,
+		delimiter: ";",
+	}
+}
+
+// SplitSQL splits a SQL script written for the database type into statements
+func SplitSQL(script string, dbType core.DbType) []string {
+	var statements []string
+	scanner := NewSQLScanner(strings.NewReader(script), dbType)
+	for scanner.Scan() {
+		statements = append(statements, scanner.Statement())
+	}
+	return statements
+}
+
+// Statement returns the statement read by the last Scan, without delimiter
+func (s *SQLScanner) Statement() string {
+	return s.stmt
+}
+
+// Line returns the line where the statement read by the last Scan starts
+func (s *SQLScanner) Line() int {
+	return s.stmtLine
+}
+
+// BytesRead returns the number of bytes of the script read so far
+func (s *SQLScanner) BytesRead() int64 {
+	return s.bytesRead
+}
+
+// Err returns the read error which stopped Scan
+func (s *SQLScanner) Err() error {
+	return s.err
+}
+
+// Scan reads the next statement, it returns false at the end of the script
+// or on a read error
+func (s *SQLScanner) Scan() bool {
+	if s.repeat > 0 {
+		s.repeat--
+		return true
+	}
+	s.stmt = ""
+	for {
+		if s.pending == "" {
+			if s.eof || s.err != nil {
+				return s.flush()
+			}
+			line, err := s.r.ReadString('\n')
+			if err == io.EOF {
+				s.eof = true
+			} else if err != nil {
+				s.err = err
+			}
+			if line == "" {
+				continue
+			}
+			s.line++
+			s.bytesRead += int64(len(line))
+
+			if s.state == scanNormal {
+				if s.dbType == core.MYSQL && !s.started {
+					if m := delimiterRegexp.FindStringSubmatch(line); m != nil {
+						s.delimiter = m[1]
+						continue
+					}
+				}
+				if s.dbType == core.MSSQL {
+					if m := goRegexp.FindStringSubmatch(line); m != nil {
+						if s.flush() {
+							if n, _ := strconv.Atoi(strings.TrimSpace(m[1])); n > 1 {
+								s.repeat = n - 1
+							}
+							return true
+						}
+						continue
+					}
+				}
+				if s.dbType == core.ORACLE && strings.TrimSpace(line) == "/" {
+					if s.flush() {
+						return true
+					}
+					continue
+				}
+			}
+			s.pending = line
+		}
+
+		if s.scanPending() {
+			return true
+		}
+	}
+}
+
+// flush ends the current statement, it returns false if it's empty
+func (s *SQLScanner) flush() bool {
+	s.stmt = strings.TrimSpace(s.buf.String())
+	s.stmtLine = s.startLine
+	s.buf.Reset()
+	s.started = false
+	return s.stmt != ""
+}
+
+func (s *SQLScanner) write(str string) {
+	if !s.started && strings.TrimSpace(str) != "" {
+		s.started = true
+		s.startLine = s.line
+	}
+	s.buf.WriteString(str)
+}
+
+// scanPending consumes the pending line until the end of a statement, it
+// returns true when a statement is complete
+func (s *SQLScanner) scanPending() bool {
+	line := s.pending
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		switch s.state {
+		case scanQuote:
+			if c == '\\' && s.escapes && i+1 < len(line) {
+				s.write(line[i : i+2])
+				i += 2
+				continue
+			}
+			if c == s.quote {
+				if i+1 < len(line) && line[i+1] == s.quote {
+					s.write(line[i : i+2])
+					i += 2
+					continue
+				}
+				s.state = scanNormal
+			}
+			s.write(line[i : i+1])
+			i++
+			continue
+		case scanBracket:
+			if c == ']' {
+				if i+1 < len(line) && line[i+1] == ']' {
+					s.write("]]")
+					i += 2
+					continue
+				}
+				s.state = scanNormal
+			}
+			s.write(line[i : i+1])
+			i++
+			continue
+		case scanComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				s.depth--
+				i += 2
+				if s.depth == 0 {
+					s.state = scanNormal
+					s.write(" ")
+				}
+				continue
+			}
+			if c == '/' && i+1 < len(line) && line[i+1] == '*' && s.dbType == core.POSTGRES {
+				s.depth++
+				i += 2
+				continue
+			}
+			i++
+			continue
+		case scanKeptComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				s.write("*/")
+				s.state = scanNormal
+				i += 2
+				continue
+			}
+			s.write(line[i : i+1])
+			i++
+			continue
+		case scanDollar:
+			end := "$" + s.dollarTag + "$"
+			if strings.HasPrefix(line[i:], end) {
+				s.write(end)
+				s.state = scanNormal
+				i += len(end)
+				continue
+			}
+			s.write(line[i : i+1])
+			i++
+			continue
+		}
+
+		if s.isDelimiter(line[i:]) {
+			s.pending = line[i+len(s.delimiter):]
+			if s.flush() {
+				return true
+			}
+			line, i = s.pending, 0
+			continue
+		}
+
+		switch {
+		case c == '-' && strings.HasPrefix(line[i:], "--") && s.isLineComment(line[i+2:]),
+			c == '#' && s.dbType == core.MYSQL:
+			// drop the rest of the line but its end
+			if strings.HasSuffix(line, "\n") {
+				s.write("\n")
+			}
+			s.pending = ""
+			return false
+		case c == '/' && strings.HasPrefix(line[i:], "/*"):
+			if s.dbType == core.MYSQL && strings.HasPrefix(line[i:], "/*!") {
+				s.state = scanKeptComment
+				s.write("/*!")
+				i += 3
+			} else {
+				s.state = scanComment
+				s.depth = 1
+				i += 2
+			}
+			continue
+		case c == '\'' || c == '"' || (c == '`' && s.dbType != core.POSTGRES && s.dbType != core.MSSQL):
+			s.state = scanQuote
+			s.quote = c
+			s.escapes = s.dbType == core.MYSQL ||
+				(c == '\'' && s.dbType == core.POSTGRES && i > 0 && (line[i-1] == 'E' || line[i-1] == 'e'))
+		case c == '[' && s.dbType == core.MSSQL:
+			s.state = scanBracket
+		case c == '$' && s.dbType == core.POSTGRES && (i == 0 || !isIdentByte(line[i-1])):
+			if m := dollarTagRegexp.FindStringSubmatch(line[i:]); m != nil {
+				s.state = scanDollar
+				s.dollarTag = m[1]
+				s.write(m[0])
+				i += len(m[0])
+				continue
+			}
+		}
+		s.write(line[i : i+1])
+		i++
+	}
+	s.pending = ""
+	return false
+}
+
+// isDelimiter reports whether the text starts with the delimiter which ends
+// the current statement
+func (s *SQLScanner) isDelimiter(text string) bool {
+	if !strings.HasPrefix(text, s.delimiter) {
+		return false
+	}
+	switch s.dbType {
+	case core.MSSQL:
+		// the statements of a batch are run together
+		return false
+	case core.ORACLE:
+		// the PL/SQL blocks end with a slash line
+		return !plsqlRegexp.MatchString(strings.TrimSpace(s.buf.String()))
+	}
+	return true
+}
+
+// isLineComment reports whether the text following -- makes it a comment,
+// MySQL requires a space after it
+func (s *SQLScanner) isLineComment(rest string) bool {
+	if s.dbType != core.MYSQL {
+		return true
+	}
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n'
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
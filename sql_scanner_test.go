@@ -0,0 +1,53 @@
+package xorm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coscms/xorm/core"
+)
+
+func TestSplitSQL(t *testing.T) {
+	var cases = []struct {
+		dbType     core.DbType
+		script     string
+		statements []string
+	}{
+		{core.SQLITE, "", nil},
+		{core.SQLITE, "CREATE TABLE a (id INT);", []string{"CREATE TABLE a (id INT)"}},
+		{core.SQLITE, "INSERT INTO a VALUES ('x;y'); -- comment;\nDELETE FROM a",
+			[]string{"INSERT INTO a VALUES ('x;y')", "DELETE FROM a"}},
+		{core.SQLITE, "/* a; b */ UPDATE a SET b = 'it''s';", []string{"UPDATE a SET b = 'it''s'"}},
+		{core.SQLITE, "SELECT \"a;\" FROM `b;`;;SELECT 2", []string{"SELECT \"a;\" FROM `b;`", "SELECT 2"}},
+		{core.MYSQL, "INSERT INTO a VALUES ('x\\';y'); # comment;\nSELECT 1--1;",
+			[]string{"INSERT INTO a VALUES ('x\\';y')", "SELECT 1--1"}},
+		{core.MYSQL, "/*!40101 SET NAMES utf8 */;\nDELIMITER ;;\nCREATE PROCEDURE p() BEGIN SELECT 1; END ;;\nDELIMITER ;\nCALL p();",
+			[]string{"/*!40101 SET NAMES utf8 */", "CREATE PROCEDURE p() BEGIN SELECT 1; END", "CALL p()"}},
+		{core.POSTGRES, "CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;\nSELECT $1, E'a\\';b', $tag$;$tag$;",
+			[]string{"CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql", "SELECT $1, E'a\\';b', $tag$;$tag$"}},
+		{core.POSTGRES, "/* a /* nested; */ comment; */ SELECT 1;", []string{"SELECT 1"}},
+		{core.MSSQL, "CREATE TABLE [a;b] (id INT);\nINSERT INTO [a;b] VALUES (1);\nGO\nSELECT 1\ngo 2\nSELECT 2\nGO 1",
+			[]string{"CREATE TABLE [a;b] (id INT);\nINSERT INTO [a;b] VALUES (1);", "SELECT 1", "SELECT 1", "SELECT 2"}},
+		{core.ORACLE, "INSERT INTO a VALUES (1);\nBEGIN\n  NULL;\nEND;\n/\nSELECT 1 FROM dual;",
+			[]string{"INSERT INTO a VALUES (1)", "BEGIN\n  NULL;\nEND;", "SELECT 1 FROM dual"}},
+	}
+
+	for _, c := range cases {
+		statements := SplitSQL(c.script, c.dbType)
+		if !reflect.DeepEqual(statements, c.statements) {
+			t.Errorf("SplitSQL(%q, %s) = %q, want %q", c.script, c.dbType, statements, c.statements)
+		}
+	}
+}
+
+func TestSQLScannerLine(t *testing.T) {
+	scanner := NewSQLScanner(strings.NewReader("-- header\n\nSELECT 1;\nSELECT\n2;"), core.SQLITE)
+	var lines []int
+	for scanner.Scan() {
+		lines = append(lines, scanner.Line())
+	}
+	if !reflect.DeepEqual(lines, []int{3, 4}) {
+		t.Errorf("got lines %v, want [3 4]", lines)
+	}
+}
@@ -6,6 +6,7 @@ package xorm
 
 import (
 	"bytes"
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
@@ -33,6 +34,11 @@ type exprParam struct {
 	expr    string
 }
 
+type jsonSetParam struct {
+	colName string
+	values  map[string]interface{} // path: value
+}
+
 // Statement save all the sql info for executing SQL
 type Statement struct {
 	RefTable        *core.Table
@@ -67,12 +73,22 @@ type Statement struct {
 	allUseBool      bool
 	checkVersion    bool
 	unscoped        bool
+	onlyDeleted     bool
 	mustColumnMap   map[string]bool
 	nullableMap     map[string]bool
 	incrColumns     map[string]incrParam
 	decrColumns     map[string]decrParam
 	exprColumns     map[string]exprParam
+	jsonColumns     map[string]jsonSetParam
 	cond            builder.Cond
+	upsertCols      []string
+	upsertDoNothing bool
+	paginate        *paginateParam
+	preloads        []preloadParam
+	cascadeWrites   []preloadParam
+	ctx             context.Context
+	scopesOff       bool
+	noScopes        map[string]bool
 
 	//[SWH|+]
 	joinTables    *joinTables
@@ -112,10 +128,17 @@ func (statement *Statement) Init() {
 	statement.nullableMap = make(map[string]bool)
 	statement.checkVersion = true
 	statement.unscoped = false
+	statement.onlyDeleted = false
 	statement.incrColumns = make(map[string]incrParam)
 	statement.decrColumns = make(map[string]decrParam)
 	statement.exprColumns = make(map[string]exprParam)
+	statement.jsonColumns = make(map[string]jsonSetParam)
 	statement.cond = builder.NewCond()
+	statement.upsertCols = nil
+	statement.upsertDoNothing = false
+	statement.paginate = nil
+	statement.preloads = nil
+	statement.cascadeWrites = nil
 
 	//[SWH|+]
 	statement.joinTables = newJoinTables(statement)
@@ -132,6 +155,16 @@ func (statement *Statement) NoAutoCondition(no ...bool) *Statement {
 	return statement
 }
 
+// NoVersionCheck disables the optimistic locking of the version column, which
+// is then written like the other columns
+func (statement *Statement) NoVersionCheck(no ...bool) *Statement {
+	statement.checkVersion = false
+	if len(no) > 0 {
+		statement.checkVersion = !no[0]
+	}
+	return statement
+}
+
 // Alias set the table alias
 func (statement *Statement) Alias(alias string) *Statement {
 	statement.TableAlias = alias
@@ -143,7 +176,7 @@ func (statement *Statement) SQL(query interface{}, args ...interface{}) *Stateme
 	switch query.(type) {
 	case (*builder.Builder):
 		var err error
-		statement.RawSQL, statement.RawParams, err = query.(*builder.Builder).ToSQL()
+		statement.RawSQL, statement.RawParams, err = statement.builderToSQL(query.(*builder.Builder))
 		if err != nil {
 			statement.Engine.logger.Error(err)
 		}
@@ -157,6 +190,54 @@ func (statement *Statement) SQL(query interface{}, args ...interface{}) *Stateme
 	return statement
 }
 
+// builderToSQL writes b in the dialect of the engine, the placeholders are
+// left as ? to the filters of the dialect
+func (statement *Statement) builderToSQL(b *builder.Builder) (string, []interface{}, error) {
+	w := builder.NewDialectWriter(string(statement.Engine.dialect.DBType()), nil)
+	if err := b.WriteTo(w); err != nil {
+		return "", nil, err
+	}
+	return w.String(), w.Args(), nil
+}
+
+// builderCond returns the SQL of b as a condition, b is usually a builder
+// of conditions only, e.g. builder.Dialect(core.MYSQL).Where(cond)
+func (statement *Statement) builderCond(b *builder.Builder) builder.Cond {
+	sqlStr, args, err := statement.builderToSQL(b)
+	if err != nil {
+		statement.Engine.logger.Error(err)
+		return nil
+	}
+	if len(sqlStr) == 0 {
+		return nil
+	}
+	return builder.Expr("("+sqlStr+")", args...)
+}
+
+// condToSQL writes cond in the dialect of the engine with its keys quoted,
+// the placeholders are left as ? to the filters of the dialect
+func (statement *Statement) condToSQL(cond builder.Cond) (string, []interface{}, error) {
+	return statement.dialectCondToSQL(cond, statement.Engine.QuoteKey)
+}
+
+// dialectCondToSQL writes cond in the dialect of the engine like condToSQL,
+// its keys are written by keyFilter, or as they are when it's nil
+func (statement *Statement) dialectCondToSQL(cond builder.Cond, keyFilter func(string) string) (string, []interface{}, error) {
+	if cond == nil || !cond.IsValid() {
+		return "", nil, nil
+	}
+	if keyFilter == nil {
+		keyFilter = func(key string) string {
+			return key
+		}
+	}
+	w := builder.NewDialectWriter(string(statement.Engine.dialect.DBType()), keyFilter)
+	if err := cond.WriteTo(w); err != nil {
+		return "", nil, err
+	}
+	return w.String(), w.Args(), nil
+}
+
 // Where add Where statment
 func (statement *Statement) Where(query interface{}, args ...interface{}) *Statement {
 	return statement.And(query, args...)
@@ -175,6 +256,8 @@ func (statement *Statement) And(query interface{}, args ...interface{}) *Stateme
 				statement.cond = statement.cond.And(vv)
 			}
 		}
+	case *builder.Builder:
+		statement.cond = statement.cond.And(statement.builderCond(q))
 	default:
 		// TODO: not support condition type
 	}
@@ -195,6 +278,8 @@ func (statement *Statement) Or(query interface{}, args ...interface{}) *Statemen
 				statement.cond = statement.cond.Or(vv)
 			}
 		}
+	case *builder.Builder:
+		statement.cond = statement.cond.Or(statement.builderCond(q))
 	default:
 		// TODO: not support condition type
 	}
@@ -441,7 +526,18 @@ func buildUpdates(engine *Engine, table *core.Table, bean interface{},
 				}
 			}
 
-			if col.SQLType.IsText() {
+			if col.IsArray {
+				if fieldType.Kind() == reflect.Slice && fieldValue.IsNil() {
+					val = nil
+				} else {
+					s, err := core.ArrayLiteral(fieldValue)
+					if err != nil {
+						engine.logger.Error(err)
+						continue
+					}
+					val = s
+				}
+			} else if col.SQLType.IsText() {
 				bytes, err := json.Marshal(fieldValue.Interface())
 				if err != nil {
 					engine.logger.Error(err)
@@ -542,9 +638,10 @@ func (statement *Statement) buildConds(table *core.Table, bean interface{}, incl
 			continue
 		}
 
-		if col.IsDeleted && !statement.unscoped { // tag "deleted" is enabled
-			conds = append(conds, builder.IsNull{colName}.Or(builder.Eq{colName: "0001-01-01 00:00:00"}))
-
+		if col.IsDeleted { // tag "deleted" is enabled
+			if cond := statement.deletedCond(colName); cond != nil {
+				conds = append(conds, cond)
+			}
 		}
 
 		fieldValue := *fieldValuePtr
@@ -676,7 +773,14 @@ func (statement *Statement) buildConds(table *core.Table, bean interface{}, incl
 				continue
 			}
 
-			if col.SQLType.IsText() {
+			if col.IsArray {
+				s, err := core.ArrayLiteral(fieldValue)
+				if err != nil {
+					engine.logger.Error(err)
+					continue
+				}
+				val = s
+			} else if col.SQLType.IsText() {
 				bytes, err := json.Marshal(fieldValue.Interface())
 				if err != nil {
 					engine.logger.Error(err)
@@ -781,6 +885,19 @@ func (statement *Statement) SetExpr(column string, expression string) *Statement
 	return statement
 }
 
+// SetJSON Generate  "Update ... Set column = {column with value at path}"
+// statment, see builder.JSONSet
+func (statement *Statement) SetJSON(column, path string, value interface{}) *Statement {
+	k := strings.ToLower(column)
+	param, ok := statement.jsonColumns[k]
+	if !ok {
+		param = jsonSetParam{column, make(map[string]interface{})}
+		statement.jsonColumns[k] = param
+	}
+	param.values[path] = value
+	return statement
+}
+
 // Generate  "Update ... Set column = column + arg" statment
 func (statement *Statement) getInc() map[string]incrParam {
 	return statement.incrColumns
@@ -964,6 +1081,26 @@ func (statement *Statement) Unscoped() *Statement {
 	return statement
 }
 
+// OnlyDeleted selects the soft deleted records only, the records joined by a
+// relation are still the ones which are not deleted
+func (statement *Statement) OnlyDeleted() *Statement {
+	statement.unscoped = true
+	statement.onlyDeleted = true
+	return statement
+}
+
+// deletedCond returns the condition of the deleted column of the queried
+// records, nil when the statement is unscoped
+func (statement *Statement) deletedCond(colName string) builder.Cond {
+	if statement.onlyDeleted {
+		return builder.NotNull{colName}.And(builder.Neq{colName: "0001-01-01 00:00:00"})
+	}
+	if statement.unscoped {
+		return nil
+	}
+	return builder.IsNull{colName}.Or(builder.Eq{colName: "0001-01-01 00:00:00"})
+}
+
 func (statement *Statement) genColumnStr() string {
 	if len(statement.selectStr) > 0 {
 		return statement.selectStr
@@ -1125,10 +1262,11 @@ func (statement *Statement) genConds(bean interface{}) (string, []interface{}, e
 		}
 		statement.cond = statement.cond.And(autoCond)
 	}
+	statement.cond = statement.cond.And(statement.refScopeCond())
 
 	statement.processIdParam()
 
-	return builder.ToSQL(statement.cond, statement.Engine.QuoteKey)
+	return statement.condToSQL(statement.cond)
 }
 
 func (statement *Statement) genGetSQL(bean interface{}) (string, []interface{}) {
@@ -1169,6 +1307,16 @@ func (statement *Statement) genCountSQL(bean interface{}) (string, []interface{}
 	return statement.genSelectSQL("count(*)", condSQL), append(statement.joinArgs, condArgs...)
 }
 
+// genFindCountSQL generates the count of the records Find returns, the grouped
+// or distinct records are counted by a subquery
+func (statement *Statement) genFindCountSQL(bean interface{}) (string, []interface{}) {
+	if len(statement.GroupByStr) == 0 && !statement.IsDistinct {
+		return statement.genCountSQL(bean)
+	}
+	sqlStr, args := statement.genGetSQL(bean)
+	return fmt.Sprintf("SELECT count(*) FROM (%v) %v", sqlStr, statement.Engine.Quote("xorm_count")), args
+}
+
 func (statement *Statement) genSumSQL(bean interface{}, columns ...string) (string, []interface{}) {
 	statement.setRefValue(rValue(bean))
 
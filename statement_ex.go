@@ -29,6 +29,7 @@ func newJoinTables(stmt *Statement) *joinTables {
 type joinTables struct {
 	params    []*joinParam
 	statement *Statement
+	args      []interface{} // args of the joins of the relation
 }
 
 func (j *joinTables) New(stmt *Statement) *joinParam {
@@ -58,19 +59,20 @@ func (j *joinTables) String() string {
 			t = ` `
 		}
 	} else {
-		joinStr = j.fromRelation()
+		joinStr, j.args = j.fromRelation()
 	}
 	return joinStr
 }
 
-func (j *joinTables) fromRelation() string {
+func (j *joinTables) fromRelation() (string, []interface{}) {
 	r := j.statement.relation
 	if r == nil || r.IsTable {
-		return ``
+		return ``, nil
 	}
 	var (
 		joinStr string
 		t       string
+		args    []interface{}
 	)
 	for i, table := range r.Extends {
 		rt := r.RelTables[i]
@@ -83,17 +85,34 @@ func (j *joinTables) fromRelation() string {
 			s += ` AS ` + j.statement.Engine.Quote(alias)
 		}
 		s += ` ON ` + rt.Where
+		name := alias
+		if len(name) == 0 {
+			name = rt.TableName
+		}
+		// the soft deleted records of a joined table are not joined, the
+		// records of the main table are kept by outer joins. OnlyDeleted
+		// selects the deleted records of the main table only.
+		if col := table.DeletedColumn(); col != nil && (!j.statement.unscoped || j.statement.onlyDeleted) {
+			colName := j.statement.Engine.Quote(name) + `.` + j.statement.Engine.Quote(col.Name)
+			s += ` AND (` + colName + ` IS NULL OR ` + colName + ` = '0001-01-01 00:00:00')`
+		}
+		// so are the records out of the scopes of the joined table
+		if cond := j.statement.scopeCond(table, name); cond != nil && cond.IsValid() {
+			condSQL, condArgs, _ := j.statement.condToSQL(cond)
+			s += ` AND (` + condSQL + `)`
+			args = append(args, condArgs...)
+		}
 		joinStr += t + s
 		t = ` `
 	}
-	return joinStr
+	return joinStr, args
 }
 
 func (j *joinTables) Args() (args []interface{}) {
 	for _, join := range j.params {
 		args = append(args, join.Args...)
 	}
-	return
+	return append(args, j.args...)
 }
 
 func NewJoinParam(stmt *Statement) *joinParam {